@@ -2,19 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"backend/internal/domain"
 	"backend/internal/handler"
+	"backend/internal/infra/card"
 	infraDB "backend/internal/infra/db"
 	"backend/internal/repository"
 	"backend/internal/service"
@@ -32,7 +38,16 @@ func main() {
 	}
 	defer pool.Close()
 
-	server := newHTTPServer(pool, logger)
+	server, jobs := newHTTPServer(pool, logger)
+
+	var jobsDone sync.WaitGroup
+	for _, job := range jobs {
+		jobsDone.Add(1)
+		go func() {
+			defer jobsDone.Done()
+			job.Run(ctx)
+		}()
+	}
 
 	go func() {
 		<-ctx.Done()
@@ -51,44 +66,111 @@ func main() {
 		logger.Fatalf("server stopped with error: %v", err)
 	}
 
+	// 処理中の結果生成ジョブを確定させてから終了する。
+	stop()
+	jobsDone.Wait()
+
 	logger.Println("server stopped")
 }
 
-func newHTTPServer(pool *pgxpool.Pool, logger *log.Logger) *http.Server {
+// backgroundJob はサーバーと一緒に動かし、ctx が終わるまで戻らない処理。
+type backgroundJob interface {
+	Run(ctx context.Context)
+}
+
+func newHTTPServer(pool *pgxpool.Pool, logger *log.Logger) (*http.Server, []backgroundJob) {
+	handler, jobs := newHTTPHandler(pool, logger)
 	return &http.Server{
 		Addr:              serverAddr(),
-		Handler:           newHTTPHandler(pool, logger),
+		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 		WriteTimeout:      15 * time.Second,
 		IdleTimeout:       60 * time.Second,
 		ErrorLog:          logger,
-	}
+	}, jobs
 }
 
-func newHTTPHandler(pool *pgxpool.Pool, logger *log.Logger) http.Handler {
+func newHTTPHandler(pool *pgxpool.Pool, logger *log.Logger) (http.Handler, []backgroundJob) {
 	userRepo := repository.NewUserRepository(pool)
-	sessionRepo := repository.NewLoginSessionRepository(pool)
+	sessionPepper, err := loadSessionTokenPepper(logger)
+	if err != nil {
+		logger.Fatalf("session token pepper error: %v", err)
+	}
+	sessionRepo := repository.NewLoginSessionRepository(pool, sessionPepper)
+	refreshRepo := repository.NewRefreshTokenRepository(pool)
 	hueRepo := repository.NewHueRepository(pool)
+	hueJobRepo := repository.NewHueJobRepository(pool)
+	huePromptRepo := repository.NewHuePromptRepository(pool)
+	hueUsageRepo := repository.NewHueUsageRepository(pool)
+	hueCacheRepo := repository.NewHueResultCacheRepository(pool)
+	hueClusterRepo := repository.NewHueClusterRepository(pool)
 
-	signInService := service.NewSignInService(userRepo, sessionRepo, logger)
-	loginService := service.NewLoginService(userRepo, sessionRepo, logger)
-	hueCfg, err := loadHueSaveConfig()
+	signInService := service.NewSignInService(userRepo, sessionRepo, refreshRepo, sessionPepper, logger)
+	loginService := service.NewLoginService(userRepo, sessionRepo, refreshRepo, sessionPepper, logger)
+	authService := service.NewAuthService(sessionRepo, userRepo, logger)
+	sessionService := service.NewSessionService(sessionRepo, refreshRepo, userRepo, sessionPepper, logger)
+	sessionCookies, err := loadSessionCookies()
 	if err != nil {
-		logger.Fatalf("hue save config error: %v", err)
+		logger.Fatalf("session cookie config error: %v", err)
 	}
-	hueSaveService, err := service.NewHueSaveService(hueRepo, logger, hueCfg)
+	hueGenerator, err := service.NewHueResultGenerator(loadHueGeneratorConfig(), http.DefaultClient)
+	if err != nil {
+		logger.Fatalf("hue generator init error: %v", err)
+	}
+	hueUsageConfig, err := loadHueUsageConfig()
+	if err != nil {
+		logger.Fatalf("hue usage config error: %v", err)
+	}
+	hueBroker := service.NewHueMessageBroker()
+	hueCache := service.NewHueResultCache(hueCacheRepo, logger, service.HueResultCacheConfig{})
+	hueSaveService, err := service.NewHueSaveService(hueRepo, huePromptRepo, hueUsageRepo, hueCache, hueGenerator, service.NewRuleBasedHueResultGenerator(hueRepo), hueBroker, logger, service.HueSaveConfig{Usage: hueUsageConfig})
 	if err != nil {
 		logger.Fatalf("hue save service init error: %v", err)
 	}
-	hueGetService := service.NewHueGetService(hueRepo, sessionRepo, userRepo, logger)
+	hueGetService := service.NewHueGetService(hueRepo, logger)
+	huePromptService := service.NewHuePromptService(huePromptRepo, logger)
+	hueUsageService := service.NewHueUsageService(hueUsageRepo, hueCache, logger, hueUsageConfig)
+	hueWorker := service.NewHueGenerationWorker(hueJobRepo, hueSaveService, logger, service.HueWorkerConfig{})
+	hueResultService := service.NewHueResultService(hueRepo, hueJobRepo, logger)
+	hueStreamService := service.NewHueStreamService(hueResultService, hueBroker, logger)
+	hueCardService := service.NewHueCardService(hueRepo, logger)
+	hueQuestionnaireService := service.NewHueQuestionnaireService()
+	hueClusterConfig, err := loadHueClusterConfig()
+	if err != nil {
+		logger.Fatalf("hue cluster config error: %v", err)
+	}
+	hueClusterService := service.NewHueClusterService(hueRepo, hueClusterRepo, logger, hueClusterConfig)
+	hueSimilarityService := service.NewHueSimilarityService(hueRepo, logger, service.HueSimilarityConfig{})
+	cardWidth, cardHeight := card.Size()
+
+	requireAdmin := handler.RequireRole(domain.UserRoleAdmin)
 
 	mux := http.NewServeMux()
-	mux.Handle("/api/sign-in", withCORS(handler.NewSignInHandler(signInService)))
-	mux.Handle("/api/login", withCORS(handler.NewLoginHandler(loginService)))
+	mux.Handle("/api/sign-in", withCORS(handler.NewSignInHandler(signInService, sessionCookies)))
+	mux.Handle("/api/login", withCORS(handler.NewLoginHandler(loginService, sessionCookies)))
+	mux.Handle("/api/logout", withCORS(handler.RequireAuthentication(handler.NewLogoutHandler(sessionService, sessionCookies))))
+	mux.Handle("/api/session/refresh", withCORS(handler.NewSessionRefreshHandler(sessionService, sessionCookies)))
+	mux.Handle("/api/sessions", withCORS(handler.RequireAuthentication(handler.NewSessionsHandler(sessionService, sessionCookies))))
+	mux.Handle("/api/sessions/{id}", withCORS(handler.RequireAuthentication(handler.NewSessionHandler(sessionService))))
+	mux.Handle("/api/hue-are-you/questionnaire", withCORS(handler.NewHueQuestionnaireHandler(hueQuestionnaireService)))
 	mux.Handle("/api/hue-are-you/save-result", withCORS(handler.NewHueSaveHandler(hueSaveService)))
 	mux.Handle("/api/hue-are-you/get-data", withCORS(handler.NewHueGetHandler(hueGetService)))
+	mux.Handle("/api/hue-are-you/export", withCORS(requireAdmin(handler.NewHueExportHandler(hueGetService))))
+	mux.Handle("/api/hue-are-you/prompts", withCORS(requireAdmin(handler.NewHuePromptHandler(huePromptService))))
+	mux.Handle("/api/hue-are-you/prompts/{version}/activate", withCORS(requireAdmin(handler.NewHuePromptActivateHandler(huePromptService))))
+	mux.Handle("/api/hue-are-you/stats", withCORS(requireAdmin(handler.NewHueStatsHandler(hueGetService))))
+	mux.Handle("/api/hue-are-you/clusters", withCORS(requireAdmin(handler.NewHueClusterHandler(hueClusterService))))
+	mux.Handle("/api/hue-are-you/usage", withCORS(requireAdmin(handler.NewHueUsageHandler(hueUsageService))))
+	mux.Handle("/api/hue-are-you/cache", withCORS(requireAdmin(handler.NewHueCacheStatsHandler(hueUsageService))))
+	mux.Handle("/api/me/hue-results", withCORS(handler.RequireAuthentication(handler.NewUserHueResultsHandler(hueGetService))))
+	mux.Handle("/api/hue-are-you/results/{id}", withCORS(handler.NewHueResultHandler(hueResultService, hueClusterService)))
+	mux.Handle("/api/hue-are-you/results/{id}/similar", withCORS(handler.NewHueSimilarHandler(hueSimilarityService)))
+	mux.Handle("/api/hue-are-you/results/{id}/stream", withCORS(handler.NewHueStreamHandler(hueStreamService)))
+	mux.Handle("/api/hue-are-you/results/{id}/card.png", withCORS(handler.NewHueCardHandler(hueCardService)))
+	mux.Handle("/api/hue-are-you/results/{id}/share", withCORS(handler.NewHueSharePageHandler(hueResultService, siteURL(), cardWidth, cardHeight)))
 
-	return mux
+	// 資格情報を読んでから主体を解決する。保護されたルートは RequireAuthentication か requireAdmin で包む。
+	return handler.WithSessionCredentials(handler.WithAuthentication(mux, authService), sessionCookies), []backgroundJob{hueWorker, hueClusterService, hueSimilarityService}
 }
 
 func serverAddr() string {
@@ -102,25 +184,163 @@ func serverAddr() string {
 	return ":" + port
 }
 
-func loadHueSaveConfig() (service.HueSaveConfig, error) {
-	endpoint := strings.TrimSpace(os.Getenv("HUE_API_ENDPOINT"))
-	if endpoint == "" {
-		return service.HueSaveConfig{}, fmt.Errorf("HUE_API_ENDPOINT is required")
-	}
-	apiKey := strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
-	if apiKey == "" {
-		return service.HueSaveConfig{}, fmt.Errorf("OPENAI_API_KEY is required")
-	}
-	return service.HueSaveConfig{
-		Endpoint: endpoint,
-		APIKey:   apiKey,
-		SystemPrompt: `
-あなたは心理テスト「Hue Are You」の結果生成AIです。
-各ワードに対して選択された色から心理的特徴を分析し、最終的なrgb値(0〜255)と2〜4文程度の日本語メッセージを返してください。
-分析には、選ばれた色に意識を向けるより「普通の人ならこう選ぶところをこの人はこの色を選んだので、こういう人なのだろう」という推察もしてください。
-メッセージは分析の結果を伝えるのではなくふんわりした内容で、いいサービスだったと思ってもらえる分にしましょう。
-`,
-	}, nil
+// siteURL は OGP などで使う公開サイトの URL。SITE_URL で上書きできる。
+func siteURL() string {
+	if url := strings.TrimSpace(os.Getenv("SITE_URL")); url != "" {
+		return url
+	}
+	return "https://www.ahaha-craft.org"
+}
+
+// loadHueGeneratorConfig は HUE_GENERATOR で生成器を選ぶ。"fake" なら API キー無しで動く。
+func loadHueGeneratorConfig() service.HueGeneratorConfig {
+	return service.HueGeneratorConfig{
+		Provider: strings.TrimSpace(os.Getenv("HUE_GENERATOR")),
+		Endpoint: strings.TrimSpace(os.Getenv("HUE_API_ENDPOINT")),
+		APIKey:   strings.TrimSpace(os.Getenv("OPENAI_API_KEY")),
+		Model:    strings.TrimSpace(os.Getenv("HUE_MODEL")),
+	}
+}
+
+// defaultLLMPrices は HUE_LLM_PRICES が無いときの単価 (100 万トークンあたりの米ドル)。
+var defaultLLMPrices = map[string]domain.LLMPrice{
+	"gpt-4.1":      {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	"gpt-4.1-mini": {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	"gpt-4.1-nano": {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gpt-4o":       {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":  {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+}
+
+// loadHueUsageConfig は推定費用の単価表と 1 日の予算を読む。
+// HUE_LLM_PRICES は {"<model>": {"input": 2.0, "output": 8.0}} の JSON (100 万トークンあたりの米ドル)、
+// HUE_LLM_DAILY_BUDGET_USD は 1 日 (UTC) の予算で、省略すると上限なし。
+func loadHueUsageConfig() (service.HueUsageConfig, error) {
+	prices := defaultLLMPrices
+	if raw := strings.TrimSpace(os.Getenv("HUE_LLM_PRICES")); raw != "" {
+		var parsed map[string]struct {
+			Input  float64 `json:"input"`
+			Output float64 `json:"output"`
+		}
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			return service.HueUsageConfig{}, fmt.Errorf("HUE_LLM_PRICES: %w", err)
+		}
+		prices = make(map[string]domain.LLMPrice, len(parsed))
+		for model, price := range parsed {
+			prices[model] = domain.LLMPrice{InputPerMillion: price.Input, OutputPerMillion: price.Output}
+		}
+	}
+	table, err := domain.NewLLMPriceTable(prices)
+	if err != nil {
+		return service.HueUsageConfig{}, fmt.Errorf("HUE_LLM_PRICES: %w", err)
+	}
+
+	var budget float64
+	if raw := strings.TrimSpace(os.Getenv("HUE_LLM_DAILY_BUDGET_USD")); raw != "" {
+		budget, err = strconv.ParseFloat(raw, 64)
+		if err != nil || budget < 0 {
+			return service.HueUsageConfig{}, fmt.Errorf("HUE_LLM_DAILY_BUDGET_USD: invalid value %q", raw)
+		}
+	}
+
+	return service.HueUsageConfig{Prices: table, DailyBudget: budget}, nil
+}
+
+// loadHueClusterConfig はクラスタリングの設定を読む。
+// HUE_CLUSTER_K はクラスタ数、HUE_CLUSTER_INTERVAL は作り直す間隔 (例: "6h")。省略すると既定値。
+func loadHueClusterConfig() (service.HueClusterConfig, error) {
+	var cfg service.HueClusterConfig
+	if raw := strings.TrimSpace(os.Getenv("HUE_CLUSTER_K")); raw != "" {
+		k, err := strconv.Atoi(raw)
+		if err != nil || k <= 0 || k > domain.MaxHueClusterCount {
+			return service.HueClusterConfig{}, fmt.Errorf("HUE_CLUSTER_K: invalid value %q", raw)
+		}
+		cfg.K = k
+	}
+	if raw := strings.TrimSpace(os.Getenv("HUE_CLUSTER_INTERVAL")); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return service.HueClusterConfig{}, fmt.Errorf("HUE_CLUSTER_INTERVAL: invalid value %q", raw)
+		}
+		cfg.Interval = interval
+	}
+	return cfg, nil
+}
+
+// loadSessionTokenPepper はセッショントークンの HMAC に使う SESSION_TOKEN_PEPPER を読む (32 バイト以上)。
+// 未設定なら起動しない。ローカル開発でだけ SESSION_TOKEN_PEPPER_DEV_RANDOM=true で起動ごとの乱数を使えるが、
+// 再起動すると新形式のセッションはすべて無効になる。
+func loadSessionTokenPepper(logger *log.Logger) (domain.SessionTokenPepper, error) {
+	if raw := strings.TrimSpace(os.Getenv("SESSION_TOKEN_PEPPER")); raw != "" {
+		pepper, err := domain.NewSessionTokenPepper([]byte(raw))
+		if err != nil {
+			return domain.SessionTokenPepper{}, fmt.Errorf("SESSION_TOKEN_PEPPER: must be at least %d bytes", domain.MinSessionTokenPepperLength)
+		}
+		return pepper, nil
+	}
+
+	devRandom, err := parseBoolEnv("SESSION_TOKEN_PEPPER_DEV_RANDOM", false)
+	if err != nil {
+		return domain.SessionTokenPepper{}, err
+	}
+	if !devRandom {
+		return domain.SessionTokenPepper{}, errors.New("SESSION_TOKEN_PEPPER: required (set SESSION_TOKEN_PEPPER_DEV_RANDOM=true only for local development)")
+	}
+
+	logger.Println("SESSION_TOKEN_PEPPER is not set; using a random pepper for development, sessions will not survive a restart")
+	key := make([]byte, domain.MinSessionTokenPepperLength)
+	if _, err := rand.Read(key); err != nil {
+		return domain.SessionTokenPepper{}, err
+	}
+	return domain.NewSessionTokenPepper(key)
+}
+
+// loadSessionCookies は SESSION_COOKIE=true のとき、セッションを HttpOnly クッキーで渡すモードを有効にする。
+// SESSION_COOKIE_SECURE は既定で true (ローカルの http でだけ false にする)、
+// SESSION_COOKIE_SAMESITE は lax (既定), strict, none、SESSION_COOKIE_DOMAIN はクッキーの Domain。
+// 無効なら nil を返し、従来どおりトークンを JSON で返す。
+func loadSessionCookies() (*handler.SessionCookies, error) {
+	enabled, err := parseBoolEnv("SESSION_COOKIE", false)
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	secure, err := parseBoolEnv("SESSION_COOKIE_SECURE", true)
+	if err != nil {
+		return nil, err
+	}
+
+	var sameSite http.SameSite
+	switch raw := strings.ToLower(strings.TrimSpace(os.Getenv("SESSION_COOKIE_SAMESITE"))); raw {
+	case "", "lax":
+		sameSite = http.SameSiteLaxMode
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		if !secure {
+			return nil, fmt.Errorf("SESSION_COOKIE_SAMESITE: none requires SESSION_COOKIE_SECURE")
+		}
+		sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("SESSION_COOKIE_SAMESITE: invalid value %q", raw)
+	}
+
+	return handler.NewSessionCookies(handler.SessionCookieConfig{
+		Secure:   secure,
+		SameSite: sameSite,
+		Domain:   strings.TrimSpace(os.Getenv("SESSION_COOKIE_DOMAIN")),
+	}), nil
+}
+
+func parseBoolEnv(name string, fallback bool) (bool, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid value %q", name, raw)
+	}
+	return v, nil
 }
 
 func withCORS(next http.Handler) http.Handler {
@@ -140,7 +360,7 @@ func withCORS(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Methods",
 				"GET, POST, PUT, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers",
-				"Content-Type, Authorization")
+				"Content-Type, Authorization, Idempotency-Key, "+handler.HueCacheHeader+", "+handler.CSRFHeader)
 		}
 
 		if r.Method == http.MethodOptions {
@@ -0,0 +1,10 @@
+ALTER TABLE hue_records
+    DROP COLUMN IF EXISTS result_r,
+    DROP COLUMN IF EXISTS result_g,
+    DROP COLUMN IF EXISTS result_b,
+    DROP COLUMN IF EXISTS result_message,
+    DROP COLUMN IF EXISTS result_source,
+    DROP COLUMN IF EXISTS result_generator,
+    DROP COLUMN IF EXISTS result_prompt_version,
+    DROP COLUMN IF EXISTS result_latency_ms,
+    DROP COLUMN IF EXISTS result_generated_at;
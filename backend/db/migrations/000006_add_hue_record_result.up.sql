@@ -0,0 +1,10 @@
+ALTER TABLE hue_records
+    ADD COLUMN result_r              SMALLINT CHECK (result_r BETWEEN 0 AND 255),
+    ADD COLUMN result_g              SMALLINT CHECK (result_g BETWEEN 0 AND 255),
+    ADD COLUMN result_b              SMALLINT CHECK (result_b BETWEEN 0 AND 255),
+    ADD COLUMN result_message        TEXT,
+    ADD COLUMN result_source         VARCHAR(16),
+    ADD COLUMN result_generator      VARCHAR(128),
+    ADD COLUMN result_prompt_version VARCHAR(64),
+    ADD COLUMN result_latency_ms     INTEGER,
+    ADD COLUMN result_generated_at   TIMESTAMPTZ;
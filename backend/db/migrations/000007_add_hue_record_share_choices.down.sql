@@ -0,0 +1,2 @@
+ALTER TABLE hue_records
+    DROP COLUMN IF EXISTS share_choices;
@@ -0,0 +1,2 @@
+ALTER TABLE hue_records
+    ADD COLUMN share_choices BOOLEAN NOT NULL DEFAULT FALSE;
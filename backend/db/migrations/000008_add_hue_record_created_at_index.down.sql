@@ -0,0 +1 @@
+DROP INDEX IF EXISTS hue_records_created_at_id_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS hue_records_created_at_id_idx
+    ON hue_records (created_at, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS hue_records_user_name_trgm_idx;
+DROP INDEX IF EXISTS hue_records_choices_idx;
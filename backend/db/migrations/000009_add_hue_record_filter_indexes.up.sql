@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS hue_records_choices_idx
+    ON hue_records USING GIN (choices jsonb_path_ops);
+
+CREATE INDEX IF NOT EXISTS hue_records_user_name_trgm_idx
+    ON hue_records USING GIN (user_name gin_trgm_ops);
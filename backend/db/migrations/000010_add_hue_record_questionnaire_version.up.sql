@@ -0,0 +1,2 @@
+ALTER TABLE hue_records
+    ADD COLUMN questionnaire_version TEXT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS hue_generation_jobs;
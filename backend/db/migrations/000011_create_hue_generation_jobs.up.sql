@@ -0,0 +1,16 @@
+CREATE TABLE hue_generation_jobs
+(
+    record_id    UUID PRIMARY KEY REFERENCES hue_records (id) ON DELETE CASCADE,
+    status       TEXT        NOT NULL DEFAULT 'pending',
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    max_attempts INTEGER     NOT NULL,
+    run_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX hue_generation_jobs_claim_idx
+    ON hue_generation_jobs (run_at)
+    WHERE status IN ('pending', 'running');
@@ -0,0 +1,4 @@
+ALTER TABLE hue_records
+    DROP COLUMN IF EXISTS prompt_version;
+
+DROP TABLE IF EXISTS hue_prompt_versions;
@@ -0,0 +1,23 @@
+CREATE TABLE hue_prompt_versions
+(
+    version         VARCHAR(64) PRIMARY KEY,
+    system_template TEXT        NOT NULL,
+    user_template   TEXT        NOT NULL,
+    weight          INTEGER     NOT NULL DEFAULT 0 CHECK (weight >= 0),
+    active          BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activated_at    TIMESTAMPTZ
+);
+
+-- これまで cmd/main.go に埋め込んでいたプロンプトを v1 として移す。
+INSERT INTO hue_prompt_versions (version, system_template, user_template, weight, active, activated_at)
+VALUES ('v1',
+        'あなたは心理テスト「Hue Are You」の結果生成AIです。' ||
+        '各ワードに対して選択された色から心理的特徴を分析し、最終的なrgb値(0〜255)と2〜4文程度の日本語メッセージを返してください。' ||
+        '分析には、選ばれた色に意識を向けるより「普通の人ならこう選ぶところをこの人はこの色を選んだので、こういう人なのだろう」という推察もしてください。' ||
+        'メッセージは分析の結果を伝えるのではなくふんわりした内容で、いいサービスだったと思ってもらえる分にしましょう。',
+        '選択 : (語彙, 色) = {{range $i, $c := .Choices}}{{if $i}}, {{end}}({{$c.Word}}, {{$c.Color}}){{end}}',
+        100, TRUE, NOW());
+
+ALTER TABLE hue_records
+    ADD COLUMN prompt_version VARCHAR(64);
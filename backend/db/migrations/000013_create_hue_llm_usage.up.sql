@@ -0,0 +1,13 @@
+CREATE TABLE hue_llm_usage
+(
+    id            BIGSERIAL PRIMARY KEY,
+    record_id     UUID REFERENCES hue_records (id) ON DELETE SET NULL,
+    generator     TEXT        NOT NULL,
+    model         TEXT        NOT NULL,
+    input_tokens  INTEGER     NOT NULL CHECK (input_tokens >= 0),
+    output_tokens INTEGER     NOT NULL CHECK (output_tokens >= 0),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX hue_llm_usage_created_at_idx
+    ON hue_llm_usage (created_at);
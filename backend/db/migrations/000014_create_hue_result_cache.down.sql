@@ -0,0 +1,4 @@
+ALTER TABLE hue_generation_jobs
+    DROP COLUMN IF EXISTS bypass_cache;
+
+DROP TABLE IF EXISTS hue_result_cache;
@@ -0,0 +1,18 @@
+CREATE TABLE hue_result_cache
+(
+    cache_key      CHAR(64) PRIMARY KEY,
+    prompt_version VARCHAR(64)  NOT NULL,
+    generator      VARCHAR(128) NOT NULL,
+    result_r       SMALLINT     NOT NULL CHECK (result_r BETWEEN 0 AND 255),
+    result_g       SMALLINT     NOT NULL CHECK (result_g BETWEEN 0 AND 255),
+    result_b       SMALLINT     NOT NULL CHECK (result_b BETWEEN 0 AND 255),
+    result_message TEXT         NOT NULL,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at     TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX hue_result_cache_expires_at_idx
+    ON hue_result_cache (expires_at);
+
+ALTER TABLE hue_generation_jobs
+    ADD COLUMN bypass_cache BOOLEAN NOT NULL DEFAULT FALSE;
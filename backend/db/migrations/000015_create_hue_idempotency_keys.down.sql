@@ -0,0 +1 @@
+DROP TABLE IF EXISTS hue_idempotency_keys;
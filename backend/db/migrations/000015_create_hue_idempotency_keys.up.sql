@@ -0,0 +1,11 @@
+CREATE TABLE hue_idempotency_keys
+(
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash    CHAR(64)    NOT NULL,
+    record_id       UUID        NOT NULL REFERENCES hue_records (id) ON DELETE CASCADE,
+    job_status      TEXT        NOT NULL,
+    max_attempts    INTEGER     NOT NULL,
+    run_at          TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ NOT NULL
+);
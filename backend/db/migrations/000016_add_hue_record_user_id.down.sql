@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS hue_records_user_id_created_at_idx;
+
+ALTER TABLE hue_records
+    DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,7 @@
+ALTER TABLE hue_records
+    ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users (id) ON DELETE SET NULL;
+
+-- /api/me/hue-results は (created_at, id) 順のキーセットで読む。
+CREATE INDEX IF NOT EXISTS hue_records_user_id_created_at_idx
+    ON hue_records (user_id, created_at, id)
+    WHERE user_id IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS hue_cluster_assignments;
+DROP TABLE IF EXISTS hue_clusters;
+DROP TABLE IF EXISTS hue_cluster_runs;
@@ -0,0 +1,33 @@
+CREATE TABLE hue_cluster_runs
+(
+    id           UUID PRIMARY KEY,
+    record_count INTEGER     NOT NULL CHECK (record_count >= 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX hue_cluster_runs_created_at_idx
+    ON hue_cluster_runs (created_at);
+
+CREATE TABLE hue_clusters
+(
+    run_id        UUID    NOT NULL REFERENCES hue_cluster_runs (id) ON DELETE CASCADE,
+    cluster_index INTEGER NOT NULL CHECK (cluster_index >= 0),
+    size          INTEGER NOT NULL CHECK (size >= 0),
+    -- 語 → 色 → クラスタ内でその色を選んだ割合
+    centroid      JSONB   NOT NULL,
+    PRIMARY KEY (run_id, cluster_index)
+);
+
+CREATE TABLE hue_cluster_assignments
+(
+    run_id        UUID             NOT NULL,
+    record_id     UUID             NOT NULL REFERENCES hue_records (id) ON DELETE CASCADE,
+    cluster_index INTEGER          NOT NULL,
+    distance      DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (run_id, record_id),
+    FOREIGN KEY (run_id, cluster_index) REFERENCES hue_clusters (run_id, cluster_index) ON DELETE CASCADE
+);
+
+-- 代表的な回答はクラスタごとに重心に近い順で読む。
+CREATE INDEX hue_cluster_assignments_cluster_idx
+    ON hue_cluster_assignments (run_id, cluster_index, distance);
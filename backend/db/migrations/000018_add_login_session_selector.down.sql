@@ -0,0 +1,7 @@
+-- 新形式のセッションは bcrypt では照合できないので消す。
+DELETE FROM login_sessions WHERE selector IS NOT NULL;
+
+DROP INDEX IF EXISTS login_sessions_selector_key;
+
+ALTER TABLE login_sessions
+    DROP COLUMN IF EXISTS selector;
@@ -0,0 +1,13 @@
+-- 新しいセッションは selector で 1 行だけ引き、token には pepper 付き HMAC-SHA256 を保存する。
+-- selector が NULL の行は旧形式 (bcrypt) のセッションで、期限切れまでそのまま使える。
+ALTER TABLE login_sessions
+    ADD COLUMN IF NOT EXISTS selector TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS login_sessions_selector_key
+    ON login_sessions (selector)
+    WHERE selector IS NOT NULL;
+
+-- 期限切れの旧形式のセッションはもう使えないので、bcrypt の照合対象に残さない。
+DELETE FROM login_sessions
+WHERE selector IS NULL
+  AND expires_at <= NOW();
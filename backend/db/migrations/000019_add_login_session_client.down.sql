@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS login_sessions_user_id_expires_at_idx;
+
+ALTER TABLE login_sessions
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS user_agent;
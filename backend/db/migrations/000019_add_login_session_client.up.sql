@@ -0,0 +1,9 @@
+-- セッション一覧で端末を見分けるための情報。旧いセッションは空のまま。
+ALTER TABLE login_sessions
+    ADD COLUMN IF NOT EXISTS user_agent   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip_address   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP;
+
+-- /api/sessions はユーザーごとに期限内のセッションを読み、ログアウトはユーザー単位で消す。
+CREATE INDEX IF NOT EXISTS login_sessions_user_id_expires_at_idx
+    ON login_sessions (user_id, expires_at);
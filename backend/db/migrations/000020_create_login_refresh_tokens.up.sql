@@ -0,0 +1,26 @@
+-- refresh token は使うたびに同じ family の次のトークンへ回転する。
+-- 使用済み (used_at) の行は期限まで残し、再利用されたら family ごと失効 (revoked_at) させる。
+CREATE TABLE IF NOT EXISTS login_refresh_tokens
+(
+    id         UUID PRIMARY KEY,
+    family_id  UUID      NOT NULL,
+    user_id    UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- 回転で古いセッションを消しても、再利用を見分けられるように行は残す。
+    session_id UUID REFERENCES login_sessions (id) ON DELETE SET NULL,
+    selector   TEXT      NOT NULL,
+    token      TEXT      NOT NULL, /* HMAC-SHA256 */
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    used_at    TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS login_refresh_tokens_selector_key
+    ON login_refresh_tokens (selector);
+CREATE INDEX IF NOT EXISTS login_refresh_tokens_family_id_idx
+    ON login_refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS login_refresh_tokens_session_id_idx
+    ON login_refresh_tokens (session_id)
+    WHERE session_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS login_refresh_tokens_user_id_idx
+    ON login_refresh_tokens (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE hue_generation_jobs
+    DROP COLUMN IF EXISTS failure;
@@ -0,0 +1,2 @@
+ALTER TABLE hue_generation_jobs
+    ADD COLUMN failure TEXT;
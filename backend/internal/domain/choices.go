@@ -6,18 +6,19 @@ type HueWord string
 
 type HueColor string
 
-var allowedHueColors = map[HueColor]struct{}{
-	"黒":    {},
-	"灰色":   {},
-	"白":    {},
-	"ピンク":  {},
-	"赤":    {},
-	"オレンジ": {},
-	"黄色":   {},
-	"緑":    {},
-	"青":    {},
-	"紫":    {},
-	"茶":    {},
+// allowedHueColors は回答に使えるパレットと、その表示色(frontend の colorToHex と同じ値)。
+var allowedHueColors = map[HueColor]HueRGB{
+	"黒":    {r: 0x44, g: 0x44, b: 0x44},
+	"灰色":   {r: 0x99, g: 0x99, b: 0x99},
+	"白":    {r: 0xf5, g: 0xf5, b: 0xf5},
+	"ピンク":  {r: 0xe8, g: 0x6b, b: 0x87},
+	"赤":    {r: 0xd9, g: 0x4f, b: 0x4f},
+	"オレンジ": {r: 0xf1, g: 0x8c, b: 0x3c},
+	"黄色":   {r: 0xe7, g: 0xc8, b: 0x4f},
+	"緑":    {r: 0x4c, g: 0xad, b: 0x68},
+	"青":    {r: 0x4f, g: 0x86, b: 0xe2},
+	"紫":    {r: 0x95, g: 0x6e, b: 0xc4},
+	"茶":    {r: 0xa1, g: 0x69, b: 0x3c},
 }
 
 func (c HueColor) valid() bool {
@@ -25,13 +26,55 @@ func (c HueColor) valid() bool {
 	return ok
 }
 
+// RGB はパレット上の表示色を返す。パレット外の色なら false。
+func (c HueColor) RGB() (HueRGB, bool) {
+	rgb, ok := allowedHueColors[c]
+	return rgb, ok
+}
+
 // HueChoices は単語ごとの色割り当てを保持し、空や空白キーを許可しない。
+// どの版の質問票に答えたものかも併せて持つ。
 type HueChoices struct {
-	values map[HueWord]HueColor
+	values               map[HueWord]HueColor
+	questionnaireVersion string
 }
 
-// NewHueChoices 空が含まれていれば ErrInvalidChoice を返す。
+// NewHueChoices は現行の質問票に対する回答として検証する。
+// 空やパレット外の色なら ErrInvalidChoice、質問票に無い語彙なら ErrUnknownWord を返す。
 func NewHueChoices(raw map[string]string) (HueChoices, error) {
+	return NewHueChoicesFor(ActiveQuestionnaire(), raw)
+}
+
+// NewHueChoicesFor は指定した版の質問票に対する回答として検証する。
+func NewHueChoicesFor(q Questionnaire, raw map[string]string) (HueChoices, error) {
+	choices, err := newHueChoices(raw)
+	if err != nil {
+		return HueChoices{}, err
+	}
+
+	for word := range choices.values {
+		if !q.Allows(word) {
+			return HueChoices{}, ErrUnknownWord
+		}
+	}
+
+	choices.questionnaireVersion = q.Version()
+	return choices, nil
+}
+
+// NewHueChoicesFromPersistence は保存済みの回答を復元する。
+// 質問票の導入前に保存された回答もあるため、語彙は検証しない。version が空なら版は不明。
+func NewHueChoicesFromPersistence(raw map[string]string, version string) (HueChoices, error) {
+	choices, err := newHueChoices(raw)
+	if err != nil {
+		return HueChoices{}, err
+	}
+
+	choices.questionnaireVersion = version
+	return choices, nil
+}
+
+func newHueChoices(raw map[string]string) (HueChoices, error) {
 	if len(raw) == 0 {
 		return HueChoices{}, ErrInvalidChoice
 	}
@@ -50,6 +93,11 @@ func NewHueChoices(raw map[string]string) (HueChoices, error) {
 	return HueChoices{values: values}, nil
 }
 
+// QuestionnaireVersion は回答した質問票の版。不明なら空文字。
+func (c HueChoices) QuestionnaireVersion() string {
+	return c.questionnaireVersion
+}
+
 func (c HueChoices) Size() int {
 	return len(c.values)
 }
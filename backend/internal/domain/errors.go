@@ -3,22 +3,52 @@ package domain
 import "errors"
 
 var (
-	ErrEmptyName           = errors.New("domain: empty name")
-	ErrInvalidChoice       = errors.New("domain: invalid choice")
-	ErrInvalidRange        = errors.New("domain: invalid record range")
-	ErrInvalidToken        = errors.New("domain: invalid token")
-	ErrExpiredToken        = errors.New("domain: expired token")
-	ErrInvalidCredential   = errors.New("domain: invalid credential")
-	ErrInvalidPassword     = errors.New("domain: invalid password")
-	ErrInvalidSessionToken = errors.New("domain: invalid login session token")
-	ErrInvalidLoginSession = errors.New("domain: invalid login session")
-	ErrInvalidSessionData  = errors.New("domain: invalid session data")
-	ErrInvalidEmail        = errors.New("domain: invalid email")
-	ErrInvalidPasswordHash = errors.New("domain: invalid password hash")
-	ErrInvalidUserRole     = errors.New("domain: invalid user role")
-	ErrInvalidUser         = errors.New("domain: invalid user")
-	ErrDuplicateUsername   = errors.New("domain: duplicate username")
-	ErrDuplicateEmail      = errors.New("domain: duplicate email")
-	ErrInvalidAPIError     = errors.New("domain: invalid api error")
-	ErrInvalidHueResult    = errors.New("domain: invalid hue result")
+	ErrEmptyName               = errors.New("domain: empty name")
+	ErrInvalidChoice           = errors.New("domain: invalid choice")
+	ErrUnknownWord             = errors.New("domain: word not in questionnaire")
+	ErrUnknownQuestionnaire    = errors.New("domain: unknown questionnaire version")
+	ErrInvalidQuestionnaire    = errors.New("domain: invalid questionnaire")
+	ErrInvalidRange            = errors.New("domain: invalid record range")
+	ErrInvalidCursor           = errors.New("domain: invalid record cursor")
+	ErrInvalidFilter           = errors.New("domain: invalid record filter")
+	ErrInvalidToken            = errors.New("domain: invalid token")
+	ErrExpiredToken            = errors.New("domain: expired token")
+	ErrInvalidCredential       = errors.New("domain: invalid credential")
+	ErrInvalidPassword         = errors.New("domain: invalid password")
+	ErrInvalidSessionToken     = errors.New("domain: invalid login session token")
+	ErrInvalidLoginSession     = errors.New("domain: invalid login session")
+	ErrInvalidSessionData      = errors.New("domain: invalid session data")
+	ErrInvalidSessionPepper    = errors.New("domain: invalid session token pepper")
+	ErrLoginSessionNotFound    = errors.New("domain: login session not found")
+	ErrInvalidRefreshToken     = errors.New("domain: invalid refresh token")
+	ErrRefreshTokenReused      = errors.New("domain: refresh token reused")
+	ErrInvalidCSRFToken        = errors.New("domain: invalid csrf token")
+	ErrInvalidPrincipal        = errors.New("domain: invalid principal")
+	ErrInsufficientRole        = errors.New("domain: insufficient role")
+	ErrInvalidEmail            = errors.New("domain: invalid email")
+	ErrInvalidPasswordHash     = errors.New("domain: invalid password hash")
+	ErrInvalidUserRole         = errors.New("domain: invalid user role")
+	ErrInvalidUser             = errors.New("domain: invalid user")
+	ErrDuplicateUsername       = errors.New("domain: duplicate username")
+	ErrDuplicateEmail          = errors.New("domain: duplicate email")
+	ErrInvalidAPIError         = errors.New("domain: invalid api error")
+	ErrInvalidHueResult        = errors.New("domain: invalid hue result")
+	ErrHueRecordNotFound       = errors.New("domain: hue record not found")
+	ErrInvalidHueJob           = errors.New("domain: invalid hue generation job")
+	ErrGeneratorUnavailable    = errors.New("domain: result generator unavailable")
+	ErrGeneratorRateLimited    = errors.New("domain: result generator rate limited")
+	ErrGeneratorTimeout        = errors.New("domain: result generator timed out")
+	ErrGeneratorRejected       = errors.New("domain: result generator rejected the request")
+	ErrInvalidPromptVersion    = errors.New("domain: invalid prompt version")
+	ErrPromptVersionNotFound   = errors.New("domain: prompt version not found")
+	ErrDuplicatePromptVersion  = errors.New("domain: duplicate prompt version")
+	ErrNoActivePromptVersion   = errors.New("domain: no active prompt version")
+	ErrInvalidUsage            = errors.New("domain: invalid llm usage")
+	ErrUsageBudgetExceeded     = errors.New("domain: daily llm budget exceeded")
+	ErrInvalidIdempotencyKey   = errors.New("domain: invalid idempotency key")
+	ErrDuplicateIdempotencyKey = errors.New("domain: duplicate idempotency key")
+	ErrIdempotencyKeyConflict  = errors.New("domain: idempotency key reused with a different request")
+	ErrInvalidHueCluster       = errors.New("domain: invalid hue cluster")
+	ErrNotEnoughHueRecords     = errors.New("domain: not enough hue records to cluster")
+	ErrHueClustersNotFound     = errors.New("domain: hue clusters not found")
 )
@@ -0,0 +1,475 @@
+package domain
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultHueClusterCount はクラスタ数の既定値。
+	DefaultHueClusterCount = 6
+	// MaxHueClusterCount はクラスタ数の上限。
+	MaxHueClusterCount = 32
+	// DefaultHueClusterIterations は k-means の反復回数の上限の既定値。
+	DefaultHueClusterIterations = 50
+)
+
+// HueAssociation はクラスタの重心に現れる (語, 色) の組と、クラスタ内でそれを選んだ人の割合。
+type HueAssociation struct {
+	word   HueWord
+	color  HueColor
+	weight float64
+}
+
+func (a HueAssociation) Word() HueWord   { return a.word }
+func (a HueAssociation) Color() HueColor { return a.color }
+func (a HueAssociation) Weight() float64 { return a.weight }
+
+// HueCluster は回答を (語, 色) の one-hot ベクトルとみなしたときの、k-means のクラスタ 1 つ。
+// 重心は語ごとの色の割合で、選んだ人のいない組は持たない。
+type HueCluster struct {
+	index    int
+	size     int
+	centroid map[HueWord]map[HueColor]float64
+	// norm は重心の二乗ノルム。距離の計算に毎回使う。
+	norm float64
+}
+
+// NewHueCluster は保存済みの重心から HueCluster を再構築する。割合が [0, 1] の外なら ErrInvalidHueCluster。
+func NewHueCluster(index, size int, centroid map[string]map[string]float64) (HueCluster, error) {
+	if index < 0 || size < 0 {
+		return HueCluster{}, ErrInvalidHueCluster
+	}
+
+	values := make(map[HueWord]map[HueColor]float64, len(centroid))
+	var norm float64
+	for word, colors := range centroid {
+		w := HueWord(strings.TrimSpace(word))
+		if w == "" {
+			return HueCluster{}, ErrInvalidHueCluster
+		}
+		for color, weight := range colors {
+			if weight < 0 || weight > 1 || math.IsNaN(weight) {
+				return HueCluster{}, ErrInvalidHueCluster
+			}
+			if weight == 0 {
+				continue
+			}
+			if values[w] == nil {
+				values[w] = make(map[HueColor]float64, len(colors))
+			}
+			values[w][HueColor(color)] = weight
+			norm += weight * weight
+		}
+	}
+
+	return HueCluster{index: index, size: size, centroid: values, norm: norm}, nil
+}
+
+// Index は大きい順に 0 から振ったクラスタの番号。
+func (c HueCluster) Index() int { return c.index }
+
+// Size はクラスタに割り当てた回答の数。
+func (c HueCluster) Size() int { return c.size }
+
+// Centroid は語ごとの色の割合を返す。
+func (c HueCluster) Centroid() map[string]map[string]float64 {
+	copied := make(map[string]map[string]float64, len(c.centroid))
+	for word, colors := range c.centroid {
+		inner := make(map[string]float64, len(colors))
+		for color, weight := range colors {
+			inner[string(color)] = weight
+		}
+		copied[string(word)] = inner
+	}
+	return copied
+}
+
+// Associations はクラスタ内で選ばれた割合が高い (語, 色) の組を limit 件返す。
+// 同じ割合なら語、色の辞書順。
+func (c HueCluster) Associations(limit int) []HueAssociation {
+	associations := make([]HueAssociation, 0)
+	for word, colors := range c.centroid {
+		for color, weight := range colors {
+			associations = append(associations, HueAssociation{word: word, color: color, weight: weight})
+		}
+	}
+	sort.Slice(associations, func(i, j int) bool {
+		a, b := associations[i], associations[j]
+		if a.weight != b.weight {
+			return a.weight > b.weight
+		}
+		if a.word != b.word {
+			return a.word < b.word
+		}
+		return a.color < b.color
+	})
+	if limit >= 0 && len(associations) > limit {
+		associations = associations[:limit]
+	}
+	return associations
+}
+
+// Distance は回答と重心の二乗ユークリッド距離。
+// 回答は one-hot なので |x|^2 - 2 x・c + |c|^2 を回答した組だけで計算できる。
+func (c HueCluster) Distance(choices HueChoices) float64 {
+	var dot float64
+	for word, color := range choices.values {
+		dot += c.centroid[word][color]
+	}
+	return float64(choices.Size()) - 2*dot + c.norm
+}
+
+// HueClusterModel は 1 回の k-means の結果。クラスタは大きい順に並ぶ。
+type HueClusterModel struct {
+	id          uuid.UUID
+	createdAt   time.Time
+	recordCount int
+	clusters    []HueCluster
+}
+
+// NewHueClusterModel は保存済みのクラスタから再構築する。クラスタの番号は 0 から連続していなければならない。
+func NewHueClusterModel(id uuid.UUID, createdAt time.Time, recordCount int, clusters []HueCluster) (HueClusterModel, error) {
+	if id == uuid.Nil || createdAt.IsZero() || recordCount < 0 || len(clusters) == 0 {
+		return HueClusterModel{}, ErrInvalidHueCluster
+	}
+
+	sorted := append([]HueCluster(nil), clusters...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].index < sorted[j].index })
+	for i, cluster := range sorted {
+		if cluster.index != i {
+			return HueClusterModel{}, ErrInvalidHueCluster
+		}
+	}
+
+	return HueClusterModel{id: id, createdAt: createdAt.UTC(), recordCount: recordCount, clusters: sorted}, nil
+}
+
+func (m HueClusterModel) ID() uuid.UUID        { return m.id }
+func (m HueClusterModel) CreatedAt() time.Time { return m.createdAt }
+
+// RecordCount はクラスタリングに使った回答の数。
+func (m HueClusterModel) RecordCount() int { return m.recordCount }
+
+func (m HueClusterModel) Clusters() []HueCluster {
+	return append([]HueCluster(nil), m.clusters...)
+}
+
+// IsZero はまだクラスタリングしていないかを返す。
+func (m HueClusterModel) IsZero() bool { return len(m.clusters) == 0 }
+
+// Nearest は回答に最も近いクラスタを返す。モデルが空なら false。
+func (m HueClusterModel) Nearest(choices HueChoices) (HueCluster, float64, bool) {
+	if len(m.clusters) == 0 {
+		return HueCluster{}, 0, false
+	}
+
+	best, bestDistance := m.clusters[0], m.clusters[0].Distance(choices)
+	for _, cluster := range m.clusters[1:] {
+		if d := cluster.Distance(choices); d < bestDistance {
+			best, bestDistance = cluster, d
+		}
+	}
+	return best, bestDistance, true
+}
+
+// HueClusterAssignment は回答を割り当てたクラスタと重心までの距離。
+type HueClusterAssignment struct {
+	recordID uuid.UUID
+	cluster  int
+	distance float64
+}
+
+func (a HueClusterAssignment) RecordID() uuid.UUID { return a.recordID }
+func (a HueClusterAssignment) Cluster() int        { return a.cluster }
+func (a HueClusterAssignment) Distance() float64   { return a.distance }
+
+// ClusterHueRecords は回答を (語, 色) の one-hot ベクトルにして k-means で k 個に分ける。
+// 初期値は k-means++ で選び、割り当てが変わらなくなるか maxIterations 回で止める。rng を固定すれば結果も固定される。
+// 回答が k 件より少なければ回答数まで減らす。回答が無ければ ErrNotEnoughHueRecords。
+func ClusterHueRecords(records []HueRecord, k, maxIterations int, rng *rand.Rand, now time.Time) (HueClusterModel, []HueClusterAssignment, error) {
+	if k <= 0 || k > MaxHueClusterCount || maxIterations <= 0 {
+		return HueClusterModel{}, nil, ErrInvalidHueCluster
+	}
+	if len(records) == 0 {
+		return HueClusterModel{}, nil, ErrNotEnoughHueRecords
+	}
+	k = min(k, len(records))
+
+	space := newHueChoiceSpace()
+	points := make([][]int, len(records))
+	for i, record := range records {
+		points[i] = space.encode(record.Choices())
+	}
+
+	km := newKMeans(points, space.size(), k)
+	km.init(rng)
+	for i := 0; i < maxIterations; i++ {
+		if !km.assign() {
+			break
+		}
+		km.update(rng)
+	}
+
+	// 大きいクラスタから 0, 1, ... と振り直す。同じ大きさなら元の順。
+	order := make([]int, k)
+	for i := range order {
+		order[i] = i
+	}
+	sizes := km.sizes()
+	sort.SliceStable(order, func(i, j int) bool { return sizes[order[i]] > sizes[order[j]] })
+	renumber := make([]int, k)
+	for newIndex, old := range order {
+		renumber[old] = newIndex
+	}
+
+	clusters := make([]HueCluster, k)
+	for newIndex, old := range order {
+		clusters[newIndex] = space.cluster(newIndex, sizes[old], km.centroids[old])
+	}
+
+	assignments := make([]HueClusterAssignment, len(records))
+	for i, record := range records {
+		cluster := clusters[renumber[km.assignments[i]]]
+		assignments[i] = HueClusterAssignment{
+			recordID: record.ID(),
+			cluster:  cluster.index,
+			distance: cluster.Distance(record.Choices()),
+		}
+	}
+
+	model, err := NewHueClusterModel(uuid.New(), now, len(records), clusters)
+	if err != nil {
+		return HueClusterModel{}, nil, err
+	}
+	return model, assignments, nil
+}
+
+// hueChoiceSpace は (語, 色) の組に one-hot ベクトルの次元を順に割り当てる。
+type hueChoiceSpace struct {
+	dims  map[hueChoicePair]int
+	pairs []hueChoicePair
+}
+
+type hueChoicePair struct {
+	word  HueWord
+	color HueColor
+}
+
+func newHueChoiceSpace() *hueChoiceSpace {
+	return &hueChoiceSpace{dims: make(map[hueChoicePair]int)}
+}
+
+func (s *hueChoiceSpace) size() int { return len(s.pairs) }
+
+// encode は回答を 1 が立つ次元の一覧にする。初めて見た組には新しい次元を割り当てる。
+func (s *hueChoiceSpace) encode(choices HueChoices) []int {
+	dims := make([]int, 0, choices.Size())
+	for word, color := range choices.values {
+		pair := hueChoicePair{word: word, color: color}
+		dim, ok := s.dims[pair]
+		if !ok {
+			dim = len(s.pairs)
+			s.dims[pair] = dim
+			s.pairs = append(s.pairs, pair)
+		}
+		dims = append(dims, dim)
+	}
+	sort.Ints(dims)
+	return dims
+}
+
+func (s *hueChoiceSpace) cluster(index, size int, centroid []float64) HueCluster {
+	values := make(map[HueWord]map[HueColor]float64)
+	var norm float64
+	for dim, weight := range centroid {
+		if weight == 0 {
+			continue
+		}
+		pair := s.pairs[dim]
+		if values[pair.word] == nil {
+			values[pair.word] = make(map[HueColor]float64)
+		}
+		values[pair.word][pair.color] = weight
+		norm += weight * weight
+	}
+	return HueCluster{index: index, size: size, centroid: values, norm: norm}
+}
+
+// kMeans は 0/1 の疎なベクトルに特化した k-means。重心だけを密に持つ。
+type kMeans struct {
+	points      [][]int
+	centroids   [][]float64
+	norms       []float64
+	assignments []int
+}
+
+func newKMeans(points [][]int, dims, k int) *kMeans {
+	centroids := make([][]float64, k)
+	for i := range centroids {
+		centroids[i] = make([]float64, dims)
+	}
+	assignments := make([]int, len(points))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	return &kMeans{points: points, centroids: centroids, norms: make([]float64, k), assignments: assignments}
+}
+
+func (km *kMeans) distance(point []int, cluster int) float64 {
+	var dot float64
+	for _, dim := range point {
+		dot += km.centroids[cluster][dim]
+	}
+	return float64(len(point)) - 2*dot + km.norms[cluster]
+}
+
+func (km *kMeans) setCentroidToPoint(cluster int, point []int) {
+	centroid := km.centroids[cluster]
+	for i := range centroid {
+		centroid[i] = 0
+	}
+	for _, dim := range point {
+		centroid[dim] = 1
+	}
+	km.norms[cluster] = float64(len(point))
+}
+
+// init は k-means++ で初期の重心を選ぶ。既に選んだ重心から遠い点ほど選ばれやすい。
+func (km *kMeans) init(rng *rand.Rand) {
+	km.setCentroidToPoint(0, km.points[rng.IntN(len(km.points))])
+
+	nearest := make([]float64, len(km.points))
+	for i, point := range km.points {
+		nearest[i] = km.distance(point, 0)
+	}
+	for cluster := 1; cluster < len(km.centroids); cluster++ {
+		km.setCentroidToPoint(cluster, km.points[km.pick(rng, nearest)])
+		for i, point := range km.points {
+			nearest[i] = min(nearest[i], km.distance(point, cluster))
+		}
+	}
+}
+
+// pick は weights に比例した確率で添字を選ぶ。すべて 0 なら一様に選ぶ。
+func (km *kMeans) pick(rng *rand.Rand, weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += max(w, 0)
+	}
+	if total <= 0 {
+		return rng.IntN(len(weights))
+	}
+
+	target := rng.Float64() * total
+	for i, w := range weights {
+		target -= max(w, 0)
+		if target < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// assign は各点を最も近い重心へ割り当てる。割り当てが変わった点があれば true。
+func (km *kMeans) assign() bool {
+	changed := false
+	for i, point := range km.points {
+		best, bestDistance := 0, km.distance(point, 0)
+		for cluster := 1; cluster < len(km.centroids); cluster++ {
+			if d := km.distance(point, cluster); d < bestDistance {
+				best, bestDistance = cluster, d
+			}
+		}
+		if km.assignments[i] != best {
+			km.assignments[i] = best
+			changed = true
+		}
+	}
+	return changed
+}
+
+// update は重心を割り当てた点の平均にする。空になったクラスタは、いまの重心から最も遠い点で作り直す。
+func (km *kMeans) update(rng *rand.Rand) {
+	sizes := km.sizes()
+	for cluster, centroid := range km.centroids {
+		if sizes[cluster] == 0 {
+			continue
+		}
+		for i := range centroid {
+			centroid[i] = 0
+		}
+	}
+	for i, point := range km.points {
+		cluster := km.assignments[i]
+		for _, dim := range point {
+			km.centroids[cluster][dim]++
+		}
+	}
+	for cluster, centroid := range km.centroids {
+		if sizes[cluster] == 0 {
+			continue
+		}
+		var norm float64
+		for i := range centroid {
+			centroid[i] /= float64(sizes[cluster])
+			norm += centroid[i] * centroid[i]
+		}
+		km.norms[cluster] = norm
+	}
+
+	for cluster, size := range sizes {
+		if size > 0 {
+			continue
+		}
+		distances := make([]float64, len(km.points))
+		for i, point := range km.points {
+			distances[i] = km.distance(point, km.assignments[i])
+		}
+		farthest := 0
+		for i, d := range distances {
+			if d > distances[farthest] {
+				farthest = i
+			}
+		}
+		if distances[farthest] <= 0 {
+			// 全点が重心と重なっている。作り直しても分けられない。
+			farthest = rng.IntN(len(km.points))
+		}
+		km.setCentroidToPoint(cluster, km.points[farthest])
+		km.assignments[farthest] = cluster
+	}
+}
+
+func (km *kMeans) sizes() []int {
+	sizes := make([]int, len(km.centroids))
+	for _, cluster := range km.assignments {
+		if cluster >= 0 {
+			sizes[cluster]++
+		}
+	}
+	return sizes
+}
+
+// HueClusterReport は管理画面に出すクラスタの一覧と、それぞれの代表的な回答。
+type HueClusterReport struct {
+	model     HueClusterModel
+	exemplars map[int][]HueRecord
+}
+
+// NewHueClusterReport は exemplars (クラスタ番号 → 重心に近い順の回答) を添えたレポートを作る。
+func NewHueClusterReport(model HueClusterModel, exemplars map[int][]HueRecord) HueClusterReport {
+	return HueClusterReport{model: model, exemplars: exemplars}
+}
+
+func (r HueClusterReport) Model() HueClusterModel { return r.model }
+
+// Exemplars はクラスタの重心に近い順の回答。
+func (r HueClusterReport) Exemplars(cluster int) []HueRecord {
+	return r.exemplars[cluster]
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClusterHueRecords_SeparatesGroups(t *testing.T) {
+	var records []HueRecord
+	for i := 0; i < 6; i++ {
+		records = append(records, mustCacheRecord(t, "warm", map[string]string{"朝": "赤", "夜": "オレンジ", "心": "ピンク"}))
+	}
+	for i := 0; i < 4; i++ {
+		records = append(records, mustCacheRecord(t, "cool", map[string]string{"朝": "青", "夜": "紫", "心": "緑"}))
+	}
+	// 暖色寄りだが 1 語だけ違う回答。
+	records = append(records, mustCacheRecord(t, "mostly warm", map[string]string{"朝": "赤", "夜": "オレンジ", "心": "白"}))
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	model, assignments, err := ClusterHueRecords(records, 2, DefaultHueClusterIterations, rand.New(rand.NewPCG(1, 2)), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clusters := model.Clusters()
+	if len(clusters) != 2 || clusters[0].Size() != 7 || clusters[1].Size() != 4 || model.RecordCount() != 11 {
+		t.Fatalf("unexpected clusters: %+v", clusters)
+	}
+	for i, assignment := range assignments {
+		want := 0
+		if records[i].Name().String() == "cool" {
+			want = 1
+		}
+		if assignment.RecordID() != records[i].ID() || assignment.Cluster() != want {
+			t.Fatalf("record %d assigned to %d, want %d", i, assignment.Cluster(), want)
+		}
+	}
+
+	top := clusters[0].Associations(2)
+	if len(top) != 2 || top[0].Word() != "夜" || top[0].Color() != "オレンジ" || top[0].Weight() != 1 {
+		t.Fatalf("unexpected associations: %+v", top)
+	}
+	if centroid := clusters[1].Centroid(); centroid["朝"]["青"] != 1 {
+		t.Fatalf("unexpected centroid: %+v", centroid)
+	}
+
+	cool := mustCacheRecord(t, "new", map[string]string{"朝": "青", "夜": "紫"})
+	if nearest, _, ok := model.Nearest(cool.Choices()); !ok || nearest.Index() != 1 {
+		t.Fatalf("expected new cool answer to be nearest to cluster 1, got %+v %v", nearest, ok)
+	}
+}
+
+func TestClusterHueRecords_Invalid(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	now := time.Now()
+	if _, _, err := ClusterHueRecords(nil, 3, 10, rng, now); !errors.Is(err, ErrNotEnoughHueRecords) {
+		t.Fatalf("expected ErrNotEnoughHueRecords, got %v", err)
+	}
+	if _, _, err := ClusterHueRecords(nil, 0, 10, rng, now); !errors.Is(err, ErrInvalidHueCluster) {
+		t.Fatalf("expected ErrInvalidHueCluster, got %v", err)
+	}
+
+	record := mustCacheRecord(t, "only", map[string]string{"朝": "青"})
+	model, _, err := ClusterHueRecords([]HueRecord{record}, 3, 10, rng, now)
+	if err != nil || len(model.Clusters()) != 1 {
+		t.Fatalf("expected k to shrink to the record count, got %d clusters, %v", len(model.Clusters()), err)
+	}
+}
+
+func TestNewHueCluster_Persistence(t *testing.T) {
+	cluster, err := NewHueCluster(0, 3, map[string]map[string]float64{"朝": {"青": 1}, "夜": {"赤": 0.5, "黒": 0.5}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	choices := mustChoices(t, map[string]string{"朝": "青", "夜": "赤"})
+	// |x|^2 = 2, x・c = 1.5, |c|^2 = 1.5
+	if d := cluster.Distance(choices); d != 0.5 {
+		t.Fatalf("expected distance 0.5, got %v", d)
+	}
+
+	if _, err := NewHueCluster(0, 1, map[string]map[string]float64{"朝": {"青": 2}}); !errors.Is(err, ErrInvalidHueCluster) {
+		t.Fatalf("expected ErrInvalidHueCluster for weight > 1, got %v", err)
+	}
+	if _, err := NewHueClusterModel(uuid.New(), time.Now(), 1, []HueCluster{{index: 1}}); !errors.Is(err, ErrInvalidHueCluster) {
+		t.Fatalf("expected ErrInvalidHueCluster for a gap in indexes, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HueJobStatus は結果生成ジョブの状態。
+type HueJobStatus string
+
+const (
+	HueJobStatusPending   HueJobStatus = "pending"
+	HueJobStatusRunning   HueJobStatus = "running"
+	HueJobStatusSucceeded HueJobStatus = "succeeded"
+	HueJobStatusFailed    HueJobStatus = "failed"
+)
+
+// ParseHueJobStatus は永続化された文字列を HueJobStatus に戻す。
+func ParseHueJobStatus(value string) (HueJobStatus, error) {
+	switch status := HueJobStatus(strings.TrimSpace(value)); status {
+	case HueJobStatusPending, HueJobStatusRunning, HueJobStatusSucceeded, HueJobStatusFailed:
+		return status, nil
+	default:
+		return "", ErrInvalidHueJob
+	}
+}
+
+func (s HueJobStatus) String() string {
+	return string(s)
+}
+
+// Finished は成功・失敗のどちらかで確定したかを返す。
+func (s HueJobStatus) Finished() bool {
+	return s == HueJobStatusSucceeded || s == HueJobStatusFailed
+}
+
+// HueJobFailure は結果生成に失敗した原因の分類。再試行待ちや失敗のジョブの状態と一緒にクライアントへ返す。
+type HueJobFailure string
+
+const (
+	HueJobFailureNone        HueJobFailure = ""
+	HueJobFailureRateLimited HueJobFailure = "rate_limited"
+	HueJobFailureTimeout     HueJobFailure = "timeout"
+	HueJobFailureUnavailable HueJobFailure = "unavailable"
+	HueJobFailureRejected    HueJobFailure = "rejected"
+	HueJobFailureInternal    HueJobFailure = "internal"
+)
+
+// HueJobFailureOf は生成器のエラーを原因の分類に変える。nil なら HueJobFailureNone。
+func HueJobFailureOf(err error) HueJobFailure {
+	switch {
+	case err == nil:
+		return HueJobFailureNone
+	case errors.Is(err, ErrGeneratorRateLimited):
+		return HueJobFailureRateLimited
+	case errors.Is(err, ErrGeneratorTimeout):
+		return HueJobFailureTimeout
+	case errors.Is(err, ErrGeneratorUnavailable):
+		return HueJobFailureUnavailable
+	case errors.Is(err, ErrGeneratorRejected):
+		return HueJobFailureRejected
+	default:
+		return HueJobFailureInternal
+	}
+}
+
+// ParseHueJobFailure は永続化された文字列を HueJobFailure に戻す。
+func ParseHueJobFailure(value string) (HueJobFailure, error) {
+	switch failure := HueJobFailure(strings.TrimSpace(value)); failure {
+	case HueJobFailureNone, HueJobFailureRateLimited, HueJobFailureTimeout,
+		HueJobFailureUnavailable, HueJobFailureRejected, HueJobFailureInternal:
+		return failure, nil
+	default:
+		return "", ErrInvalidHueJob
+	}
+}
+
+func (f HueJobFailure) String() string {
+	return string(f)
+}
+
+// HueResultStatus は結果生成の状態と、直近の失敗の原因。
+// 再試行待ち (pending) でも前回の失敗の原因を持つので、クライアントは待たされている理由を示せる。
+type HueResultStatus struct {
+	status  HueJobStatus
+	failure HueJobFailure
+}
+
+func NewHueResultStatus(status HueJobStatus, failure HueJobFailure) HueResultStatus {
+	if status == HueJobStatusSucceeded {
+		failure = HueJobFailureNone
+	}
+	return HueResultStatus{status: status, failure: failure}
+}
+
+func (s HueResultStatus) Status() HueJobStatus {
+	return s.status
+}
+
+func (s HueResultStatus) Failure() HueJobFailure {
+	return s.failure
+}
+
+// Finished は成功・失敗のどちらかで確定したかを返す。
+func (s HueResultStatus) Finished() bool {
+	return s.status.Finished()
+}
+
+// HueGenerationJob はレコード 1 件ぶんの結果生成ジョブ。ジョブ ID はレコード ID と同じ。
+type HueGenerationJob struct {
+	recordID    uuid.UUID
+	status      HueJobStatus
+	attempts    int
+	maxAttempts int
+	runAt       time.Time
+	lastError   string
+	failure     HueJobFailure
+	// bypassCache は結果キャッシュを使わずに生成し直すか。管理者の確認用。
+	bypassCache bool
+}
+
+// NewHueGenerationJob は runAt 以降に実行する未着手のジョブを作る。
+func NewHueGenerationJob(recordID uuid.UUID, maxAttempts int, runAt time.Time) (HueGenerationJob, error) {
+	return NewHueGenerationJobFromPersistence(recordID, HueJobStatusPending, 0, maxAttempts, runAt, "")
+}
+
+// NewHueGenerationJobFromPersistence は永続化済みの値からジョブを再構築する。
+func NewHueGenerationJobFromPersistence(recordID uuid.UUID, status HueJobStatus, attempts, maxAttempts int, runAt time.Time, lastError string) (HueGenerationJob, error) {
+	if recordID == uuid.Nil || maxAttempts <= 0 || attempts < 0 || runAt.IsZero() {
+		return HueGenerationJob{}, ErrInvalidHueJob
+	}
+	if _, err := ParseHueJobStatus(string(status)); err != nil {
+		return HueGenerationJob{}, err
+	}
+
+	return HueGenerationJob{
+		recordID:    recordID,
+		status:      status,
+		attempts:    attempts,
+		maxAttempts: maxAttempts,
+		runAt:       runAt.UTC(),
+		lastError:   lastError,
+	}, nil
+}
+
+func (j HueGenerationJob) RecordID() uuid.UUID {
+	return j.recordID
+}
+
+func (j HueGenerationJob) Status() HueJobStatus {
+	return j.status
+}
+
+// Attempts はこれまでに取り出された回数 (実行中の回を含む)。
+func (j HueGenerationJob) Attempts() int {
+	return j.attempts
+}
+
+func (j HueGenerationJob) MaxAttempts() int {
+	return j.maxAttempts
+}
+
+func (j HueGenerationJob) RunAt() time.Time {
+	return j.runAt
+}
+
+func (j HueGenerationJob) LastError() string {
+	return j.lastError
+}
+
+// WithFailure は直近の失敗の原因を設定したコピーを返す。
+func (j HueGenerationJob) WithFailure(failure HueJobFailure) HueGenerationJob {
+	j.failure = failure
+	return j
+}
+
+// Failure は直近の失敗の原因。失敗していなければ HueJobFailureNone。
+func (j HueGenerationJob) Failure() HueJobFailure {
+	return j.failure
+}
+
+// WithBypassCache は結果キャッシュを使うかどうかを設定したコピーを返す。
+func (j HueGenerationJob) WithBypassCache(bypass bool) HueGenerationJob {
+	j.bypassCache = bypass
+	return j
+}
+
+// BypassesCache は結果キャッシュを読まずに生成するかを返す。
+func (j HueGenerationJob) BypassesCache() bool {
+	return j.bypassCache
+}
+
+// CanRetry は失敗したときにもう一度試せるかを返す。
+func (j HueGenerationJob) CanRetry() bool {
+	return j.attempts < j.maxAttempts
+}
+
+// HueResultStreamSink は結果生成の途中経過を受け取る口。
+// Status は状態が変わるたび、Message はメッセージの続きが届くたびに呼ばれる。
+// Reset は生成がやり直されたときに呼ばれ、それまでに受け取ったメッセージは捨ててよい。
+// Result は生成が終わったときに 1 回だけ呼ばれ、その message が正となる。
+type HueResultStreamSink interface {
+	Status(record HueRecord, status HueResultStatus) error
+	Message(delta string) error
+	Reset() error
+	Result(record HueRecord) error
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewHueGenerationJob(t *testing.T) {
+	job, err := NewHueGenerationJob(uuid.New(), 3, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if job.Status() != HueJobStatusPending || job.Attempts() != 0 || !job.CanRetry() {
+		t.Fatalf("unexpected new job: %+v", job)
+	}
+	if job.Status().Finished() {
+		t.Fatalf("pending job should not be finished")
+	}
+}
+
+func TestNewHueGenerationJobFromPersistence(t *testing.T) {
+	job, err := NewHueGenerationJobFromPersistence(uuid.New(), HueJobStatusRunning, 3, 3, time.Now(), "timeout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.CanRetry() || job.LastError() != "timeout" {
+		t.Fatalf("expected last attempt to be exhausted: %+v", job)
+	}
+
+	if _, err := NewHueGenerationJobFromPersistence(uuid.Nil, HueJobStatusPending, 0, 3, time.Now(), ""); !errors.Is(err, ErrInvalidHueJob) {
+		t.Fatalf("expected ErrInvalidHueJob for nil id, got %v", err)
+	}
+	if _, err := NewHueGenerationJobFromPersistence(uuid.New(), "queued", 0, 3, time.Now(), ""); !errors.Is(err, ErrInvalidHueJob) {
+		t.Fatalf("expected ErrInvalidHueJob for unknown status, got %v", err)
+	}
+}
+
+func TestHueJobFailureOf(t *testing.T) {
+	cases := []struct {
+		err  error
+		want HueJobFailure
+	}{
+		{err: nil, want: HueJobFailureNone},
+		{err: fmt.Errorf("generate: %w", ErrGeneratorRateLimited), want: HueJobFailureRateLimited},
+		{err: errors.Join(ErrGeneratorTimeout, errors.New("fallback failed")), want: HueJobFailureTimeout},
+		{err: ErrGeneratorUnavailable, want: HueJobFailureUnavailable},
+		{err: ErrGeneratorRejected, want: HueJobFailureRejected},
+		{err: errors.New("db down"), want: HueJobFailureInternal},
+	}
+	for _, tc := range cases {
+		if got := HueJobFailureOf(tc.err); got != tc.want {
+			t.Fatalf("HueJobFailureOf(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+
+	if _, err := ParseHueJobFailure("quota"); !errors.Is(err, ErrInvalidHueJob) {
+		t.Fatalf("expected ErrInvalidHueJob for unknown failure, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// MaxPromptWeight は A/B 割り当ての重みの上限。
+	MaxPromptWeight = 10000
+	// DefaultPromptWeight は重みを省略して有効化したときの値。
+	DefaultPromptWeight = 100
+)
+
+var promptVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// HuePromptVersion は結果生成に使うプロンプトの版。system と user は text/template で書く。
+// 有効な版のうち weight の比で回答ごとに 1 つを割り当てる。
+type HuePromptVersion struct {
+	version        string
+	systemTemplate string
+	userTemplate   string
+	system         *template.Template
+	user           *template.Template
+	weight         int
+	active         bool
+	createdAt      time.Time
+	activatedAt    time.Time
+	usesName       bool
+}
+
+// NewHuePromptVersion は新しい (無効な) 版を作る。テンプレートが解釈できなければ ErrInvalidPromptVersion。
+func NewHuePromptVersion(version, systemTemplate, userTemplate string) (HuePromptVersion, error) {
+	return NewHuePromptVersionFromPersistence(version, systemTemplate, userTemplate, 0, false, time.Now().UTC(), time.Time{})
+}
+
+// NewHuePromptVersionFromPersistence は保存済みの版を再構築する。activatedAt は未有効化ならゼロ値。
+func NewHuePromptVersionFromPersistence(version, systemTemplate, userTemplate string, weight int, active bool, createdAt, activatedAt time.Time) (HuePromptVersion, error) {
+	version = strings.TrimSpace(version)
+	if !promptVersionPattern.MatchString(version) {
+		return HuePromptVersion{}, ErrInvalidPromptVersion
+	}
+	if strings.TrimSpace(systemTemplate) == "" || strings.TrimSpace(userTemplate) == "" {
+		return HuePromptVersion{}, ErrInvalidPromptVersion
+	}
+	if weight < 0 || weight > MaxPromptWeight {
+		return HuePromptVersion{}, ErrInvalidPromptVersion
+	}
+
+	system, err := parsePromptTemplate("system", systemTemplate)
+	if err != nil {
+		return HuePromptVersion{}, err
+	}
+	user, err := parsePromptTemplate("user", userTemplate)
+	if err != nil {
+		return HuePromptVersion{}, err
+	}
+
+	return HuePromptVersion{
+		version:        version,
+		systemTemplate: systemTemplate,
+		userTemplate:   userTemplate,
+		system:         system,
+		user:           user,
+		weight:         weight,
+		active:         active,
+		createdAt:      createdAt.UTC(),
+		activatedAt:    activatedAt.UTC(),
+		usesName:       templatesUseName(system, user),
+	}, nil
+}
+
+// parsePromptTemplate は見本のデータで一度描画し、存在しない項目の参照なども作成時に弾く。
+func parsePromptTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, ErrInvalidPromptVersion
+	}
+	sample := HuePromptData{Name: "sample", Choices: []HuePromptChoice{{Word: "夜", Color: "青"}}}
+	if err := tmpl.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, ErrInvalidPromptVersion
+	}
+	return tmpl, nil
+}
+
+// templatesUseName は名前だけを変えて描画し、出力が変わるかで参加者名の参照を判定する。
+func templatesUseName(templates ...*template.Template) bool {
+	choices := []HuePromptChoice{{Word: "夜", Color: "青"}}
+	for _, tmpl := range templates {
+		var a, b strings.Builder
+		_ = tmpl.Execute(&a, HuePromptData{Name: "a", Choices: choices})
+		_ = tmpl.Execute(&b, HuePromptData{Name: "b", Choices: choices})
+		if a.String() != b.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p HuePromptVersion) Version() string {
+	return p.version
+}
+
+func (p HuePromptVersion) SystemTemplate() string {
+	return p.systemTemplate
+}
+
+func (p HuePromptVersion) UserTemplate() string {
+	return p.userTemplate
+}
+
+func (p HuePromptVersion) Weight() int {
+	return p.weight
+}
+
+func (p HuePromptVersion) Active() bool {
+	return p.active
+}
+
+func (p HuePromptVersion) CreatedAt() time.Time {
+	return p.createdAt
+}
+
+// UsesName はテンプレートが参加者名を参照するかを返す。参照しない版の結果は名前を問わず使い回せる。
+func (p HuePromptVersion) UsesName() bool {
+	return p.usesName
+}
+
+// ActivatedAt は最後に有効化された日時。一度も有効化されていなければ false。
+func (p HuePromptVersion) ActivatedAt() (time.Time, bool) {
+	return p.activatedAt, !p.activatedAt.IsZero()
+}
+
+// Activate は weight で有効化したコピーを返す。weight が範囲外なら ErrInvalidPromptVersion。
+func (p HuePromptVersion) Activate(weight int, now time.Time) (HuePromptVersion, error) {
+	if weight <= 0 || weight > MaxPromptWeight {
+		return HuePromptVersion{}, ErrInvalidPromptVersion
+	}
+	p.weight = weight
+	p.active = true
+	p.activatedAt = now.UTC()
+	return p, nil
+}
+
+// HuePromptData はテンプレートへ渡す値。Choices は語彙の昇順。
+type HuePromptData struct {
+	Name    string
+	Choices []HuePromptChoice
+}
+
+// HuePromptChoice は 1 つの回答 (語彙と選んだ色)。
+type HuePromptChoice struct {
+	Word  string
+	Color string
+}
+
+// NewHuePromptData はレコードからテンプレートへ渡す値を作る。
+func NewHuePromptData(record HueRecord) HuePromptData {
+	choices := record.ChoiceMap()
+	words := make([]string, 0, len(choices))
+	for word := range choices {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	data := HuePromptData{Name: record.Name().String(), Choices: make([]HuePromptChoice, len(words))}
+	for i, word := range words {
+		data.Choices[i] = HuePromptChoice{Word: word, Color: choices[word]}
+	}
+	return data
+}
+
+// Render は system と user のメッセージを描画する。
+func (p HuePromptVersion) Render(data HuePromptData) (string, string, error) {
+	var system, user strings.Builder
+	if err := p.system.Execute(&system, data); err != nil {
+		return "", "", err
+	}
+	if err := p.user.Execute(&user, data); err != nil {
+		return "", "", err
+	}
+	return system.String(), user.String(), nil
+}
+
+// ChooseHuePromptVersion は有効な版から weight の比で 1 つ選ぶ。roll は [0, 1) の乱数。
+// 選べる版が無ければ ErrNoActivePromptVersion。
+func ChooseHuePromptVersion(versions []HuePromptVersion, roll float64) (HuePromptVersion, error) {
+	total := 0
+	for _, p := range versions {
+		if p.active {
+			total += p.weight
+		}
+	}
+	if total == 0 {
+		return HuePromptVersion{}, ErrNoActivePromptVersion
+	}
+
+	point := int(roll * float64(total))
+	for _, p := range versions {
+		if !p.active || p.weight == 0 {
+			continue
+		}
+		if point < p.weight {
+			return p, nil
+		}
+		point -= p.weight
+	}
+	// roll が 1 に丸められた場合など。
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].active && versions[i].weight > 0 {
+			return versions[i], nil
+		}
+	}
+	return HuePromptVersion{}, ErrNoActivePromptVersion
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// v1 の user テンプレート (マイグレーションで投入するもの)。
+const huePromptV1User = `選択 : (語彙, 色) = {{range $i, $c := .Choices}}{{if $i}}, {{end}}({{$c.Word}}, {{$c.Color}}){{end}}`
+
+func TestHuePromptVersion_Render(t *testing.T) {
+	prompt, err := NewHuePromptVersion("v1", "{{.Name}} さんの結果を作ってください。", huePromptV1User)
+	if err != nil {
+		t.Fatalf("prompt error: %v", err)
+	}
+	record, err := NewHueRecordFromRaw("tester", map[string]string{"朝": "青", "夜": "赤"})
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+
+	system, user, err := prompt.Render(NewHuePromptData(record))
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	if system != "tester さんの結果を作ってください。" {
+		t.Fatalf("unexpected system prompt: %s", system)
+	}
+	if user != "選択 : (語彙, 色) = (夜, 赤), (朝, 青)" {
+		t.Fatalf("unexpected user prompt: %s", user)
+	}
+}
+
+func TestNewHuePromptVersion_Invalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		version string
+		system  string
+		user    string
+	}{
+		{name: "empty version", version: "", system: "s", user: "u"},
+		{name: "version with space", version: "v 2", system: "s", user: "u"},
+		{name: "empty system", version: "v2", system: " ", user: "u"},
+		{name: "broken template", version: "v2", system: "{{.Name", user: "u"},
+		{name: "unknown field", version: "v2", system: "s", user: "{{.Age}}"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewHuePromptVersion(tc.version, tc.system, tc.user); !errors.Is(err, ErrInvalidPromptVersion) {
+				t.Fatalf("expected ErrInvalidPromptVersion, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHuePromptVersion_Activate(t *testing.T) {
+	prompt, err := NewHuePromptVersion("v2", "s", "u")
+	if err != nil {
+		t.Fatalf("prompt error: %v", err)
+	}
+	if prompt.Active() {
+		t.Fatalf("expected new prompt to be inactive")
+	}
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	active, err := prompt.Activate(30, now)
+	if err != nil {
+		t.Fatalf("activate error: %v", err)
+	}
+	if at, ok := active.ActivatedAt(); !active.Active() || active.Weight() != 30 || !ok || !at.Equal(now) {
+		t.Fatalf("unexpected activated prompt: %+v", active)
+	}
+
+	for _, weight := range []int{0, -1, MaxPromptWeight + 1} {
+		if _, err := prompt.Activate(weight, now); !errors.Is(err, ErrInvalidPromptVersion) {
+			t.Fatalf("weight %d: expected ErrInvalidPromptVersion, got %v", weight, err)
+		}
+	}
+}
+
+func TestChooseHuePromptVersion(t *testing.T) {
+	now := time.Now()
+	build := func(version string, weight int, active bool) HuePromptVersion {
+		t.Helper()
+		p, err := NewHuePromptVersionFromPersistence(version, "s", "u", weight, active, now, now)
+		if err != nil {
+			t.Fatalf("prompt error: %v", err)
+		}
+		return p
+	}
+	versions := []HuePromptVersion{build("a", 75, true), build("off", 100, false), build("b", 25, true)}
+
+	cases := map[float64]string{0: "a", 0.74: "a", 0.75: "b", 0.9999: "b", 1: "b"}
+	for roll, want := range cases {
+		got, err := ChooseHuePromptVersion(versions, roll)
+		if err != nil {
+			t.Fatalf("roll %v: unexpected error: %v", roll, err)
+		}
+		if got.Version() != want {
+			t.Fatalf("roll %v: expected %s, got %s", roll, want, got.Version())
+		}
+	}
+
+	if _, err := ChooseHuePromptVersion([]HuePromptVersion{build("off", 100, false)}, 0.5); !errors.Is(err, ErrNoActivePromptVersion) {
+		t.Fatalf("expected ErrNoActivePromptVersion, got %v", err)
+	}
+}
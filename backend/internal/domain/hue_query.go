@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultRecordPageSize は limit 省略時の件数。
+	DefaultRecordPageSize = 100
+	// MaxRecordPageSize は 1 回で返す件数の上限。旧来の data-range 指定にも適用する。
+	MaxRecordPageSize = 500
+)
+
+// RecordCursor は (created_at, id) の並びで「ここより後」を指すキーセットカーソル。
+type RecordCursor struct {
+	createdAt time.Time
+	id        uuid.UUID
+}
+
+func NewRecordCursor(createdAt time.Time, id uuid.UUID) (RecordCursor, error) {
+	if createdAt.IsZero() || id == uuid.Nil {
+		return RecordCursor{}, ErrInvalidCursor
+	}
+
+	// Postgres の timestamptz はマイクロ秒精度なので、往復で値が変わらないよう揃える。
+	return RecordCursor{createdAt: createdAt.UTC().Truncate(time.Microsecond), id: id}, nil
+}
+
+// ParseRecordCursor は String() で得た不透明な文字列を復元する。
+func ParseRecordCursor(value string) (RecordCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return RecordCursor{}, ErrInvalidCursor
+	}
+
+	micros, rawID, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return RecordCursor{}, ErrInvalidCursor
+	}
+
+	unixMicro, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return RecordCursor{}, ErrInvalidCursor
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return RecordCursor{}, ErrInvalidCursor
+	}
+
+	return NewRecordCursor(time.UnixMicro(unixMicro), id)
+}
+
+// String はクライアントへ返す不透明なカーソル文字列。
+func (c RecordCursor) String() string {
+	raw := strconv.FormatInt(c.createdAt.UnixMicro(), 10) + ":" + c.id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func (c RecordCursor) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+func (c RecordCursor) ID() uuid.UUID {
+	return c.id
+}
+
+// HueRecordFilter は管理画面からの絞り込み条件。ゼロ値は絞り込み無し。
+type HueRecordFilter struct {
+	window       TimeWindow
+	nameContains string
+	choices      map[string]string
+	minWords     int
+	userID       uuid.UUID
+}
+
+// NewHueRecordFilter は期間・名前の部分一致・「語彙にこの色を選んだ」・最低回答語数から条件を作る。
+// choices はすべて満たすレコードだけを残す (JSONB の包含)。
+func NewHueRecordFilter(window TimeWindow, nameContains string, choices map[string]string, minWords int) (HueRecordFilter, error) {
+	if minWords < 0 {
+		return HueRecordFilter{}, ErrInvalidFilter
+	}
+
+	var normalized map[string]string
+	if len(choices) > 0 {
+		// 過去の版の語彙でも絞り込めるよう、質問票では検証しない。
+		parsed, err := newHueChoices(choices)
+		if err != nil {
+			return HueRecordFilter{}, ErrInvalidFilter
+		}
+		normalized = parsed.ToMap()
+	}
+
+	return HueRecordFilter{
+		window:       window,
+		nameContains: strings.TrimSpace(nameContains),
+		choices:      normalized,
+		minWords:     minWords,
+	}, nil
+}
+
+func (f HueRecordFilter) Window() TimeWindow {
+	return f.window
+}
+
+// NameContains は名前の部分一致条件を返す。指定が無ければ false。
+func (f HueRecordFilter) NameContains() (string, bool) {
+	return f.nameContains, f.nameContains != ""
+}
+
+// Choices は語彙ごとに要求する色を返す。指定が無ければ nil。
+func (f HueRecordFilter) Choices() map[string]string {
+	if f.choices == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(f.choices))
+	for word, color := range f.choices {
+		copied[word] = color
+	}
+	return copied
+}
+
+// MinWords は回答語数の下限。0 なら無制限。
+func (f HueRecordFilter) MinWords() int {
+	return f.minWords
+}
+
+// WithUserID はそのユーザーの回答だけに絞ったコピーを返す。
+func (f HueRecordFilter) WithUserID(id uuid.UUID) HueRecordFilter {
+	f.userID = id
+	return f
+}
+
+// UserID は回答したユーザーの条件を返す。指定が無ければ false。
+func (f HueRecordFilter) UserID() (uuid.UUID, bool) {
+	return f.userID, f.userID != uuid.Nil
+}
+
+// HueRecordQuery は管理画面からのレコード取得条件。
+// 旧来の位置指定 (RecordRange) かカーソル指定のどちらか一方と、絞り込み条件を持つ。
+type HueRecordQuery struct {
+	recordRange RecordRange
+	hasRange    bool
+	cursor      RecordCursor
+	hasCursor   bool
+	limit       int
+	filter      HueRecordFilter
+}
+
+// NewRangeRecordQuery は旧来の data-range 指定。件数は MaxRecordPageSize で頭打ちにする。
+// end が int の上限付近でも溢れないよう、+1 する前に上限と比べる。
+func NewRangeRecordQuery(recordRange RecordRange) HueRecordQuery {
+	limit := MaxRecordPageSize
+	if span := recordRange.End() - recordRange.Begin(); span < MaxRecordPageSize {
+		limit = span + 1
+	}
+	return HueRecordQuery{
+		recordRange: recordRange,
+		hasRange:    true,
+		limit:       limit,
+	}
+}
+
+// NewCursorRecordQuery は cursor (nil なら先頭) から limit 件を取る指定。
+// limit が 0 以下なら DefaultRecordPageSize、上限超過は MaxRecordPageSize に丸める。
+func NewCursorRecordQuery(cursor *RecordCursor, limit int) HueRecordQuery {
+	switch {
+	case limit <= 0:
+		limit = DefaultRecordPageSize
+	case limit > MaxRecordPageSize:
+		limit = MaxRecordPageSize
+	}
+
+	q := HueRecordQuery{limit: limit}
+	if cursor != nil {
+		q.cursor = *cursor
+		q.hasCursor = true
+	}
+	return q
+}
+
+// Range は位置指定の場合にその範囲を返す。
+func (q HueRecordQuery) Range() (RecordRange, bool) {
+	return q.recordRange, q.hasRange
+}
+
+// Cursor はカーソル指定の場合にその位置を返す。先頭からなら false。
+func (q HueRecordQuery) Cursor() (RecordCursor, bool) {
+	return q.cursor, q.hasCursor
+}
+
+func (q HueRecordQuery) Limit() int {
+	return q.limit
+}
+
+// WithFilter は絞り込み条件を付けたコピーを返す。
+func (q HueRecordQuery) WithFilter(filter HueRecordFilter) HueRecordQuery {
+	q.filter = filter
+	return q
+}
+
+func (q HueRecordQuery) Filter() HueRecordFilter {
+	return q.filter
+}
+
+// HueRecordPage は取得結果と、続きがある場合の次カーソル。
+type HueRecordPage struct {
+	records []HueRecord
+	next    RecordCursor
+	hasNext bool
+}
+
+func NewHueRecordPage(records []HueRecord, next *RecordCursor) HueRecordPage {
+	page := HueRecordPage{records: records}
+	if next != nil {
+		page.next = *next
+		page.hasNext = true
+	}
+	return page
+}
+
+func (p HueRecordPage) Records() []HueRecord {
+	return p.records
+}
+
+// Next は次ページのカーソルを返す。最後のページなら false。
+func (p HueRecordPage) Next() (RecordCursor, bool) {
+	return p.next, p.hasNext
+}
+
+// HueRecordSink は書き出し処理がレコードを 1 件ずつ受け取るための口。
+// Begin は最初に 1 回だけ、出力に現れる語彙の一覧 (昇順) とともに呼ばれる。
+type HueRecordSink interface {
+	Begin(words []string) error
+	Write(record HueRecord) error
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecordCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 5, 1, 12, 30, 0, 123456789, time.FixedZone("JST", 9*60*60))
+	id := uuid.New()
+
+	cursor, err := NewRecordCursor(createdAt, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parsed, err := ParseRecordCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if !parsed.CreatedAt().Equal(createdAt.Truncate(time.Microsecond)) || parsed.ID() != id {
+		t.Fatalf("cursor did not round-trip: %v %v", parsed.CreatedAt(), parsed.ID())
+	}
+}
+
+func TestParseRecordCursor_Invalid(t *testing.T) {
+	for _, value := range []string{"", "!!!", "bm8tY29sb24", "MTIzOm5vdC1hLXV1aWQ"} {
+		if _, err := ParseRecordCursor(value); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestNewCursorRecordQuery_ClampsLimit(t *testing.T) {
+	if q := NewCursorRecordQuery(nil, 0); q.Limit() != DefaultRecordPageSize {
+		t.Fatalf("expected default limit, got %d", q.Limit())
+	}
+	if q := NewCursorRecordQuery(nil, MaxRecordPageSize+1); q.Limit() != MaxRecordPageSize {
+		t.Fatalf("expected max limit, got %d", q.Limit())
+	}
+	if _, ok := NewCursorRecordQuery(nil, 10).Cursor(); ok {
+		t.Fatalf("expected no cursor for first page")
+	}
+}
+
+func TestNewRangeRecordQuery_CapsLimit(t *testing.T) {
+	recordRange, err := NewRecordRange(0, 999)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	q := NewRangeRecordQuery(recordRange)
+	if q.Limit() != MaxRecordPageSize {
+		t.Fatalf("expected range to be capped, got %d", q.Limit())
+	}
+	if _, ok := q.Range(); !ok {
+		t.Fatalf("expected range query")
+	}
+
+	huge, err := NewRecordRange(0, math.MaxInt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := NewRangeRecordQuery(huge); q.Limit() != MaxRecordPageSize {
+		t.Fatalf("expected huge range to be capped, got %d", q.Limit())
+	}
+}
+
+func TestNewHueRecordFilter(t *testing.T) {
+	window, err := NewTimeWindow(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filter, err := NewHueRecordFilter(window, "  たろう ", map[string]string{" 夜 ": "黒"}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if name, ok := filter.NameContains(); !ok || name != "たろう" {
+		t.Fatalf("unexpected name filter: %q", name)
+	}
+	if got := filter.Choices(); len(got) != 1 || got["夜"] != "黒" {
+		t.Fatalf("unexpected choices filter: %v", got)
+	}
+	if filter.MinWords() != 3 {
+		t.Fatalf("unexpected min words: %d", filter.MinWords())
+	}
+
+	q := NewCursorRecordQuery(nil, 10).WithFilter(filter)
+	if from, ok := q.Filter().Window().From(); !ok || !from.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected filter to be attached to query")
+	}
+}
+
+func TestNewHueRecordFilter_Invalid(t *testing.T) {
+	if _, err := NewHueRecordFilter(TimeWindow{}, "", nil, -1); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("expected ErrInvalidFilter for negative min words, got %v", err)
+	}
+	if _, err := NewHueRecordFilter(TimeWindow{}, "", map[string]string{"夜": "金"}, 0); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("expected ErrInvalidFilter for unknown color, got %v", err)
+	}
+
+	filter, err := NewHueRecordFilter(TimeWindow{}, " ", nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := filter.NameContains(); ok || filter.Choices() != nil {
+		t.Fatalf("expected empty filter")
+	}
+}
+
+func TestHueRecordFilter_WithUserID(t *testing.T) {
+	if _, ok := (HueRecordFilter{}).UserID(); ok {
+		t.Fatalf("expected zero filter to have no user")
+	}
+	id := uuid.New()
+	if got, ok := (HueRecordFilter{}).WithUserID(id).UserID(); !ok || got != id {
+		t.Fatalf("expected user %s, got %s %v", id, got, ok)
+	}
+}
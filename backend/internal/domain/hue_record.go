@@ -1,12 +1,25 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // HueRecord は参加者名と色割り当てをまとめた値オブジェクト。
 type HueRecord struct {
-	id      uuid.UUID
-	name    Name
-	choices HueChoices
+	id        uuid.UUID
+	name      Name
+	choices   HueChoices
+	createdAt time.Time
+	// promptVersion は回答時に割り当てたプロンプトの版。割り当て前のレコードは空文字。
+	promptVersion string
+	result        HueRecordResult
+	hasResult     bool
+	// shareChoices は結果ページで回答そのものの公開を本人が許可したかどうか。
+	shareChoices bool
+	// userID はログインして回答したユーザー。匿名の回答では uuid.Nil。
+	userID uuid.UUID
 }
 
 // NewHueRecord は空の選択を拒否し、完全なレコードを構築する。
@@ -16,14 +29,15 @@ func NewHueRecord(name Name, choices HueChoices) (HueRecord, error) {
 	}
 
 	return HueRecord{
-		id:      uuid.New(),
-		name:    name,
-		choices: choices,
+		id:        uuid.New(),
+		name:      name,
+		choices:   choices,
+		createdAt: time.Now().UTC(),
 	}, nil
 }
 
 // NewHueRecordFromPersistence は永続化済みデータから HueRecord を再構築する。
-func NewHueRecordFromPersistence(id uuid.UUID, name Name, choices HueChoices) (HueRecord, error) {
+func NewHueRecordFromPersistence(id uuid.UUID, name Name, choices HueChoices, createdAt time.Time) (HueRecord, error) {
 	if id == uuid.Nil {
 		return HueRecord{}, ErrInvalidChoice
 	}
@@ -36,10 +50,15 @@ func NewHueRecordFromPersistence(id uuid.UUID, name Name, choices HueChoices) (H
 		return HueRecord{}, ErrEmptyName
 	}
 
+	if createdAt.IsZero() {
+		return HueRecord{}, ErrInvalidChoice
+	}
+
 	return HueRecord{
-		id:      id,
-		name:    name,
-		choices: choices,
+		id:        id,
+		name:      name,
+		choices:   choices,
+		createdAt: createdAt.UTC(),
 	}, nil
 }
 
@@ -66,10 +85,64 @@ func (r HueRecord) Name() Name {
 	return r.name
 }
 
+func (r HueRecord) CreatedAt() time.Time {
+	return r.createdAt
+}
+
 func (r HueRecord) Choices() HueChoices {
 	return r.choices
 }
 
+// QuestionnaireVersion は回答した質問票の版。質問票の導入前のレコードは空文字。
+func (r HueRecord) QuestionnaireVersion() string {
+	return r.choices.QuestionnaireVersion()
+}
+
 func (r HueRecord) ChoiceMap() map[string]string {
 	return r.choices.ToMap()
 }
+
+// WithPromptVersion は割り当てたプロンプトの版を設定したコピーを返す。
+func (r HueRecord) WithPromptVersion(version string) HueRecord {
+	r.promptVersion = version
+	return r
+}
+
+// PromptVersion は回答時に割り当てたプロンプトの版。
+func (r HueRecord) PromptVersion() string {
+	return r.promptVersion
+}
+
+// WithResult は生成結果を添えたコピーを返す。
+func (r HueRecord) WithResult(result HueRecordResult) HueRecord {
+	r.result = result
+	r.hasResult = true
+	return r
+}
+
+// Result は生成結果を返す。まだ生成されていなければ false。
+func (r HueRecord) Result() (HueRecordResult, bool) {
+	return r.result, r.hasResult
+}
+
+// WithShareChoices は回答公開の可否を設定したコピーを返す。
+func (r HueRecord) WithShareChoices(share bool) HueRecord {
+	r.shareChoices = share
+	return r
+}
+
+// SharesChoices は公開ページで回答を見せてよいかを返す。
+func (r HueRecord) SharesChoices() bool {
+	return r.shareChoices
+}
+
+// WithUserID は回答したユーザーを紐付けたコピーを返す。uuid.Nil なら匿名に戻す。
+func (r HueRecord) WithUserID(id uuid.UUID) HueRecord {
+	r.userID = id
+	return r
+}
+
+// UserID は回答したユーザーを返す。匿名の回答なら false。
+func (r HueRecord) UserID() (uuid.UUID, bool) {
+	return r.userID, r.userID != uuid.Nil
+}
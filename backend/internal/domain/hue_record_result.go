@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// HueRecordResult は hue_records に保存する生成結果と、その生成条件をまとめる。
+type HueRecordResult struct {
+	result        HueResult
+	generator     string
+	promptVersion string
+	latency       time.Duration
+	generatedAt   time.Time
+}
+
+// NewHueRecordResult は生成器名と生成時刻を必須として構築する。
+func NewHueRecordResult(result HueResult, generator, promptVersion string, latency time.Duration, generatedAt time.Time) (HueRecordResult, error) {
+	g := strings.TrimSpace(generator)
+	if g == "" || result.Message() == "" || latency < 0 || generatedAt.IsZero() {
+		return HueRecordResult{}, ErrInvalidHueResult
+	}
+
+	return HueRecordResult{
+		result:        result,
+		generator:     g,
+		promptVersion: strings.TrimSpace(promptVersion),
+		latency:       latency,
+		generatedAt:   generatedAt.UTC(),
+	}, nil
+}
+
+func (r HueRecordResult) Result() HueResult {
+	return r.result
+}
+
+// Generator は生成器とモデルの識別子 (例: "openai-responses:gpt-4.1") を返す。
+func (r HueRecordResult) Generator() string {
+	return r.generator
+}
+
+func (r HueRecordResult) PromptVersion() string {
+	return r.promptVersion
+}
+
+func (r HueRecordResult) Latency() time.Duration {
+	return r.latency
+}
+
+func (r HueRecordResult) GeneratedAt() time.Time {
+	return r.generatedAt
+}
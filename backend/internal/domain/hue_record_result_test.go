@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewHueRecordResult(t *testing.T) {
+	hueResult, err := NewHueResultFromRaw(1, 2, 3, "message")
+	if err != nil {
+		t.Fatalf("hue result error: %v", err)
+	}
+
+	generatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("JST", 9*60*60))
+	result, err := NewHueRecordResult(hueResult.WithSource(HueResultSourceModel), " openai-responses:gpt-4.1 ", "v1", 1500*time.Millisecond, generatedAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Generator() != "openai-responses:gpt-4.1" {
+		t.Fatalf("expected trimmed generator, got %s", result.Generator())
+	}
+	if !result.GeneratedAt().Equal(generatedAt) || result.GeneratedAt().Location() != time.UTC {
+		t.Fatalf("expected UTC generated_at, got %v", result.GeneratedAt())
+	}
+	if result.Result().Source() != HueResultSourceModel {
+		t.Fatalf("expected model source, got %s", result.Result().Source())
+	}
+
+	record, err := NewHueRecordFromRaw("Tester", map[string]string{"平和": "青"})
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	if _, ok := record.Result(); ok {
+		t.Fatalf("expected record without result")
+	}
+	if got, ok := record.WithResult(result).Result(); !ok || got != result {
+		t.Fatalf("expected attached result")
+	}
+}
+
+func TestNewHueRecordResult_Invalid(t *testing.T) {
+	hueResult, err := NewHueResultFromRaw(1, 2, 3, "message")
+	if err != nil {
+		t.Fatalf("hue result error: %v", err)
+	}
+
+	if _, err := NewHueRecordResult(hueResult, " ", "v1", time.Second, time.Now()); !errors.Is(err, ErrInvalidHueResult) {
+		t.Fatalf("expected ErrInvalidHueResult for empty generator, got %v", err)
+	}
+	if _, err := NewHueRecordResult(hueResult, "fake", "v1", -time.Second, time.Now()); !errors.Is(err, ErrInvalidHueResult) {
+		t.Fatalf("expected ErrInvalidHueResult for negative latency, got %v", err)
+	}
+	if _, err := NewHueRecordResult(hueResult, "fake", "v1", time.Second, time.Time{}); !errors.Is(err, ErrInvalidHueResult) {
+		t.Fatalf("expected ErrInvalidHueResult for zero time, got %v", err)
+	}
+}
+
+func TestParseHueResultSource(t *testing.T) {
+	if source, err := ParseHueResultSource("fallback"); err != nil || source != HueResultSourceFallback {
+		t.Fatalf("expected fallback, got %s (%v)", source, err)
+	}
+	if _, err := ParseHueResultSource("other"); !errors.Is(err, ErrInvalidHueResult) {
+		t.Fatalf("expected ErrInvalidHueResult, got %v", err)
+	}
+}
@@ -26,10 +26,33 @@ func (h HueRGB) R() int { return h.r }
 func (h HueRGB) G() int { return h.g }
 func (h HueRGB) B() int { return h.b }
 
+// HueResultSource は結果がモデル由来かルールベースのフォールバック由来かを表す。
+type HueResultSource string
+
+const (
+	HueResultSourceModel    HueResultSource = "model"
+	HueResultSourceFallback HueResultSource = "fallback"
+)
+
+// ParseHueResultSource は永続化された文字列を HueResultSource に戻す。
+func ParseHueResultSource(value string) (HueResultSource, error) {
+	switch source := HueResultSource(strings.TrimSpace(value)); source {
+	case HueResultSourceModel, HueResultSourceFallback:
+		return source, nil
+	default:
+		return "", ErrInvalidHueResult
+	}
+}
+
+func (s HueResultSource) String() string {
+	return string(s)
+}
+
 // HueResult は結果確認画面で表示する色とメッセージをまとめる。
 type HueResult struct {
 	hue     HueRGB
 	message string
+	source  HueResultSource
 }
 
 // NewHueResult は hue の妥当性とメッセージの空チェックを行う。
@@ -52,5 +75,12 @@ func NewHueResultFromRaw(r, g, b int, message string) (HueResult, error) {
 	return NewHueResult(hue, message)
 }
 
-func (r HueResult) Hue() HueRGB     { return r.hue }
-func (r HueResult) Message() string { return r.message }
+func (r HueResult) Hue() HueRGB             { return r.hue }
+func (r HueResult) Message() string         { return r.message }
+func (r HueResult) Source() HueResultSource { return r.source }
+
+// WithSource は生成元を付与したコピーを返す。
+func (r HueResult) WithSource(source HueResultSource) HueResult {
+	r.source = source
+	return r
+}
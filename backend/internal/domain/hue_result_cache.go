@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// HueResultCacheKey は同じ入力の結果を使い回すためのキャッシュのキー。
+// 語彙と色の組を整列してハッシュし、プロンプトの版と生成器 (モデル) を添える。
+type HueResultCacheKey struct {
+	hash          string
+	promptVersion string
+	generator     string
+}
+
+// NewHueResultCacheKey は record の回答から prompt と generator 向けのキーを作る。
+// prompt が参加者名を参照する版なら、名前もキーに含める。
+func NewHueResultCacheKey(record HueRecord, prompt HuePromptVersion, generator string) HueResultCacheKey {
+	choices := record.ChoiceMap()
+	words := make([]string, 0, len(choices))
+	for word := range choices {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	// 区切りには回答に現れない制御文字を使う。
+	var b strings.Builder
+	b.WriteString(prompt.Version())
+	b.WriteByte(0x1e)
+	b.WriteString(generator)
+	b.WriteByte(0x1e)
+	if prompt.UsesName() {
+		b.WriteString(record.Name().String())
+	}
+	for _, word := range words {
+		b.WriteByte(0x1e)
+		b.WriteString(word)
+		b.WriteByte(0x1f)
+		b.WriteString(choices[word])
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return HueResultCacheKey{hash: hex.EncodeToString(sum[:]), promptVersion: prompt.Version(), generator: generator}
+}
+
+// String は保存に使う 16 進のハッシュ。
+func (k HueResultCacheKey) String() string { return k.hash }
+
+func (k HueResultCacheKey) PromptVersion() string { return k.promptVersion }
+func (k HueResultCacheKey) Generator() string     { return k.generator }
+
+// HueResultCacheStats は起動してからの結果キャッシュの当たり外れ。
+type HueResultCacheStats struct {
+	MemoryHits    int64
+	StoreHits     int64
+	Misses        int64
+	Bypasses      int64
+	MemoryEntries int
+	StoreEntries  int64
+}
+
+// HitRatio は問い合わせのうちキャッシュから返せた割合。問い合わせが無ければ 0。
+func (s HueResultCacheStats) HitRatio() float64 {
+	lookups := s.MemoryHits + s.StoreHits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.MemoryHits+s.StoreHits) / float64(lookups)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "testing"
+
+func TestNewHueResultCacheKey(t *testing.T) {
+	anonymous, err := NewHuePromptVersion("v1", "結果を作ってください。", huePromptV1User)
+	if err != nil {
+		t.Fatalf("prompt error: %v", err)
+	}
+	named, err := NewHuePromptVersion("v2", "{{.Name}} さんの結果を作ってください。", huePromptV1User)
+	if err != nil {
+		t.Fatalf("prompt error: %v", err)
+	}
+	if anonymous.UsesName() || !named.UsesName() {
+		t.Fatalf("unexpected UsesName: %v %v", anonymous.UsesName(), named.UsesName())
+	}
+
+	alice := mustCacheRecord(t, "alice", map[string]string{"朝": "青", "夜": "赤"})
+	bob := mustCacheRecord(t, "bob", map[string]string{"夜": "赤", "朝": "青"})
+	other := mustCacheRecord(t, "alice", map[string]string{"朝": "青", "夜": "黒"})
+
+	key := NewHueResultCacheKey(alice, anonymous, "openai-responses:gpt-4.1")
+	if len(key.String()) != 64 || key.PromptVersion() != "v1" || key.Generator() != "openai-responses:gpt-4.1" {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+	if NewHueResultCacheKey(bob, anonymous, "openai-responses:gpt-4.1") != key {
+		t.Fatalf("expected same choices to share a key when the prompt ignores the name")
+	}
+	if NewHueResultCacheKey(other, anonymous, "openai-responses:gpt-4.1") == key {
+		t.Fatalf("expected different choices to have different keys")
+	}
+	if NewHueResultCacheKey(alice, anonymous, "openai-chat:gpt-4.1") == key {
+		t.Fatalf("expected different generators to have different keys")
+	}
+	if NewHueResultCacheKey(alice, named, "openai-responses:gpt-4.1") == NewHueResultCacheKey(bob, named, "openai-responses:gpt-4.1") {
+		t.Fatalf("expected names to be part of the key when the prompt uses them")
+	}
+}
+
+func TestHueResultCacheStats_HitRatio(t *testing.T) {
+	if ratio := (HueResultCacheStats{}).HitRatio(); ratio != 0 {
+		t.Fatalf("expected 0 without lookups, got %v", ratio)
+	}
+	stats := HueResultCacheStats{MemoryHits: 2, StoreHits: 1, Misses: 1, Bypasses: 5}
+	if ratio := stats.HitRatio(); ratio != 0.75 {
+		t.Fatalf("expected 0.75, got %v", ratio)
+	}
+}
+
+func mustCacheRecord(t *testing.T, name string, choices map[string]string) HueRecord {
+	t.Helper()
+	record, err := NewHueRecordFromRaw(name, choices)
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	return record
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultSimilarLimit は limit 省略時に返す似た参加者の人数。
+	DefaultSimilarLimit = 5
+	// MaxSimilarLimit は 1 回で返す似た参加者の人数の上限。
+	MaxSimilarLimit = 20
+)
+
+// ChoiceSimilarity は 2 つの回答で同じ語に同じ色を選んだ数と、(語, 色) の組の Jaccard 係数を返す。
+// 片方しか答えていない語は不一致として数えるので、質問票の版が違っても比べられる。
+func ChoiceSimilarity(a, b HueChoices) (int, float64) {
+	other := b.ToMap()
+	matches := 0
+	for word, color := range a.ToMap() {
+		if other[word] == color {
+			matches++
+		}
+	}
+
+	union := a.Size() + b.Size() - matches
+	if union == 0 {
+		return 0, 0
+	}
+	return matches, float64(matches) / float64(union)
+}
+
+// HueSimilarityQuery は record に似た回答を limit 人分探す条件。
+type HueSimilarityQuery struct {
+	recordID uuid.UUID
+	limit    int
+}
+
+// NewHueSimilarityQuery は limit が 0 以下なら DefaultSimilarLimit、上限超過は MaxSimilarLimit に丸める。
+func NewHueSimilarityQuery(recordID uuid.UUID, limit int) HueSimilarityQuery {
+	switch {
+	case limit <= 0:
+		limit = DefaultSimilarLimit
+	case limit > MaxSimilarLimit:
+		limit = MaxSimilarLimit
+	}
+	return HueSimilarityQuery{recordID: recordID, limit: limit}
+}
+
+func (q HueSimilarityQuery) RecordID() uuid.UUID { return q.recordID }
+func (q HueSimilarityQuery) Limit() int          { return q.limit }
+
+// HueSimilarity は基準の回答に似た、結果生成済みの参加者。
+type HueSimilarity struct {
+	record     HueRecord
+	matches    int
+	similarity float64
+}
+
+// NewHueSimilarity は target と candidate の回答を比べる。candidate に結果が無ければ ErrHueRecordNotFound。
+func NewHueSimilarity(target, candidate HueRecord) (HueSimilarity, error) {
+	if _, ok := candidate.Result(); !ok {
+		return HueSimilarity{}, ErrHueRecordNotFound
+	}
+	matches, similarity := ChoiceSimilarity(target.Choices(), candidate.Choices())
+	return HueSimilarity{record: candidate, matches: matches, similarity: similarity}, nil
+}
+
+// Record は似た参加者のレコード。結果は必ず生成済み。
+func (s HueSimilarity) Record() HueRecord { return s.record }
+
+// Matches は同じ語に同じ色を選んだ数。
+func (s HueSimilarity) Matches() int { return s.matches }
+
+// Similarity は 0 から 1 の類似度。1 なら回答がすべて同じ。
+func (s HueSimilarity) Similarity() float64 { return s.similarity }
+
+// HueSimilarityIndex は似た回答を探すための、(語, 色) の組から回答への転置索引。
+// 一致数は回答そのものから分かるので、回答の公開を許可した結果生成済みの回答だけを載せる。
+// 作ったあとは変更しないので並行に読んでよい。
+type HueSimilarityIndex struct {
+	records  []HueRecord
+	postings map[hueChoicePair][]int
+}
+
+// NewHueSimilarityIndex は records のうち、公開を許可していない回答と結果の無い回答を除いて索引を作る。
+func NewHueSimilarityIndex(records []HueRecord) HueSimilarityIndex {
+	index := HueSimilarityIndex{postings: make(map[hueChoicePair][]int)}
+	for _, record := range records {
+		if _, ok := record.Result(); !ok || !record.SharesChoices() {
+			continue
+		}
+		position := len(index.records)
+		index.records = append(index.records, record)
+		for word, color := range record.Choices().values {
+			pair := hueChoicePair{word: word, color: color}
+			index.postings[pair] = append(index.postings[pair], position)
+		}
+	}
+	return index
+}
+
+// Size は索引に載っている回答の数。
+func (i HueSimilarityIndex) Size() int {
+	return len(i.records)
+}
+
+// Find は target と 1 組以上一致する回答を、類似度の高い順 (同じなら新しい順) に limit 件返す。target 自身は除く。
+// target と同じ組を持つ回答だけを数えるので、索引全体は走査しない。
+func (i HueSimilarityIndex) Find(target HueRecord, limit int) []HueSimilarity {
+	matches := make(map[int]int)
+	for word, color := range target.Choices().values {
+		for _, position := range i.postings[hueChoicePair{word: word, color: color}] {
+			matches[position]++
+		}
+	}
+
+	similar := make([]HueSimilarity, 0, len(matches))
+	for position, count := range matches {
+		candidate := i.records[position]
+		if candidate.ID() == target.ID() {
+			continue
+		}
+		union := target.Choices().Size() + candidate.Choices().Size() - count
+		similar = append(similar, HueSimilarity{record: candidate, matches: count, similarity: float64(count) / float64(union)})
+	}
+
+	slices.SortFunc(similar, func(a, b HueSimilarity) int {
+		if c := cmp.Compare(b.similarity, a.similarity); c != 0 {
+			return c
+		}
+		if c := b.record.CreatedAt().Compare(a.record.CreatedAt()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.record.ID().String(), b.record.ID().String())
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar
+}
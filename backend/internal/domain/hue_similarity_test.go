@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChoiceSimilarity(t *testing.T) {
+	a := mustChoices(t, map[string]string{"朝": "青", "夜": "赤", "心": "青"})
+	b := mustChoices(t, map[string]string{"朝": "青", "夜": "黒", "心": "青"})
+	c := mustChoices(t, map[string]string{"朝": "青"})
+
+	if matches, similarity := ChoiceSimilarity(a, a); matches != 3 || similarity != 1 {
+		t.Fatalf("expected identical answers to match fully, got %d %v", matches, similarity)
+	}
+	// 一致 2 組、和集合は 3 + 3 - 2 = 4 組。
+	if matches, similarity := ChoiceSimilarity(a, b); matches != 2 || similarity != 0.5 {
+		t.Fatalf("unexpected similarity: %d %v", matches, similarity)
+	}
+	if matches, similarity := ChoiceSimilarity(a, c); matches != 1 || similarity != 1.0/3 {
+		t.Fatalf("expected unanswered words to count as mismatches, got %d %v", matches, similarity)
+	}
+}
+
+func TestNewHueSimilarityQuery_ClampsLimit(t *testing.T) {
+	if limit := NewHueSimilarityQuery([16]byte{1}, 0).Limit(); limit != DefaultSimilarLimit {
+		t.Fatalf("expected default limit, got %d", limit)
+	}
+	if limit := NewHueSimilarityQuery([16]byte{1}, MaxSimilarLimit+1).Limit(); limit != MaxSimilarLimit {
+		t.Fatalf("expected max limit, got %d", limit)
+	}
+}
+
+func TestNewHueSimilarity(t *testing.T) {
+	target := mustCacheRecord(t, "alice", map[string]string{"朝": "青", "夜": "赤"})
+	candidate := mustCacheRecord(t, "bob", map[string]string{"朝": "青", "夜": "黒"})
+
+	if _, err := NewHueSimilarity(target, candidate); !errors.Is(err, ErrHueRecordNotFound) {
+		t.Fatalf("expected ErrHueRecordNotFound without a result, got %v", err)
+	}
+
+	hue, err := NewHueResultFromRaw(10, 20, 30, "message")
+	if err != nil {
+		t.Fatalf("result error: %v", err)
+	}
+	result, err := NewHueRecordResult(hue.WithSource(HueResultSourceModel), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+
+	similarity, err := NewHueSimilarity(target, candidate.WithResult(result))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if similarity.Matches() != 1 || similarity.Similarity() != 1.0/3 || similarity.Record().Name().String() != "bob" {
+		t.Fatalf("unexpected similarity: %+v", similarity)
+	}
+}
+
+func TestHueSimilarityIndex_Find(t *testing.T) {
+	hue, err := NewHueResultFromRaw(10, 20, 30, "message")
+	if err != nil {
+		t.Fatalf("result error: %v", err)
+	}
+	result, err := NewHueRecordResult(hue.WithSource(HueResultSourceModel), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	shared := func(name string, choices map[string]string) HueRecord {
+		return mustCacheRecord(t, name, choices).WithResult(result).WithShareChoices(true)
+	}
+
+	target := shared("alice", map[string]string{"朝": "青", "夜": "赤", "心": "青"})
+	bob := shared("bob", map[string]string{"朝": "青", "夜": "黒"})
+	carol := shared("carol", map[string]string{"朝": "青", "夜": "赤"})
+	private := mustCacheRecord(t, "dave", map[string]string{"朝": "青", "夜": "赤", "心": "青"}).WithResult(result)
+	pending := mustCacheRecord(t, "erin", map[string]string{"朝": "青"}).WithShareChoices(true)
+	unrelated := shared("frank", map[string]string{"朝": "緑"})
+
+	index := NewHueSimilarityIndex([]HueRecord{target, bob, carol, private, pending, unrelated})
+	if index.Size() != 4 {
+		t.Fatalf("expected only shared results to be indexed, got %d", index.Size())
+	}
+
+	similar := index.Find(target, 5)
+	if len(similar) != 2 || similar[0].Record().ID() != carol.ID() || similar[1].Record().ID() != bob.ID() {
+		t.Fatalf("unexpected similar records: %+v", similar)
+	}
+	// carol は 2 組一致、和集合は 3 + 2 - 2 = 3 組。
+	if similar[0].Matches() != 2 || similar[0].Similarity() != 2.0/3 {
+		t.Fatalf("unexpected similarity: %+v", similar[0])
+	}
+	if got := index.Find(target, 1); len(got) != 1 || got[0].Record().ID() != carol.ID() {
+		t.Fatalf("expected limit to keep the most similar, got %+v", got)
+	}
+}
+
+func mustChoices(t *testing.T, raw map[string]string) HueChoices {
+	t.Helper()
+	choices, err := NewHueChoices(raw)
+	if err != nil {
+		t.Fatalf("choices error: %v", err)
+	}
+	return choices
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// HueColorShare は語彙に対してある色が選ばれた回数と割合。
+type HueColorShare struct {
+	color HueColor
+	count int
+	share float64
+}
+
+func (s HueColorShare) Color() HueColor { return s.color }
+func (s HueColorShare) Count() int      { return s.count }
+func (s HueColorShare) Share() float64  { return s.share }
+
+// HueWordStats は 1 語彙ぶんの色の分布。
+type HueWordStats struct {
+	word   HueWord
+	counts map[HueColor]int
+	total  int
+}
+
+// NewHueWordStats は 0 件以下の色を除いた分布を構築する。空の分布は ErrInvalidChoice。
+func NewHueWordStats(word string, counts map[string]int) (HueWordStats, error) {
+	w := HueWord(strings.TrimSpace(word))
+	if w == "" {
+		return HueWordStats{}, ErrInvalidChoice
+	}
+
+	values := make(map[HueColor]int, len(counts))
+	total := 0
+	for color, count := range counts {
+		if count <= 0 {
+			continue
+		}
+		values[HueColor(color)] = count
+		total += count
+	}
+	if total == 0 {
+		return HueWordStats{}, ErrInvalidChoice
+	}
+
+	return HueWordStats{word: w, counts: values, total: total}, nil
+}
+
+func (s HueWordStats) Word() HueWord {
+	return s.word
+}
+
+// Total はこの語彙に回答した人数。
+func (s HueWordStats) Total() int {
+	return s.total
+}
+
+func (s HueWordStats) Counts() map[string]int {
+	copied := make(map[string]int, len(s.counts))
+	for color, count := range s.counts {
+		copied[string(color)] = count
+	}
+	return copied
+}
+
+// MostTypical は最も多く選ばれた色。同数なら色名の辞書順で先のもの。
+func (s HueWordStats) MostTypical() HueColorShare {
+	shares := s.sortedShares()
+	return shares[0]
+}
+
+// LeastTypical は選ばれた色のうち最も少ないもの。同数なら色名の辞書順で後のもの。
+func (s HueWordStats) LeastTypical() HueColorShare {
+	shares := s.sortedShares()
+	return shares[len(shares)-1]
+}
+
+func (s HueWordStats) sortedShares() []HueColorShare {
+	shares := make([]HueColorShare, 0, len(s.counts))
+	for color, count := range s.counts {
+		shares = append(shares, HueColorShare{color: color, count: count, share: float64(count) / float64(s.total)})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].count != shares[j].count {
+			return shares[i].count > shares[j].count
+		}
+		return shares[i].color < shares[j].color
+	})
+	return shares
+}
+
+// HueStats は期間内の回答全体の集計。
+type HueStats struct {
+	window       TimeWindow
+	participants int
+	words        []HueWordStats
+}
+
+// NewHueStats は語彙順に並べた集計を構築する。
+func NewHueStats(window TimeWindow, participants int, words []HueWordStats) (HueStats, error) {
+	if participants < 0 {
+		return HueStats{}, ErrInvalidRange
+	}
+
+	sorted := append([]HueWordStats(nil), words...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].word < sorted[j].word })
+
+	return HueStats{window: window, participants: participants, words: sorted}, nil
+}
+
+func (s HueStats) Window() TimeWindow {
+	return s.window
+}
+
+// Participants は期間内のレコード数。
+func (s HueStats) Participants() int {
+	return s.participants
+}
+
+func (s HueStats) Words() []HueWordStats {
+	return append([]HueWordStats(nil), s.words...)
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewHueWordStats(t *testing.T) {
+	stats, err := NewHueWordStats(" 夜 ", map[string]int{"黒": 6, "青": 3, "紫": 1, "白": 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.Word() != "夜" || stats.Total() != 10 {
+		t.Fatalf("unexpected stats: %s %d", stats.Word(), stats.Total())
+	}
+	if _, ok := stats.Counts()["白"]; ok {
+		t.Fatalf("expected zero counts to be dropped")
+	}
+
+	most := stats.MostTypical()
+	if most.Color() != "黒" || most.Count() != 6 || most.Share() != 0.6 {
+		t.Fatalf("unexpected most typical: %+v", most)
+	}
+	least := stats.LeastTypical()
+	if least.Color() != "紫" || least.Count() != 1 || least.Share() != 0.1 {
+		t.Fatalf("unexpected least typical: %+v", least)
+	}
+}
+
+func TestNewHueWordStats_Invalid(t *testing.T) {
+	if _, err := NewHueWordStats(" ", map[string]int{"黒": 1}); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice for empty word, got %v", err)
+	}
+	if _, err := NewHueWordStats("夜", map[string]int{"黒": 0}); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice for empty counts, got %v", err)
+	}
+}
+
+func TestNewHueStats_SortsWords(t *testing.T) {
+	b, _ := NewHueWordStats("b", map[string]int{"黒": 1})
+	a, _ := NewHueWordStats("a", map[string]int{"黒": 1})
+
+	stats, err := NewHueStats(TimeWindow{}, 1, []HueWordStats{b, a})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	words := stats.Words()
+	if len(words) != 2 || words[0].Word() != "a" || words[1].Word() != "b" {
+		t.Fatalf("expected words sorted, got %+v", words)
+	}
+}
+
+func TestNewTimeWindow(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	window, err := NewTimeWindow(from, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, ok := window.From(); !ok || !got.Equal(from) {
+		t.Fatalf("unexpected from: %v", got)
+	}
+	if _, ok := window.To(); ok {
+		t.Fatalf("expected open upper bound")
+	}
+
+	if _, err := NewTimeWindow(to, from); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("expected ErrInvalidRange, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// MaxIdempotencyKeyLength は Idempotency-Key の最大長。
+	MaxIdempotencyKeyLength = 255
+	// IdempotencyKeyTTL は同じキーの再送を同じ送信として扱う期間。過ぎたキーは使い直せる。
+	IdempotencyKeyTTL = 24 * time.Hour
+)
+
+// IdempotencyKey はクライアントが送信ごとに付けるキーと、その送信内容のハッシュ。
+// ゼロ値はキーなし (毎回新しい送信として扱う) を表す。
+type IdempotencyKey struct {
+	key         string
+	requestHash string
+}
+
+// NewIdempotencyKey は key が空、長すぎる、または表示可能な ASCII 以外を含む場合 ErrInvalidIdempotencyKey を返す。
+// body は送信の本文で、そのハッシュで同じキーの使い回しを見分ける。
+func NewIdempotencyKey(key string, body []byte) (IdempotencyKey, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return IdempotencyKey{}, ErrInvalidIdempotencyKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return IdempotencyKey{}, ErrInvalidIdempotencyKey
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	return IdempotencyKey{key: key, requestHash: hex.EncodeToString(sum[:])}, nil
+}
+
+func (k IdempotencyKey) Key() string         { return k.key }
+func (k IdempotencyKey) RequestHash() string { return k.requestHash }
+
+// IsZero はキーが付いていないかを返す。
+func (k IdempotencyKey) IsZero() bool { return k.key == "" }
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	key, err := NewIdempotencyKey("0b6f1c2e-retry", []byte(`{"name":"a"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.Key() != "0b6f1c2e-retry" || len(key.RequestHash()) != 64 || key.IsZero() {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+
+	same, _ := NewIdempotencyKey("other", []byte(`{"name":"a"}`))
+	changed, _ := NewIdempotencyKey("0b6f1c2e-retry", []byte(`{"name":"b"}`))
+	if same.RequestHash() != key.RequestHash() || changed.RequestHash() == key.RequestHash() {
+		t.Fatalf("expected hash to depend only on the body")
+	}
+
+	for _, invalid := range []string{"", "has space", "日本語", strings.Repeat("a", MaxIdempotencyKeyLength+1)} {
+		if _, err := NewIdempotencyKey(invalid, nil); !errors.Is(err, ErrInvalidIdempotencyKey) {
+			t.Fatalf("expected ErrInvalidIdempotencyKey for %q, got %v", invalid, err)
+		}
+	}
+	if !(IdempotencyKey{}).IsZero() {
+		t.Fatalf("expected zero value to have no key")
+	}
+}
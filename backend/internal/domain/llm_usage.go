@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// LLMUsage は 1 回のベンダー呼び出しで消費したトークン数。
+type LLMUsage struct {
+	model        string
+	inputTokens  int
+	outputTokens int
+}
+
+// NewLLMUsage はモデル名が空、またはトークン数が負なら ErrInvalidUsage を返す。
+func NewLLMUsage(model string, inputTokens, outputTokens int) (LLMUsage, error) {
+	model = strings.TrimSpace(model)
+	if model == "" || inputTokens < 0 || outputTokens < 0 {
+		return LLMUsage{}, ErrInvalidUsage
+	}
+	return LLMUsage{model: model, inputTokens: inputTokens, outputTokens: outputTokens}, nil
+}
+
+func (u LLMUsage) Model() string     { return u.model }
+func (u LLMUsage) InputTokens() int  { return u.inputTokens }
+func (u LLMUsage) OutputTokens() int { return u.outputTokens }
+
+// LLMPrice は 100 万トークンあたりの米ドル単価。
+type LLMPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// LLMPriceTable はモデル名から単価を引く表。
+type LLMPriceTable struct {
+	prices map[string]LLMPrice
+}
+
+// NewLLMPriceTable はモデル名が空、または単価が負なら ErrInvalidUsage を返す。
+func NewLLMPriceTable(prices map[string]LLMPrice) (LLMPriceTable, error) {
+	copied := make(map[string]LLMPrice, len(prices))
+	for model, price := range prices {
+		model = strings.TrimSpace(model)
+		if model == "" || price.InputPerMillion < 0 || price.OutputPerMillion < 0 {
+			return LLMPriceTable{}, ErrInvalidUsage
+		}
+		copied[model] = price
+	}
+	return LLMPriceTable{prices: copied}, nil
+}
+
+// Price は model の単価を返す。完全一致が無ければ "<表の名前>-" で始まる最長の名前を使う
+// (ベンダーは "gpt-4.1-2025-04-14" のように日付付きで返すため)。
+func (t LLMPriceTable) Price(model string) (LLMPrice, bool) {
+	if price, ok := t.prices[model]; ok {
+		return price, true
+	}
+
+	var (
+		best  string
+		found bool
+	)
+	for name := range t.prices {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best, found = name, true
+		}
+	}
+	return t.prices[best], found
+}
+
+// Cost はトークン数から推定費用 (米ドル) を計算する。単価が無いモデルは 0 と false。
+func (t LLMPriceTable) Cost(model string, inputTokens, outputTokens int64) (float64, bool) {
+	price, ok := t.Price(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(inputTokens)*price.InputPerMillion + float64(outputTokens)*price.OutputPerMillion) / 1_000_000, true
+}
+
+// LLMUsageTotal は 1 日 (UTC)・1 モデルぶんの使用量の合計。
+type LLMUsageTotal struct {
+	day          time.Time
+	model        string
+	requests     int64
+	inputTokens  int64
+	outputTokens int64
+}
+
+// NewLLMUsageTotal は day を UTC の 0 時へ切り詰める。件数が負なら ErrInvalidUsage。
+func NewLLMUsageTotal(day time.Time, model string, requests, inputTokens, outputTokens int64) (LLMUsageTotal, error) {
+	if requests < 0 || inputTokens < 0 || outputTokens < 0 {
+		return LLMUsageTotal{}, ErrInvalidUsage
+	}
+	return LLMUsageTotal{
+		day:          truncateDay(day),
+		model:        model,
+		requests:     requests,
+		inputTokens:  inputTokens,
+		outputTokens: outputTokens,
+	}, nil
+}
+
+func (t LLMUsageTotal) Day() time.Time      { return t.day }
+func (t LLMUsageTotal) Model() string       { return t.model }
+func (t LLMUsageTotal) Requests() int64     { return t.requests }
+func (t LLMUsageTotal) InputTokens() int64  { return t.inputTokens }
+func (t LLMUsageTotal) OutputTokens() int64 { return t.outputTokens }
+
+// TotalCost は totals の推定費用の合計。単価が無いモデルは数えない。
+func (t LLMPriceTable) TotalCost(totals []LLMUsageTotal) float64 {
+	sum := 0.0
+	for _, total := range totals {
+		cost, _ := t.Cost(total.model, total.inputTokens, total.outputTokens)
+		sum += cost
+	}
+	return sum
+}
+
+// HueUsageModel は 1 日・1 モデルぶんの使用量と推定費用。
+type HueUsageModel struct {
+	total  LLMUsageTotal
+	cost   float64
+	priced bool
+}
+
+func (m HueUsageModel) Total() LLMUsageTotal { return m.total }
+
+// Cost は推定費用 (米ドル)。単価表に無いモデルは 0 と false。
+func (m HueUsageModel) Cost() (float64, bool) { return m.cost, m.priced }
+
+// HueUsageDay は 1 日 (UTC) ぶんの使用量と推定費用。
+type HueUsageDay struct {
+	day          time.Time
+	models       []HueUsageModel
+	requests     int64
+	inputTokens  int64
+	outputTokens int64
+	cost         float64
+}
+
+func (d HueUsageDay) Day() time.Time      { return d.day }
+func (d HueUsageDay) Requests() int64     { return d.requests }
+func (d HueUsageDay) InputTokens() int64  { return d.inputTokens }
+func (d HueUsageDay) OutputTokens() int64 { return d.outputTokens }
+func (d HueUsageDay) Cost() float64       { return d.cost }
+
+func (d HueUsageDay) Models() []HueUsageModel {
+	return append([]HueUsageModel(nil), d.models...)
+}
+
+// HueUsageReport は期間内の日別の使用量と推定費用、1 日の予算をまとめる。
+type HueUsageReport struct {
+	window      TimeWindow
+	days        []HueUsageDay
+	dailyBudget float64
+}
+
+// NewHueUsageReport は totals を日付の昇順、日の中ではモデル名順にまとめる。dailyBudget が 0 以下なら予算なし。
+func NewHueUsageReport(window TimeWindow, totals []LLMUsageTotal, prices LLMPriceTable, dailyBudget float64) HueUsageReport {
+	sorted := append([]LLMUsageTotal(nil), totals...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].day.Equal(sorted[j].day) {
+			return sorted[i].day.Before(sorted[j].day)
+		}
+		return sorted[i].model < sorted[j].model
+	})
+
+	var days []HueUsageDay
+	for _, total := range sorted {
+		if len(days) == 0 || !days[len(days)-1].day.Equal(total.day) {
+			days = append(days, HueUsageDay{day: total.day})
+		}
+		day := &days[len(days)-1]
+
+		cost, priced := prices.Cost(total.model, total.inputTokens, total.outputTokens)
+		day.models = append(day.models, HueUsageModel{total: total, cost: cost, priced: priced})
+		day.requests += total.requests
+		day.inputTokens += total.inputTokens
+		day.outputTokens += total.outputTokens
+		day.cost += cost
+	}
+
+	if dailyBudget < 0 {
+		dailyBudget = 0
+	}
+	return HueUsageReport{window: window, days: days, dailyBudget: dailyBudget}
+}
+
+func (r HueUsageReport) Window() TimeWindow { return r.window }
+
+func (r HueUsageReport) Days() []HueUsageDay {
+	return append([]HueUsageDay(nil), r.days...)
+}
+
+// TotalCost は期間全体の推定費用。
+func (r HueUsageReport) TotalCost() float64 {
+	sum := 0.0
+	for _, day := range r.days {
+		sum += day.cost
+	}
+	return sum
+}
+
+// DailyBudget は 1 日の予算 (米ドル)。予算が無ければ false。
+func (r HueUsageReport) DailyBudget() (float64, bool) {
+	return r.dailyBudget, r.dailyBudget > 0
+}
+
+// UsageDayWindow は now を含む UTC の 1 日を返す。予算の判定に使う。
+func UsageDayWindow(now time.Time) TimeWindow {
+	from := truncateDay(now)
+	return TimeWindow{from: from, to: from.AddDate(0, 0, 1)}
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewLLMUsage_Invalid(t *testing.T) {
+	if _, err := NewLLMUsage(" ", 1, 1); !errors.Is(err, ErrInvalidUsage) {
+		t.Fatalf("expected ErrInvalidUsage for empty model, got %v", err)
+	}
+	if _, err := NewLLMUsage("gpt-4.1", -1, 1); !errors.Is(err, ErrInvalidUsage) {
+		t.Fatalf("expected ErrInvalidUsage for negative tokens, got %v", err)
+	}
+}
+
+func TestLLMPriceTable_Cost(t *testing.T) {
+	table, err := NewLLMPriceTable(map[string]LLMPrice{
+		"gpt-4.1":      {InputPerMillion: 2, OutputPerMillion: 8},
+		"gpt-4.1-mini": {InputPerMillion: 0.4, OutputPerMillion: 1.6},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		model  string
+		want   float64
+		priced bool
+	}{
+		{model: "gpt-4.1", want: 2 + 4, priced: true},
+		{model: "gpt-4.1-2025-04-14", want: 2 + 4, priced: true},
+		{model: "gpt-4.1-mini-2025-04-14", want: 0.4 + 0.8, priced: true},
+		{model: "gpt-4.10", priced: false},
+		{model: "other", priced: false},
+	}
+	for _, tc := range cases {
+		cost, ok := table.Cost(tc.model, 1_000_000, 500_000)
+		if ok != tc.priced || math.Abs(cost-tc.want) > 1e-9 {
+			t.Fatalf("%s: expected (%v, %v), got (%v, %v)", tc.model, tc.want, tc.priced, cost, ok)
+		}
+	}
+
+	if _, err := NewLLMPriceTable(map[string]LLMPrice{"gpt-4.1": {InputPerMillion: -1}}); !errors.Is(err, ErrInvalidUsage) {
+		t.Fatalf("expected ErrInvalidUsage for negative price, got %v", err)
+	}
+}
+
+func TestNewHueUsageReport(t *testing.T) {
+	table, err := NewLLMPriceTable(map[string]LLMPrice{"gpt-4.1": {InputPerMillion: 2, OutputPerMillion: 8}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	day1 := time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	totals := []LLMUsageTotal{
+		mustUsageTotal(t, day2, "gpt-4.1", 1, 1_000_000, 0),
+		mustUsageTotal(t, day1, "unknown", 2, 100, 10),
+		mustUsageTotal(t, day1, "gpt-4.1", 3, 0, 1_000_000),
+	}
+
+	report := NewHueUsageReport(TimeWindow{}, totals, table, 5)
+
+	days := report.Days()
+	if len(days) != 2 || !days[0].Day().Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected days: %+v", days)
+	}
+	if days[0].Requests() != 5 || days[0].InputTokens() != 100 || days[0].OutputTokens() != 1_000_010 || days[0].Cost() != 8 {
+		t.Fatalf("unexpected first day: %+v", days[0])
+	}
+	models := days[0].Models()
+	if len(models) != 2 || models[0].Total().Model() != "gpt-4.1" {
+		t.Fatalf("expected models sorted by name, got %+v", models)
+	}
+	if _, ok := models[1].Cost(); ok {
+		t.Fatalf("expected unknown model to be unpriced")
+	}
+	if report.TotalCost() != 10 {
+		t.Fatalf("expected total cost 10, got %v", report.TotalCost())
+	}
+	if budget, ok := report.DailyBudget(); !ok || budget != 5 {
+		t.Fatalf("expected budget 5, got %v %v", budget, ok)
+	}
+	if table.TotalCost(totals) != 10 {
+		t.Fatalf("expected table total 10, got %v", table.TotalCost(totals))
+	}
+}
+
+func TestUsageDayWindow(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	window := UsageDayWindow(time.Date(2025, 3, 2, 3, 0, 0, 0, jst))
+
+	from, _ := window.From()
+	to, _ := window.To()
+	if !from.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected window: %v - %v", from, to)
+	}
+}
+
+func mustUsageTotal(t *testing.T, day time.Time, model string, requests, input, output int64) LLMUsageTotal {
+	t.Helper()
+	total, err := NewLLMUsageTotal(day, model, requests, input, output)
+	if err != nil {
+		t.Fatalf("usage total error: %v", err)
+	}
+	return total
+}
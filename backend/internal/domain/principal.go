@@ -0,0 +1,49 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Principal は認証済みのリクエストの主体。認証ミドルウェアがセッションを 1 度だけ照合して作り、
+// サービスは生の SessionData の代わりにこれを受け取って認可する。
+type Principal struct {
+	userID    uuid.UUID
+	role      UserRole
+	sessionID uuid.UUID
+}
+
+// NewPrincipal は照合できたセッションのユーザー、ロール、セッション ID から主体を作る。
+func NewPrincipal(userID uuid.UUID, role UserRole, sessionID uuid.UUID) (Principal, error) {
+	if userID == uuid.Nil || sessionID == uuid.Nil || !role.valid() {
+		return Principal{}, ErrInvalidPrincipal
+	}
+	return Principal{userID: userID, role: role, sessionID: sessionID}, nil
+}
+
+func (p Principal) UserID() uuid.UUID {
+	return p.userID
+}
+
+func (p Principal) Role() UserRole {
+	return p.role
+}
+
+// SessionID は認証に使った login_sessions の行の ID。
+func (p Principal) SessionID() uuid.UUID {
+	return p.sessionID
+}
+
+// IsZero は認証されていない (ゼロ値の) 主体かを返す。
+func (p Principal) IsZero() bool {
+	return p.userID == uuid.Nil
+}
+
+// Require は主体が role を持っていれば nil を返す。
+// ゼロ値なら ErrInvalidLoginSession、ロールが違えば ErrInsufficientRole。
+func (p Principal) Require(role UserRole) error {
+	if p.IsZero() {
+		return ErrInvalidLoginSession
+	}
+	if p.role != role {
+		return ErrInsufficientRole
+	}
+	return nil
+}
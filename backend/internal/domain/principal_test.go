@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewPrincipal(t *testing.T) {
+	userID, sessionID := uuid.New(), uuid.New()
+	principal, err := NewPrincipal(userID, UserRoleAdmin, sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if principal.UserID() != userID || principal.SessionID() != sessionID || principal.Role() != UserRoleAdmin {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+
+	if _, err := NewPrincipal(uuid.Nil, UserRoleUser, sessionID); !errors.Is(err, ErrInvalidPrincipal) {
+		t.Fatalf("expected ErrInvalidPrincipal for nil user, got %v", err)
+	}
+	if _, err := NewPrincipal(userID, UserRoleUser, uuid.Nil); !errors.Is(err, ErrInvalidPrincipal) {
+		t.Fatalf("expected ErrInvalidPrincipal for nil session, got %v", err)
+	}
+	if _, err := NewPrincipal(userID, UserRole("root"), sessionID); !errors.Is(err, ErrInvalidPrincipal) {
+		t.Fatalf("expected ErrInvalidPrincipal for unknown role, got %v", err)
+	}
+}
+
+func TestPrincipal_Require(t *testing.T) {
+	admin, err := NewPrincipal(uuid.New(), UserRoleAdmin, uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	user, err := NewPrincipal(uuid.New(), UserRoleUser, uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := admin.Require(UserRoleAdmin); err != nil {
+		t.Fatalf("expected admin to pass, got %v", err)
+	}
+	if err := user.Require(UserRoleAdmin); !errors.Is(err, ErrInsufficientRole) {
+		t.Fatalf("expected ErrInsufficientRole, got %v", err)
+	}
+	if err := (Principal{}).Require(UserRoleUser); !errors.Is(err, ErrInvalidLoginSession) {
+		t.Fatalf("expected ErrInvalidLoginSession for zero principal, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package domain
+
+import "strings"
+
+// ActiveQuestionnaireVersion は新しい回答に使う質問票の版。
+const ActiveQuestionnaireVersion = "v1"
+
+// hueColorOrder は画面に並べるパレットの順番。
+var hueColorOrder = []HueColor{"黒", "灰色", "白", "ピンク", "赤", "オレンジ", "黄色", "緑", "青", "紫", "茶"}
+
+// Questionnaire は版ごとの語彙リストと、回答に使うパレット。
+type Questionnaire struct {
+	version string
+	words   []HueWord
+	wordSet map[HueWord]struct{}
+}
+
+// NewQuestionnaire は空や重複した語彙を拒否し、並び順を保ったまま質問票を作る。
+func NewQuestionnaire(version string, words []string) (Questionnaire, error) {
+	v := strings.TrimSpace(version)
+	if v == "" || len(words) == 0 {
+		return Questionnaire{}, ErrInvalidQuestionnaire
+	}
+
+	list := make([]HueWord, 0, len(words))
+	set := make(map[HueWord]struct{}, len(words))
+	for _, word := range words {
+		w := HueWord(strings.TrimSpace(word))
+		if w == "" {
+			return Questionnaire{}, ErrInvalidQuestionnaire
+		}
+		if _, dup := set[w]; dup {
+			return Questionnaire{}, ErrInvalidQuestionnaire
+		}
+		set[w] = struct{}{}
+		list = append(list, w)
+	}
+
+	return Questionnaire{version: v, words: list, wordSet: set}, nil
+}
+
+func (q Questionnaire) Version() string {
+	return q.version
+}
+
+// Words は出題順の語彙を返す。
+func (q Questionnaire) Words() []HueWord {
+	return append([]HueWord(nil), q.words...)
+}
+
+// Allows は語彙がこの版の質問票に含まれるかを返す。
+func (q Questionnaire) Allows(word HueWord) bool {
+	_, ok := q.wordSet[word]
+	return ok
+}
+
+// Palette は回答に使える色を表示順に返す。パレットは全版で共通。
+func (q Questionnaire) Palette() []HueColor {
+	return append([]HueColor(nil), hueColorOrder...)
+}
+
+var questionnaires = map[string]Questionnaire{
+	"v1": mustQuestionnaire("v1", questionnaireV1Words),
+}
+
+// FindQuestionnaire は版を指定して質問票を返す。空なら現行の版。
+func FindQuestionnaire(version string) (Questionnaire, error) {
+	v := strings.TrimSpace(version)
+	if v == "" {
+		v = ActiveQuestionnaireVersion
+	}
+
+	q, ok := questionnaires[v]
+	if !ok {
+		return Questionnaire{}, ErrUnknownQuestionnaire
+	}
+	return q, nil
+}
+
+// ActiveQuestionnaire は現行の質問票を返す。
+func ActiveQuestionnaire() Questionnaire {
+	return questionnaires[ActiveQuestionnaireVersion]
+}
+
+func mustQuestionnaire(version string, words []string) Questionnaire {
+	q, err := NewQuestionnaire(version, words)
+	if err != nil {
+		panic("domain: invalid questionnaire " + version)
+	}
+	return q
+}
+
+// questionnaireV1Words は frontend の words.ts と同じ出題リスト (重複していた「情緒」は 1 つにまとめた)。
+var questionnaireV1Words = []string{
+	"夜", "詐欺", "毒", "男性", "平和", "児童", "心", "母", "宗教", "孤独",
+	"未来", "良心", "熱情", "情緒", "気質", "活動", "反抗", "力", "緊張", "愛情",
+	"勝利", "自発性", "恥", "野望", "嫉妬", "戯れ", "笑い", "お祭り", "快楽", "朝",
+	"喜び", "独創", "成功", "調和", "利益", "娘", "家庭", "満足", "幸福", "女性",
+	"嫌悪", "冗談", "苦痛", "野心", "協力", "自然", "善", "慈善", "教育", "親切",
+	"息子", "信任", "献身", "科学", "涙", "理論", "理想", "不幸", "病気", "夕暮",
+	"拘束", "憐み", "霊魂", "仕事", "機械仕掛け", "父", "依存", "老人", "労働",
+	"苦難", "退屈", "過去", "悲しみ", "敗北", "責任", "自分個人の", "盗み", "逆境（不幸）", "殺人",
+	"性欲", "怨恨", "裸体", "祝祭", "女友達", "男友達", "自然さ", "従順", "有用", "兄弟",
+	"確信", "若者", "心配", "職業", "機械", "苦悩", "損害", "赤ん坊", "単純さ", "自由",
+	"結婚", "都会", "優雅",
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestActiveQuestionnaire(t *testing.T) {
+	q := ActiveQuestionnaire()
+	if q.Version() != ActiveQuestionnaireVersion {
+		t.Fatalf("unexpected version: %s", q.Version())
+	}
+	if len(q.Words()) != 102 || !q.Allows("夜") || q.Allows("海") {
+		t.Fatalf("unexpected word list: %d words", len(q.Words()))
+	}
+
+	palette := q.Palette()
+	if len(palette) != len(allowedHueColors) {
+		t.Fatalf("palette order and allowed colors diverged: %d vs %d", len(palette), len(allowedHueColors))
+	}
+	for _, color := range palette {
+		if !color.valid() {
+			t.Fatalf("palette contains unknown color %s", color)
+		}
+	}
+}
+
+func TestNewQuestionnaire_Invalid(t *testing.T) {
+	if _, err := NewQuestionnaire("v9", []string{"夜", " 夜 "}); !errors.Is(err, ErrInvalidQuestionnaire) {
+		t.Fatalf("expected ErrInvalidQuestionnaire for duplicates, got %v", err)
+	}
+	if _, err := NewQuestionnaire(" ", []string{"夜"}); !errors.Is(err, ErrInvalidQuestionnaire) {
+		t.Fatalf("expected ErrInvalidQuestionnaire for empty version, got %v", err)
+	}
+	if _, err := FindQuestionnaire("v0"); !errors.Is(err, ErrUnknownQuestionnaire) {
+		t.Fatalf("expected ErrUnknownQuestionnaire, got %v", err)
+	}
+}
+
+func TestNewHueChoices_ValidatesAgainstQuestionnaire(t *testing.T) {
+	choices, err := NewHueChoices(map[string]string{" 夜 ": "黒"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if choices.QuestionnaireVersion() != ActiveQuestionnaireVersion {
+		t.Fatalf("expected active version, got %q", choices.QuestionnaireVersion())
+	}
+
+	if _, err := NewHueChoices(map[string]string{"海": "青"}); !errors.Is(err, ErrUnknownWord) {
+		t.Fatalf("expected ErrUnknownWord, got %v", err)
+	}
+
+	// 質問票の導入前の回答も読み戻せる。
+	legacy, err := NewHueChoicesFromPersistence(map[string]string{"海": "青"}, "")
+	if err != nil || legacy.QuestionnaireVersion() != "" {
+		t.Fatalf("expected legacy choices to load, got %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // RecordRange はレコード取得の閉区間 [begin, end] を保持する。
 type RecordRange struct {
 	begin int
@@ -29,3 +31,28 @@ func (r RecordRange) End() int {
 func (r RecordRange) Count() int {
 	return r.end - r.begin + 1
 }
+
+// TimeWindow は created_at の半開区間 [from, to) を保持する。ゼロ値の端は無制限を表す。
+type TimeWindow struct {
+	from time.Time
+	to   time.Time
+}
+
+// NewTimeWindow は両端が指定されていて from>=to の場合 ErrInvalidRange を返す。
+func NewTimeWindow(from, to time.Time) (TimeWindow, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return TimeWindow{}, ErrInvalidRange
+	}
+
+	return TimeWindow{from: from.UTC(), to: to.UTC()}, nil
+}
+
+// From は下端を返す。無制限なら false。
+func (w TimeWindow) From() (time.Time, bool) {
+	return w.from, !w.from.IsZero()
+}
+
+// To は上端を返す。無制限なら false。
+func (w TimeWindow) To() (time.Time, bool) {
+	return w.to, !w.to.IsZero()
+}
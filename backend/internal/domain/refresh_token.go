@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRefreshTokenTTL はログインから refresh token の系列が使える期間。回転しても延びない。
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// RefreshToken はセッションを作り直すための長寿命のトークン。値はセッショントークンと同じ
+// "<selector>.<verifier>" 形式で、照合値だけを保存する。
+// 使うたびに同じ系列 (family) の次のトークンへ回転し、使用済みのトークンが再び使われたら
+// 盗まれたとみなして系列ごと失効させる。
+type RefreshToken struct {
+	id        uuid.UUID
+	familyID  uuid.UUID
+	userID    uuid.UUID
+	sessionID uuid.UUID
+	token     HashedLoginSessionToken
+	expiresAt time.Time
+	createdAt time.Time
+	usedAt    time.Time
+	revokedAt time.Time
+}
+
+// NewRefreshToken はログイン時に新しい系列の最初のトークンを作る。sessionID は同時に発行したセッション。
+func NewRefreshToken(userID, sessionID uuid.UUID, token HashedLoginSessionToken, issuedAt time.Time) (RefreshToken, error) {
+	issued := issuedAt.UTC()
+	if issued.IsZero() {
+		return RefreshToken{}, ErrInvalidRefreshToken
+	}
+	return buildRefreshToken(uuid.New(), uuid.New(), userID, sessionID, token, issued, issued.Add(DefaultRefreshTokenTTL))
+}
+
+// NewRefreshTokenFromPersistence は保存済みの行から復元する。sessionID はセッションが消えていれば uuid.Nil。
+func NewRefreshTokenFromPersistence(id, familyID, userID, sessionID uuid.UUID, token HashedLoginSessionToken, expiresAt, createdAt time.Time) (RefreshToken, error) {
+	return buildRefreshToken(id, familyID, userID, sessionID, token, createdAt.UTC(), expiresAt.UTC())
+}
+
+func buildRefreshToken(id, familyID, userID, sessionID uuid.UUID, token HashedLoginSessionToken, createdAt, expiresAt time.Time) (RefreshToken, error) {
+	if id == uuid.Nil || familyID == uuid.Nil || userID == uuid.Nil || token.Selector() == "" {
+		return RefreshToken{}, ErrInvalidRefreshToken
+	}
+	if createdAt.IsZero() || !expiresAt.After(createdAt) {
+		return RefreshToken{}, ErrInvalidRefreshToken
+	}
+	return RefreshToken{
+		id:        id,
+		familyID:  familyID,
+		userID:    userID,
+		sessionID: sessionID,
+		token:     token,
+		expiresAt: expiresAt,
+		createdAt: createdAt,
+	}, nil
+}
+
+// Rotate は同じ系列の次のトークンを作る。期限は系列の最初のトークンから引き継ぐ。
+func (t RefreshToken) Rotate(sessionID uuid.UUID, token HashedLoginSessionToken, at time.Time) (RefreshToken, error) {
+	if t.IsExpired(at) {
+		return RefreshToken{}, ErrExpiredToken
+	}
+	return buildRefreshToken(uuid.New(), t.familyID, t.userID, sessionID, token, at.UTC(), t.expiresAt)
+}
+
+func (t RefreshToken) ID() uuid.UUID        { return t.id }
+func (t RefreshToken) FamilyID() uuid.UUID  { return t.familyID }
+func (t RefreshToken) UserID() uuid.UUID    { return t.userID }
+func (t RefreshToken) Selector() string     { return t.token.Selector() }
+func (t RefreshToken) HashedToken() string  { return t.token.String() }
+func (t RefreshToken) ExpiresAt() time.Time { return t.expiresAt }
+func (t RefreshToken) CreatedAt() time.Time { return t.createdAt }
+
+// SessionID は一緒に発行したセッション。セッションが消えていれば false。
+func (t RefreshToken) SessionID() (uuid.UUID, bool) {
+	return t.sessionID, t.sessionID != uuid.Nil
+}
+
+// WithUsedAt は次のトークンへ回転した時刻を付ける。
+func (t RefreshToken) WithUsedAt(at time.Time) RefreshToken {
+	t.usedAt = at.UTC()
+	return t
+}
+
+// UsedAt は回転した時刻。まだ使われていなければ false。
+func (t RefreshToken) UsedAt() (time.Time, bool) {
+	return t.usedAt, !t.usedAt.IsZero()
+}
+
+// WithRevokedAt は系列ごと失効させた時刻を付ける。
+func (t RefreshToken) WithRevokedAt(at time.Time) RefreshToken {
+	t.revokedAt = at.UTC()
+	return t
+}
+
+// RevokedAt は失効した時刻。失効していなければ false。
+func (t RefreshToken) RevokedAt() (time.Time, bool) {
+	return t.revokedAt, !t.revokedAt.IsZero()
+}
+
+func (t RefreshToken) IsExpired(at time.Time) bool {
+	return !at.UTC().Before(t.expiresAt)
+}
+
+// Check は token で at の時点に回転してよいかを確かめる。
+// 照合できなければ ErrInvalidRefreshToken、使用済みなら ErrRefreshTokenReused、期限切れなら ErrExpiredToken。
+// 照合を最初に行い、selector だけを知る第三者が系列を失効させられないようにする。
+func (t RefreshToken) Check(token LoginSessionToken, pepper SessionTokenPepper, at time.Time) error {
+	if err := t.token.Verify(token, pepper); err != nil {
+		return ErrInvalidRefreshToken
+	}
+	if _, revoked := t.RevokedAt(); revoked {
+		return ErrInvalidRefreshToken
+	}
+	if _, used := t.UsedAt(); used {
+		return ErrRefreshTokenReused
+	}
+	if t.IsExpired(at) {
+		return ErrExpiredToken
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRefreshToken_RotateKeepsFamilyAndExpiry(t *testing.T) {
+	pepper := testSessionPepper(t, "p")
+	first, hashed := newTestRefreshSecret(t, pepper)
+	issued := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	token, err := NewRefreshToken(uuid.New(), uuid.New(), hashed, issued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := token.Check(first, pepper, issued.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected check error: %v", err)
+	}
+
+	_, nextHashed := newTestRefreshSecret(t, pepper)
+	session := uuid.New()
+	next, err := token.Rotate(session, nextHashed, issued.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected rotate error: %v", err)
+	}
+	if next.ID() == token.ID() || next.FamilyID() != token.FamilyID() || next.UserID() != token.UserID() {
+		t.Fatalf("expected a new token in the same family")
+	}
+	if !next.ExpiresAt().Equal(issued.Add(DefaultRefreshTokenTTL)) {
+		t.Fatalf("expected expiry to be inherited, got %v", next.ExpiresAt())
+	}
+	if id, ok := next.SessionID(); !ok || id != session {
+		t.Fatalf("unexpected session id: %v", id)
+	}
+
+	if _, err := token.Rotate(session, nextHashed, token.ExpiresAt()); !errors.Is(err, ErrExpiredToken) {
+		t.Fatalf("expected ErrExpiredToken when rotating an expired family, got %v", err)
+	}
+}
+
+func TestRefreshToken_Check(t *testing.T) {
+	pepper := testSessionPepper(t, "p")
+	secret, hashed := newTestRefreshSecret(t, pepper)
+	issued := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	token, err := NewRefreshToken(uuid.New(), uuid.New(), hashed, issued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other, _ := newTestRefreshSecret(t, pepper)
+	at := issued.Add(time.Hour)
+
+	cases := []struct {
+		name  string
+		token RefreshToken
+		input LoginSessionToken
+		at    time.Time
+		want  error
+	}{
+		{name: "wrong secret", token: token, input: other, at: at, want: ErrInvalidRefreshToken},
+		{name: "wrong secret on used token", token: token.WithUsedAt(at), input: other, at: at, want: ErrInvalidRefreshToken},
+		{name: "revoked", token: token.WithUsedAt(at).WithRevokedAt(at), input: secret, at: at, want: ErrInvalidRefreshToken},
+		{name: "reused", token: token.WithUsedAt(at), input: secret, at: at, want: ErrRefreshTokenReused},
+		{name: "expired", token: token, input: secret, at: token.ExpiresAt(), want: ErrExpiredToken},
+	}
+	for _, tc := range cases {
+		if err := tc.token.Check(tc.input, pepper, tc.at); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestNewRefreshToken_RequiresSelector(t *testing.T) {
+	legacy, err := ParseHashedLoginSessionToken("", "bcrypt-hash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewRefreshToken(uuid.New(), uuid.New(), legacy, time.Now()); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func newTestRefreshSecret(t *testing.T, pepper SessionTokenPepper) (LoginSessionToken, HashedLoginSessionToken) {
+	t.Helper()
+	token, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	hashed, err := token.Hash(pepper)
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+	return token, hashed
+}
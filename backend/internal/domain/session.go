@@ -1,9 +1,13 @@
 package domain
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"golang.org/x/crypto/bcrypt"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -11,81 +15,204 @@ import (
 )
 
 // DefaultLoginSessionTTL は login_sessions.expires_at のデフォルト(30分)に合わせる。
+// 使われるたびに、その時点からこの長さだけ延長する。
 const DefaultLoginSessionTTL = 30 * time.Minute
 
-const loginSessionTokenByteLength = 32
+// MaxLoginSessionLifetime は使い続けても延長できない、発行からのセッションの寿命。
+const MaxLoginSessionLifetime = 12 * time.Hour
 
+const (
+	loginSessionTokenByteLength = 32
+	// loginSessionSelectorByteLength はセッションを 1 行で引くための selector の長さ。
+	loginSessionSelectorByteLength = 16
+	loginSessionTokenSeparator     = "."
+	// MinSessionTokenPepperLength は pepper に求める最低のバイト数。
+	MinSessionTokenPepperLength = 32
+)
+
+// SessionTokenPepper はセッショントークンの HMAC に使うサーバー側の秘密。DB には保存しない。
+type SessionTokenPepper struct {
+	key []byte
+}
+
+// NewSessionTokenPepper は MinSessionTokenPepperLength バイトに満たない鍵を ErrInvalidSessionPepper で拒否する。
+func NewSessionTokenPepper(key []byte) (SessionTokenPepper, error) {
+	if len(key) < MinSessionTokenPepperLength {
+		return SessionTokenPepper{}, ErrInvalidSessionPepper
+	}
+	return SessionTokenPepper{key: append([]byte(nil), key...)}, nil
+}
+
+func (p SessionTokenPepper) digest(token LoginSessionToken) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(token.value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LoginSessionToken はクライアントに渡すセッショントークン。
+// "<selector>.<verifier>" の形で、selector でセッションを 1 行引き、verifier で照合する。
+// selector を持たない旧形式のトークンは bcrypt で照合する。
 type LoginSessionToken struct {
-	value string
+	value    string
+	selector string
 }
 
+// HashedLoginSessionToken は DB に保存するトークンの照合値。
+// selector があれば pepper 付きの HMAC-SHA256、無ければ旧形式の bcrypt ハッシュ。
 type HashedLoginSessionToken struct {
-	value string
+	selector string
+	value    string
 }
 
 func NewLoginSessionToken() (LoginSessionToken, error) {
-	tokenBytes := make([]byte, loginSessionTokenByteLength)
-	_, err := rand.Read(tokenBytes)
+	selector, err := randomToken(loginSessionSelectorByteLength)
 	if err != nil {
 		return LoginSessionToken{}, err
 	}
-	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	verifier, err := randomToken(loginSessionTokenByteLength)
+	if err != nil {
+		return LoginSessionToken{}, err
+	}
+
+	return LoginSessionToken{value: selector + loginSessionTokenSeparator + verifier, selector: selector}, nil
+}
 
-	return LoginSessionToken{value: token}, nil
+func randomToken(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
+// ParseLoginSessionToken は "<selector>.<verifier>" と旧形式のトークンを受け付ける。
 func ParseLoginSessionToken(value string) (LoginSessionToken, error) {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
 		return LoginSessionToken{}, ErrInvalidSessionToken
 	}
 
-	decoded, err := base64.RawURLEncoding.DecodeString(trimmed)
-	if err != nil || len(decoded) != loginSessionTokenByteLength {
+	selector, verifier, ok := strings.Cut(trimmed, loginSessionTokenSeparator)
+	if !ok {
+		// 旧形式: verifier だけ。
+		if !validTokenPart(trimmed, loginSessionTokenByteLength) {
+			return LoginSessionToken{}, ErrInvalidSessionToken
+		}
+		return LoginSessionToken{value: trimmed}, nil
+	}
+
+	if !validTokenPart(selector, loginSessionSelectorByteLength) || !validTokenPart(verifier, loginSessionTokenByteLength) {
 		return LoginSessionToken{}, ErrInvalidSessionToken
 	}
+	return LoginSessionToken{value: trimmed, selector: selector}, nil
+}
 
-	return LoginSessionToken{value: trimmed}, nil
+func validTokenPart(value string, length int) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	return err == nil && len(decoded) == length
 }
 
 func (t LoginSessionToken) String() string {
 	return t.value
 }
 
+// Selector はセッションを引くための selector を返す。旧形式のトークンなら false。
+func (t LoginSessionToken) Selector() (string, bool) {
+	return t.selector, t.selector != ""
+}
+
 func (t LoginSessionToken) isZero() bool {
 	return t.value == ""
 }
 
-func (t LoginSessionToken) Hash() (HashedLoginSessionToken, error) {
-	result := HashedLoginSessionToken{}
-
-	hashed, err := bcrypt.GenerateFromPassword([]byte(t.value), bcrypt.DefaultCost)
-	if err != nil {
-		return HashedLoginSessionToken{}, err
+// Hash は pepper で HMAC した照合値を返す。旧形式のトークンは新しく保存しないので ErrInvalidSessionToken。
+func (t LoginSessionToken) Hash(pepper SessionTokenPepper) (HashedLoginSessionToken, error) {
+	if t.selector == "" {
+		return HashedLoginSessionToken{}, ErrInvalidSessionToken
 	}
-	result.value = string(hashed)
-	return result, nil
+	if len(pepper.key) == 0 {
+		return HashedLoginSessionToken{}, ErrInvalidSessionPepper
+	}
+	return HashedLoginSessionToken{selector: t.selector, value: pepper.digest(t)}, nil
 }
 
-func ParseHashedLoginSessionToken(value string) (HashedLoginSessionToken, error) {
+// ParseHashedLoginSessionToken は保存済みの照合値を復元する。selector が空なら旧形式の bcrypt ハッシュとして扱う。
+func ParseHashedLoginSessionToken(selector, value string) (HashedLoginSessionToken, error) {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
 		return HashedLoginSessionToken{}, ErrInvalidSessionToken
 	}
-	return HashedLoginSessionToken{value: trimmed}, nil
+	return HashedLoginSessionToken{selector: strings.TrimSpace(selector), value: trimmed}, nil
 }
 
 func (h HashedLoginSessionToken) String() string {
 	return h.value
 }
 
+// Selector はセッションを引くための selector。旧形式なら空文字。
+func (h HashedLoginSessionToken) Selector() string {
+	return h.selector
+}
+
+// Verify は入力トークンが照合値と一致するかを確かめる。HMAC は定数時間で比べる。
+func (h HashedLoginSessionToken) Verify(token LoginSessionToken, pepper SessionTokenPepper) error {
+	if h.selector == "" {
+		if token.selector != "" {
+			return ErrInvalidSessionToken
+		}
+		return bcrypt.CompareHashAndPassword([]byte(h.value), []byte(token.value))
+	}
+
+	if token.selector != h.selector || len(pepper.key) == 0 {
+		return ErrInvalidSessionToken
+	}
+	if !hmac.Equal([]byte(pepper.digest(token)), []byte(h.value)) {
+		return ErrInvalidSessionToken
+	}
+	return nil
+}
+
+const (
+	// SessionTouchInterval より短い間隔では last_used_at を書き換えない。
+	SessionTouchInterval = time.Minute
+	// maxSessionUserAgentLength を超える User-Agent は切り詰めて保存する。
+	maxSessionUserAgentLength = 512
+)
+
+// SessionClient はセッションを発行した端末の User-Agent と IP アドレス。
+// セッション一覧で利用者が自分の端末を見分けるためだけに使う。
+type SessionClient struct {
+	userAgent string
+	ipAddress string
+}
+
+// NewSessionClient は User-Agent を切り詰め、IP アドレスとして読めない値は空にする。
+func NewSessionClient(userAgent, ipAddress string) SessionClient {
+	agent := strings.TrimSpace(userAgent)
+	if len(agent) > maxSessionUserAgentLength {
+		agent = strings.ToValidUTF8(agent[:maxSessionUserAgentLength], "")
+	}
+
+	var ip string
+	if addr, err := netip.ParseAddr(strings.TrimSpace(ipAddress)); err == nil {
+		ip = addr.Unmap().String()
+	}
+
+	return SessionClient{userAgent: agent, ipAddress: ip}
+}
+
+func (c SessionClient) UserAgent() string { return c.userAgent }
+func (c SessionClient) IPAddress() string { return c.ipAddress }
+
 // LoginSession はログイン済みユーザーのセッション状態を表す。
 type LoginSession struct {
-	id        uuid.UUID
-	userID    uuid.UUID
-	token     HashedLoginSessionToken
-	expiresAt time.Time
-	createdAt time.Time
+	id         uuid.UUID
+	userID     uuid.UUID
+	token      HashedLoginSessionToken
+	client     SessionClient
+	expiresAt  time.Time
+	createdAt  time.Time
+	lastUsedAt time.Time
 }
 
 // NewLoginSession はセッションを発行時間を基準に構築する。
@@ -119,9 +246,14 @@ func (s LoginSession) HashedToken() string {
 	return s.token.String()
 }
 
-// Verify は保存済みハッシュと入力トークンを照合する。
-func (s LoginSession) Verify(token LoginSessionToken) error {
-	return bcrypt.CompareHashAndPassword([]byte(s.token.value), []byte(token.value))
+// Selector はセッションを引くための selector。旧形式のセッションなら空文字。
+func (s LoginSession) Selector() string {
+	return s.token.selector
+}
+
+// Verify は保存済みの照合値と入力トークンを照合する。
+func (s LoginSession) Verify(token LoginSessionToken, pepper SessionTokenPepper) error {
+	return s.token.Verify(token, pepper)
 }
 
 func (s LoginSession) CreatedAt() time.Time {
@@ -132,6 +264,54 @@ func (s LoginSession) ExpiresAt() time.Time {
 	return s.expiresAt
 }
 
+// WithClient はセッションを発行した端末の情報を付ける。
+func (s LoginSession) WithClient(client SessionClient) LoginSession {
+	s.client = client
+	return s
+}
+
+func (s LoginSession) Client() SessionClient {
+	return s.client
+}
+
+// WithLastUsedAt は最後に認証に使われた時刻を付ける。
+func (s LoginSession) WithLastUsedAt(at time.Time) LoginSession {
+	s.lastUsedAt = at.UTC()
+	return s
+}
+
+// LastUsedAt は最後に認証に使われた時刻。発行後に一度も使われていなければ false。
+func (s LoginSession) LastUsedAt() (time.Time, bool) {
+	return s.lastUsedAt, !s.lastUsedAt.IsZero()
+}
+
+// NeedsTouch は at の時点で last_used_at を書き換えるべきかを返す。
+// 毎リクエストの書き込みを避けるため SessionTouchInterval ごとに間引く。
+// 旧形式のセッションは発行時の期限で失効させるので、書き換えない。
+func (s LoginSession) NeedsTouch(at time.Time) bool {
+	if s.token.selector == "" {
+		return false
+	}
+	return s.lastUsedAt.IsZero() || at.UTC().Sub(s.lastUsedAt) >= SessionTouchInterval
+}
+
+// Extend は at から DefaultLoginSessionTTL だけ有効期限を延ばす。
+// 発行から MaxLoginSessionLifetime を超えては延ばさず、期限を縮めることもしない。
+// 旧形式のセッションは延ばさない。
+func (s LoginSession) Extend(at time.Time) LoginSession {
+	if s.token.selector == "" {
+		return s
+	}
+	expires := at.UTC().Add(DefaultLoginSessionTTL)
+	if limit := s.createdAt.Add(MaxLoginSessionLifetime); expires.After(limit) {
+		expires = limit
+	}
+	if expires.After(s.expiresAt) {
+		s.expiresAt = expires
+	}
+	return s
+}
+
 // IsExpired は参照時刻が有効期限に到達したかどうかを返す。
 func (s LoginSession) IsExpired(at time.Time) bool {
 	return !at.UTC().Before(s.expiresAt)
@@ -159,8 +339,9 @@ func buildLoginSession(id uuid.UUID, userID uuid.UUID, token HashedLoginSessionT
 
 // SessionData は API へ返却する session-data-struct を表現する。
 type SessionData struct {
-	userID uuid.UUID
-	token  LoginSessionToken
+	userID       uuid.UUID
+	token        LoginSessionToken
+	refreshToken LoginSessionToken
 }
 
 func NewSessionData(userID uuid.UUID, token LoginSessionToken) (SessionData, error) {
@@ -178,3 +359,14 @@ func (s SessionData) UserID() uuid.UUID {
 func (s SessionData) Token() LoginSessionToken {
 	return s.token
 }
+
+// WithRefreshToken はログインや更新で発行した refresh token を付ける。
+func (s SessionData) WithRefreshToken(token LoginSessionToken) SessionData {
+	s.refreshToken = token
+	return s
+}
+
+// RefreshToken は発行した refresh token。リクエストから読んだ SessionData では false。
+func (s SessionData) RefreshToken() (LoginSessionToken, bool) {
+	return s.refreshToken, !s.refreshToken.isZero()
+}
@@ -1,13 +1,27 @@
 package domain
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func testSessionPepper(t *testing.T, seed string) SessionTokenPepper {
+	t.Helper()
+	pepper, err := NewSessionTokenPepper([]byte(strings.Repeat(seed, MinSessionTokenPepperLength)))
+	if err != nil {
+		t.Fatalf("pepper error: %v", err)
+	}
+	return pepper
+}
+
 func TestParseLoginSessionToken(t *testing.T) {
 	generated, err := NewLoginSessionToken()
 	if err != nil {
@@ -23,23 +37,122 @@ func TestParseLoginSessionToken(t *testing.T) {
 		t.Fatalf("expected %s got %s", generated.String(), parsed.String())
 	}
 
-	if _, err := ParseLoginSessionToken("invalid-base64"); !errors.Is(err, ErrInvalidSessionToken) {
-		t.Fatalf("expected ErrInvalidSessionToken, got %v", err)
+	if selector, ok := parsed.Selector(); !ok || !strings.HasPrefix(generated.String(), selector+".") {
+		t.Fatalf("expected selector prefix, got %q", selector)
+	}
+
+	for _, invalid := range []string{"invalid-base64", "short.token", generated.String() + ".extra", "." + strings.SplitN(generated.String(), ".", 2)[1]} {
+		if _, err := ParseLoginSessionToken(invalid); !errors.Is(err, ErrInvalidSessionToken) {
+			t.Fatalf("%q: expected ErrInvalidSessionToken, got %v", invalid, err)
+		}
+	}
+}
+
+func TestParseLoginSessionToken_Legacy(t *testing.T) {
+	raw := make([]byte, loginSessionTokenByteLength)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatalf("rand error: %v", err)
+	}
+	legacy := base64.RawURLEncoding.EncodeToString(raw)
+
+	parsed, err := ParseLoginSessionToken(legacy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := parsed.Selector(); ok {
+		t.Fatalf("legacy token should not have a selector")
+	}
+	if _, err := parsed.Hash(testSessionPepper(t, "p")); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Fatalf("expected legacy tokens not to be hashed again, got %v", err)
+	}
+
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte(legacy), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt error: %v", err)
+	}
+	hashed, err := ParseHashedLoginSessionToken("", string(bcrypted))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hashed.Verify(parsed, SessionTokenPepper{}); err != nil {
+		t.Fatalf("legacy session should verify with bcrypt: %v", err)
+	}
+
+	current, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	if err := hashed.Verify(current, testSessionPepper(t, "p")); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Fatalf("expected new-format token to be rejected by a legacy session, got %v", err)
+	}
+}
+
+func TestNewSessionTokenPepper(t *testing.T) {
+	if _, err := NewSessionTokenPepper([]byte("too-short")); !errors.Is(err, ErrInvalidSessionPepper) {
+		t.Fatalf("expected ErrInvalidSessionPepper, got %v", err)
+	}
+
+	token, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	if _, err := token.Hash(SessionTokenPepper{}); !errors.Is(err, ErrInvalidSessionPepper) {
+		t.Fatalf("expected ErrInvalidSessionPepper for zero pepper, got %v", err)
+	}
+}
+
+func TestHashedLoginSessionToken_Verify(t *testing.T) {
+	pepper := testSessionPepper(t, "p")
+	token, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	hashed, err := token.Hash(pepper)
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+
+	selector, _ := token.Selector()
+	if hashed.Selector() != selector || len(hashed.String()) != 64 || strings.Contains(hashed.String(), token.String()) {
+		t.Fatalf("unexpected hashed token: selector=%q value=%q", hashed.Selector(), hashed.String())
+	}
+	if err := hashed.Verify(token, pepper); err != nil {
+		t.Fatalf("unexpected verify error: %v", err)
+	}
+
+	if err := hashed.Verify(token, testSessionPepper(t, "q")); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Fatalf("expected other pepper to fail, got %v", err)
+	}
+
+	other, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	otherVerifier := strings.SplitN(other.String(), ".", 2)[1]
+	forged, err := ParseLoginSessionToken(selector + "." + otherVerifier)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if err := hashed.Verify(forged, pepper); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Fatalf("expected forged verifier to fail, got %v", err)
+	}
+	if err := hashed.Verify(other, pepper); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Fatalf("expected other selector to fail, got %v", err)
 	}
 }
 
 func TestParseHashedLoginSessionToken(t *testing.T) {
-	if _, err := ParseHashedLoginSessionToken("  "); !errors.Is(err, ErrInvalidSessionToken) {
+	if _, err := ParseHashedLoginSessionToken("", "  "); !errors.Is(err, ErrInvalidSessionToken) {
 		t.Fatalf("expected ErrInvalidSessionToken, got %v", err)
 	}
 
-	hashed, err := ParseHashedLoginSessionToken("  hashed-value  ")
+	hashed, err := ParseHashedLoginSessionToken(" selector ", "  hashed-value  ")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if hashed.String() != "hashed-value" {
-		t.Fatalf("expected trimmed hash, got %s", hashed.String())
+	if hashed.String() != "hashed-value" || hashed.Selector() != "selector" {
+		t.Fatalf("expected trimmed hash, got %s (%s)", hashed.String(), hashed.Selector())
 	}
 }
 
@@ -49,7 +162,7 @@ func TestNewLoginSession(t *testing.T) {
 	if err != nil {
 		t.Fatalf("token error: %v", err)
 	}
-	hashed, err := token.Hash()
+	hashed, err := token.Hash(testSessionPepper(t, "p"))
 	if err != nil {
 		t.Fatalf("hash error: %v", err)
 	}
@@ -68,7 +181,7 @@ func TestNewLoginSession(t *testing.T) {
 		t.Fatalf("unexpected user id: %v", session.UserID())
 	}
 
-	if err := session.Verify(token); err != nil {
+	if err := session.Verify(token, testSessionPepper(t, "p")); err != nil {
 		t.Fatalf("unexpected hashed token: %s", err)
 	}
 
@@ -99,7 +212,7 @@ func TestNewLoginSession_InvalidInput(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		hashed, err := tc.token.Hash()
+		hashed, err := tc.token.Hash(testSessionPepper(t, "p"))
 		if err != nil {
 			t.Fatalf("hash error: %v", err)
 		}
@@ -114,7 +227,7 @@ func TestNewLoginSessionFromPersistence_InvalidTimes(t *testing.T) {
 	if err != nil {
 		t.Fatalf("token error: %v", err)
 	}
-	hashed, err := token.Hash()
+	hashed, err := token.Hash(testSessionPepper(t, "p"))
 	if err != nil {
 		t.Fatalf("hash error: %v", err)
 	}
@@ -134,7 +247,7 @@ func TestLoginSession_IsExpired(t *testing.T) {
 	if err != nil {
 		t.Fatalf("token error: %v", err)
 	}
-	hashed, err := token.Hash()
+	hashed, err := token.Hash(testSessionPepper(t, "p"))
 	if err != nil {
 		t.Fatalf("hash error: %v", err)
 	}
@@ -183,3 +296,97 @@ func TestNewSessionData(t *testing.T) {
 		t.Fatalf("expected ErrInvalidSessionData, got %v", err)
 	}
 }
+
+func TestNewSessionClient(t *testing.T) {
+	client := NewSessionClient("  Mozilla/5.0  ", "::ffff:192.0.2.10")
+	if client.UserAgent() != "Mozilla/5.0" || client.IPAddress() != "192.0.2.10" {
+		t.Fatalf("unexpected client: %q %q", client.UserAgent(), client.IPAddress())
+	}
+
+	long := NewSessionClient(strings.Repeat("あ", maxSessionUserAgentLength), "not-an-ip")
+	if len(long.UserAgent()) > maxSessionUserAgentLength || !utf8.ValidString(long.UserAgent()) {
+		t.Fatalf("expected truncated valid user agent, got %d bytes", len(long.UserAgent()))
+	}
+	if long.IPAddress() != "" {
+		t.Fatalf("expected invalid ip to be dropped, got %q", long.IPAddress())
+	}
+}
+
+func TestLoginSession_NeedsTouch(t *testing.T) {
+	token, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	hashed, err := token.Hash(testSessionPepper(t, "p"))
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+	issued := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	session, err := NewLoginSession(uuid.New(), hashed, issued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := session.LastUsedAt(); ok || !session.NeedsTouch(issued) {
+		t.Fatalf("new session should need a touch")
+	}
+
+	session = session.WithLastUsedAt(issued)
+	if session.NeedsTouch(issued.Add(SessionTouchInterval - time.Second)) {
+		t.Fatalf("touch should be throttled within the interval")
+	}
+	if !session.NeedsTouch(issued.Add(SessionTouchInterval)) {
+		t.Fatalf("touch should be needed after the interval")
+	}
+}
+
+func TestLoginSession_Extend(t *testing.T) {
+	token, err := NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	hashed, err := token.Hash(testSessionPepper(t, "p"))
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+	issued := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	session, err := NewLoginSession(uuid.New(), hashed, issued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	used := issued.Add(20 * time.Minute)
+	if got := session.Extend(used).ExpiresAt(); !got.Equal(used.Add(DefaultLoginSessionTTL)) {
+		t.Fatalf("expected sliding expiry %v, got %v", used.Add(DefaultLoginSessionTTL), got)
+	}
+
+	if got := session.Extend(issued.Add(-time.Hour)).ExpiresAt(); !got.Equal(session.ExpiresAt()) {
+		t.Fatalf("expiry should never shrink, got %v", got)
+	}
+
+	late := issued.Add(MaxLoginSessionLifetime - 10*time.Minute)
+	if got := session.Extend(late).ExpiresAt(); !got.Equal(issued.Add(MaxLoginSessionLifetime)) {
+		t.Fatalf("expected expiry capped at %v, got %v", issued.Add(MaxLoginSessionLifetime), got)
+	}
+}
+
+func TestLoginSession_LegacyIsNotExtended(t *testing.T) {
+	hashed, err := ParseHashedLoginSessionToken("", "$2a$10$legacyhashedvalue")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	issued := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := issued.Add(DefaultLoginSessionTTL)
+	session, err := NewLoginSessionFromPersistence(uuid.New(), uuid.New(), hashed, expires, issued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	used := issued.Add(20 * time.Minute)
+	if session.NeedsTouch(used) {
+		t.Fatalf("legacy session should not be touched")
+	}
+	if got := session.Extend(used).ExpiresAt(); !got.Equal(expires) {
+		t.Fatalf("legacy session should keep its expiry %v, got %v", expires, got)
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"backend/internal/domain"
+)
+
+// Authenticator はセッションを照合して認証済みの主体を返すユースケース境界。
+type Authenticator interface {
+	Authenticate(ctx context.Context, session domain.SessionData) (domain.Principal, error)
+}
+
+type principalKey struct{}
+
+// principalResolver はリクエストのセッションを初めて求められたときに 1 度だけ照合し、結果を覚えておく。
+type principalResolver struct {
+	authenticator Authenticator
+	once          sync.Once
+	principal     domain.Principal
+	err           error
+}
+
+// WithAuthentication はリクエストの context に主体の解決を仕込む。WithSessionCredentials の内側に置く。
+// セッションは RequireAuthentication, RequireRole かハンドラが requestPrincipal で求めたときに照合するので、
+// 認証しない API では DB を引かない。
+func WithAuthentication(next http.Handler, authenticator Authenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolver := &principalResolver{authenticator: authenticator}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, resolver)))
+	})
+}
+
+// RequireAuthentication はセッションが有効なリクエストだけを next に渡す。ロールは問わない。
+func RequireAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := requestPrincipal(r); err != nil {
+			respondAuthError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole は role を持つ主体のリクエストだけを next に渡すミドルウェアを返す。
+// セッションが無効なら 401、ロールが違えば 403。
+func RequireRole(role domain.UserRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := requestPrincipal(r)
+			if err == nil {
+				err = principal.Require(role)
+			}
+			if err != nil {
+				respondAuthError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestPrincipal は Authorization ヘッダかセッションクッキーのセッションを照合した主体を返す。
+// 照合は 1 リクエストにつき 1 度だけ。WithAuthentication を通っていなければ ErrInvalidLoginSession。
+func requestPrincipal(r *http.Request) (domain.Principal, error) {
+	resolver, ok := r.Context().Value(principalKey{}).(*principalResolver)
+	if !ok {
+		return domain.Principal{}, domain.ErrInvalidLoginSession
+	}
+	resolver.once.Do(func() {
+		session, err := requestSession(r)
+		if err != nil {
+			resolver.err = err
+			return
+		}
+		resolver.principal, resolver.err = resolver.authenticator.Authenticate(r.Context(), session)
+	})
+	return resolver.principal, resolver.err
+}
+
+// authenticateSession は本文で送られたセッションを照合する。本文に session を持つ古い API のためのもの。
+func authenticateSession(r *http.Request, session domain.SessionData) (domain.Principal, error) {
+	resolver, ok := r.Context().Value(principalKey{}).(*principalResolver)
+	if !ok {
+		return domain.Principal{}, domain.ErrInvalidLoginSession
+	}
+	return resolver.authenticator.Authenticate(r.Context(), session)
+}
+
+// respondAuthError は認証・認可の失敗を返す。CSRF とロール不足は 403、無効なセッションは 401。
+func respondAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCSRFToken):
+		respondCSRFRejected(w)
+	case errors.Is(err, domain.ErrInsufficientRole):
+		respondForbidden(w)
+	case invalidCredential(err):
+		respondUnauthorizedSession(w)
+	default:
+		respondInternalServerError(w)
+	}
+}
+
+// invalidCredential は err が壊れた・失効した・期限切れのセッションによるものかを返す。
+func invalidCredential(err error) bool {
+	return errors.Is(err, domain.ErrInvalidSessionData) ||
+		errors.Is(err, domain.ErrInvalidSessionToken) ||
+		errors.Is(err, domain.ErrInvalidLoginSession) ||
+		errors.Is(err, domain.ErrExpiredToken)
+}
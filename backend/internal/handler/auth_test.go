@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+func TestRequireRole(t *testing.T) {
+	cases := []struct {
+		name   string
+		auth   bool
+		role   domain.UserRole
+		err    error
+		status int
+	}{
+		{name: "admin", auth: true, role: domain.UserRoleAdmin, status: http.StatusOK},
+		{name: "user", auth: true, role: domain.UserRoleUser, status: http.StatusForbidden},
+		{name: "no session", status: http.StatusUnauthorized},
+		{name: "expired", auth: true, err: domain.ErrExpiredToken, status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+			authenticator := &fakeAuthenticator{role: tc.role, err: tc.err}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/stats", nil)
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			}
+			res := httptest.NewRecorder()
+
+			WithAuthentication(RequireRole(domain.UserRoleAdmin)(next), authenticator).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+			if called != (tc.status == http.StatusOK) {
+				t.Fatalf("unexpected call to next: %v", called)
+			}
+		})
+	}
+}
+
+func TestRequestPrincipal_ResolvesOnce(t *testing.T) {
+	session := buildSessionData(t)
+	authenticator := &fakeAuthenticator{}
+	var principal domain.Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if principal, err = requestPrincipal(r); err != nil {
+			respondAuthError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(session).BearerCredential())
+	res := httptest.NewRecorder()
+
+	WithAuthentication(RequireAuthentication(next), authenticator).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if authenticator.calls != 1 {
+		t.Fatalf("expected the session to be authenticated once, got %d", authenticator.calls)
+	}
+	if principal.UserID() != session.UserID() || principal.Role() != domain.UserRoleAdmin {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+}
+
+func TestRequestPrincipal_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+
+	if _, err := requestPrincipal(req); err != domain.ErrInvalidLoginSession {
+		t.Fatalf("expected ErrInvalidLoginSession, got %v", err)
+	}
+}
+
+// withTestAuth はセッションをそのまま管理者の主体として通す認証ミドルウェアで包む。
+func withTestAuth(next http.Handler) http.Handler {
+	return WithAuthentication(next, &fakeAuthenticator{})
+}
+
+// fakeAuthenticator は送られたセッションのユーザーを role (既定は管理者) の主体にする。
+// sessionID が空なら毎回新しい ID を振る。
+type fakeAuthenticator struct {
+	role      domain.UserRole
+	sessionID uuid.UUID
+	err       error
+	calls     int
+}
+
+func (f *fakeAuthenticator) Authenticate(_ context.Context, session domain.SessionData) (domain.Principal, error) {
+	f.calls++
+	if f.err != nil {
+		return domain.Principal{}, f.err
+	}
+	role := f.role
+	if role == "" {
+		role = domain.UserRoleAdmin
+	}
+	sessionID := f.sessionID
+	if sessionID == uuid.Nil {
+		sessionID = uuid.New()
+	}
+	return domain.NewPrincipal(session.UserID(), role, sessionID)
+}
@@ -15,7 +15,10 @@ const (
 	causeMethodNotAllowed  = "method_not_allowed"
 	causeInvalidCredential = "invalid_credential"
 	causeUnauthorized      = "unauthorized"
+	causeForbidden         = "forbidden"
 	causeDuplicate         = "duplicate"
+	causeConflict          = "conflict"
+	causeNotFound          = "not_found"
 	causeInternalError     = "internal_error"
 )
 
@@ -36,6 +39,15 @@ func respondDuplicateField(w http.ResponseWriter, field string) {
 	respondAPIError(w, http.StatusConflict, causeDuplicate, field, fmt.Sprintf("%s already exists", field))
 }
 
+// respondIdempotencyConflict は同じ Idempotency-Key で違う内容が送られたときの 409。
+func respondIdempotencyConflict(w http.ResponseWriter) {
+	respondAPIError(w, http.StatusConflict, causeConflict, "Idempotency-Key", "Idempotency-Key was already used with a different request")
+}
+
+func respondNotFound(w http.ResponseWriter, field string) {
+	respondAPIError(w, http.StatusNotFound, causeNotFound, field, fmt.Sprintf("%s not found", field))
+}
+
 func respondInvalidCredential(w http.ResponseWriter, status int) {
 	respondAPIError(w, status, causeInvalidCredential, "credential", "credential mismatch")
 }
@@ -44,6 +56,16 @@ func respondUnauthorizedSession(w http.ResponseWriter) {
 	respondAPIError(w, http.StatusUnauthorized, causeUnauthorized, "session", "invalid or expired session")
 }
 
+// respondForbidden はセッションは有効だがロールが足りないときの 403。
+func respondForbidden(w http.ResponseWriter) {
+	respondAPIError(w, http.StatusForbidden, causeForbidden, "role", "insufficient role")
+}
+
+// respondCSRFRejected はクッキーで認証したリクエストの X-CSRF-Token が一致しないときの 403。
+func respondCSRFRejected(w http.ResponseWriter) {
+	respondAPIError(w, http.StatusForbidden, causeForbidden, CSRFHeader, "missing or mismatched csrf token")
+}
+
 func respondInternalServerError(w http.ResponseWriter) {
 	respondAPIError(w, http.StatusInternalServerError, causeInternalError, "server", "internal server error")
 }
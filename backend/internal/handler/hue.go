@@ -1,26 +1,71 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"backend/internal/domain"
 	"backend/pkg/api"
+
+	"github.com/google/uuid"
 )
 
 // HueSaveService は Hue 結果保存のユースケース境界。
 type HueSaveService interface {
-	SaveResult(ctx context.Context, record domain.HueRecord) (domain.HueResult, error)
+	// key がゼロ値でなければ、同じキーの再送には最初に受け付けたジョブを返す。
+	SaveResult(ctx context.Context, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error)
+	// SaveUserResult はログイン中のユーザーの回答として保存する。
+	SaveUserResult(ctx context.Context, principal domain.Principal, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error)
+	// SaveResultWithoutCache は結果キャッシュを使わずに生成させる。管理者のみ。
+	SaveResultWithoutCache(ctx context.Context, principal domain.Principal, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error)
+}
+
+// HueResultService は共有用の結果ページ取得のユースケース境界。
+type HueResultService interface {
+	GetResult(ctx context.Context, id uuid.UUID) (domain.HueRecord, error)
+}
+
+// HueResultStatusService は結果生成の状態確認のユースケース境界。
+type HueResultStatusService interface {
+	GetStatus(ctx context.Context, id uuid.UUID) (domain.HueRecord, domain.HueResultStatus, error)
+}
+
+// HueClusterLookup は回答に最も近いクラスタを引く。クラスタがまだ無ければ false。
+type HueClusterLookup interface {
+	ClusterOf(ctx context.Context, record domain.HueRecord) (domain.HueCluster, bool)
+}
+
+// HueSimilarService は回答が似た参加者を探すユースケース境界。
+type HueSimilarService interface {
+	GetSimilar(ctx context.Context, query domain.HueSimilarityQuery) ([]domain.HueSimilarity, error)
 }
 
 // HueGetService は Hue データ取得のユースケース境界。
 type HueGetService interface {
-	GetData(ctx context.Context, session domain.SessionData, recordRange domain.RecordRange) ([]domain.HueRecord, error)
+	GetData(ctx context.Context, principal domain.Principal, query domain.HueRecordQuery) (domain.HueRecordPage, error)
 }
 
+// maxSaveResultBodyBytes は save-result の本文の上限。
+const maxSaveResultBodyBytes = 64 << 10
+
+const (
+	// HueCacheHeader に HueCacheBypass を載せると、管理者は結果キャッシュを使わずに生成させられる。
+	HueCacheHeader = "X-Hue-Cache"
+	HueCacheBypass = "bypass"
+)
+
+// HueSaveHandler は POST /api/hue-are-you/save-result を処理する。
+// 管理者は "X-Hue-Cache: bypass" とセッションを付けると、結果キャッシュを使わずに生成させられる。
+// 管理者以外が付けたときは無視して、普段どおり保存する。
+// "Idempotency-Key" を付けた再送には、同じ本文なら最初の応答を返し、違う本文なら 409 を返す。
+// 本文の session か Bearer のセッションがあれば、回答をそのユーザーに紐付ける。無効なセッションは匿名として扱う。
 type HueSaveHandler struct {
 	service HueSaveService
 }
@@ -35,8 +80,25 @@ func (h *HueSaveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 冪等キーの照合に本文のハッシュを使うので、先にすべて読む。
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSaveResultBodyBytes))
+	if err != nil {
+		log.Print("error: ", err)
+		respondInvalidJSON(w)
+		return
+	}
+
+	var key domain.IdempotencyKey
+	if raw := r.Header.Get("Idempotency-Key"); raw != "" {
+		key, err = domain.NewIdempotencyKey(raw, body)
+		if err != nil {
+			respondInvalidField(w, "Idempotency-Key")
+			return
+		}
+	}
+
 	var req api.SaveResultRequest
-	decoder := json.NewDecoder(r.Body)
+	decoder := json.NewDecoder(bytes.NewReader(body))
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&req); err != nil {
 		log.Print("error: ", err)
@@ -47,19 +109,90 @@ func (h *HueSaveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	submission, err := req.ToDomain()
 	if err != nil {
 		log.Print("error: ", err)
-		respondInvalidField(w, "record")
+		switch {
+		case errors.Is(err, domain.ErrUnknownQuestionnaire):
+			respondInvalidField(w, "questionnaire_version")
+		case errors.Is(err, domain.ErrUnknownWord):
+			respondInvalidField(w, "choice")
+		default:
+			respondInvalidField(w, "record")
+		}
 		return
 	}
 
-	result, err := h.service.SaveResult(r.Context(), submission)
+	principal, hasSession, err := saveResultPrincipal(r, req)
 	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	var job domain.HueGenerationJob
+	switch {
+	case hasSession && bypassesCache(r, principal):
+		job, err = h.service.SaveResultWithoutCache(r.Context(), principal, submission, key)
+	case hasSession:
+		job, err = h.service.SaveUserResult(r.Context(), principal, submission, key)
+	default:
+		job, err = h.service.SaveResult(r.Context(), submission, key)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrIdempotencyKeyConflict) {
+			respondIdempotencyConflict(w)
+			return
+		}
 		handleHueServiceError(w, err)
 		return
 	}
 
+	// 結果はワーカーが非同期に作るので、受け付けたことと問い合わせ先だけを返す。
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(w).Encode(api.NewSaveResultResponse(result))
+	w.Header().Set("Location", "/api/hue-are-you/results/"+job.RecordID().String())
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(api.NewSaveResultResponse(job))
+}
+
+// saveResultPrincipal は本文の session、無ければ Authorization ヘッダかセッションクッキーのセッションを照合する。
+// どれも無いときや、古いクッキーなどで照合できないときは匿名の回答として false を返す。
+// CSRF ヘッダの不一致は、クッキーのセッションが有効なときだけ拒否する。
+func saveResultPrincipal(r *http.Request, req api.SaveResultRequest) (domain.Principal, bool, error) {
+	var (
+		principal domain.Principal
+		err       error
+	)
+	switch {
+	case req.Session != nil:
+		var session domain.SessionData
+		if session, err = req.Session.ToDomain(); err == nil {
+			principal, err = authenticateSession(r, session)
+		}
+	case hasRequestSession(r):
+		principal, err = requestPrincipal(r)
+		if errors.Is(err, domain.ErrInvalidCSRFToken) {
+			session, _ := requestSession(r)
+			if _, authErr := authenticateSession(r, session); authErr != nil {
+				err = authErr
+			}
+		}
+	default:
+		return domain.Principal{}, false, nil
+	}
+
+	switch {
+	case err == nil:
+		return principal, true, nil
+	case invalidCredential(err):
+		return domain.Principal{}, false, nil
+	default:
+		return domain.Principal{}, false, err
+	}
+}
+
+// bypassesCache は管理者が HueCacheHeader でキャッシュの迂回を求めたかを返す。
+func bypassesCache(r *http.Request, principal domain.Principal) bool {
+	if !strings.EqualFold(strings.TrimSpace(r.Header.Get(HueCacheHeader)), HueCacheBypass) {
+		return false
+	}
+	return principal.Require(domain.UserRoleAdmin) == nil
 }
 
 type HueGetHandler struct {
@@ -84,7 +217,7 @@ func (h *HueGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, recordRange, err := req.ToDomain()
+	session, query, err := req.ToDomain()
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrInvalidSessionToken),
@@ -92,13 +225,121 @@ func (h *HueGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			respondInvalidField(w, "session")
 		case errors.Is(err, domain.ErrInvalidRange):
 			respondInvalidField(w, "data-range")
+		case errors.Is(err, domain.ErrInvalidCursor):
+			respondInvalidField(w, "cursor")
+		case errors.Is(err, domain.ErrInvalidFilter):
+			respondInvalidField(w, "filter")
 		default:
 			respondInvalidField(w, "request")
 		}
 		return
 	}
 
-	records, err := h.service.GetData(r.Context(), session, recordRange)
+	// 本文の session を省略したら、Authorization ヘッダかセッションクッキーを使う。
+	var principal domain.Principal
+	if req.Session.IsZero() {
+		principal, err = requestPrincipal(r)
+	} else {
+		principal, err = authenticateSession(r, session)
+	}
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	page, err := h.service.GetData(r.Context(), principal, query)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewGetDataResponse(page))
+}
+
+// HueResultHandler は GET /api/hue-are-you/results/{id} を処理する。認証は不要。
+// 生成中は 202 と Retry-After を返すので、クライアントは status が確定するまで問い合わせる。
+// 生成済みなら、回答に最も近いクラスタも添える。
+type HueResultHandler struct {
+	service  HueResultStatusService
+	clusters HueClusterLookup
+}
+
+// NewHueResultHandler は clusters (nil 可) が無ければクラスタを添えない。
+func NewHueResultHandler(service HueResultStatusService, clusters HueClusterLookup) *HueResultHandler {
+	return &HueResultHandler{service: service, clusters: clusters}
+}
+
+func (h *HueResultHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondInvalidField(w, "id")
+		return
+	}
+
+	record, status, err := h.service.GetStatus(r.Context(), id)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	code := http.StatusOK
+	if !status.Finished() {
+		code = http.StatusAccepted
+		w.Header().Set("Retry-After", "1")
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	resp := api.NewResultStatusResponse(record, status)
+	if status.Status() == domain.HueJobStatusSucceeded && h.clusters != nil {
+		if cluster, ok := h.clusters.ClusterOf(r.Context(), record); ok {
+			resp = resp.WithCluster(cluster)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// HueSimilarHandler は GET /api/hue-are-you/results/{id}/similar を処理する。認証は不要。
+// 回答の公開を許可した参加者から limit (省略時 domain.DefaultSimilarLimit) 人まで、回答が似ている順に返す。
+type HueSimilarHandler struct {
+	service HueSimilarService
+}
+
+func NewHueSimilarHandler(service HueSimilarService) *HueSimilarHandler {
+	return &HueSimilarHandler{service: service}
+}
+
+func (h *HueSimilarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondInvalidField(w, "id")
+		return
+	}
+
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			respondInvalidField(w, "limit")
+			return
+		}
+	}
+
+	similar, err := h.service.GetSimilar(r.Context(), domain.NewHueSimilarityQuery(id, limit))
 	if err != nil {
 		handleHueServiceError(w, err)
 		return
@@ -106,15 +347,21 @@ func (h *HueGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(api.NewGetDataResponse(records))
+	_ = json.NewEncoder(w).Encode(api.NewSimilarResultsResponse(similar))
 }
 
 func handleHueServiceError(w http.ResponseWriter, err error) {
 	switch {
+	case errors.Is(err, domain.ErrHueRecordNotFound):
+		respondNotFound(w, "result")
+	case errors.Is(err, domain.ErrHueClustersNotFound):
+		respondNotFound(w, "clusters")
 	case errors.Is(err, domain.ErrInvalidSessionToken),
 		errors.Is(err, domain.ErrInvalidLoginSession),
 		errors.Is(err, domain.ErrExpiredToken):
 		respondUnauthorizedSession(w)
+	case errors.Is(err, domain.ErrInsufficientRole):
+		respondForbidden(w)
 	default:
 		respondInternalServerError(w)
 	}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+// HueClusterService は回答のクラスタを取得するユースケース境界。
+type HueClusterService interface {
+	GetClusters(ctx context.Context, principal domain.Principal) (domain.HueClusterReport, error)
+}
+
+// HueClusterHandler は GET /api/hue-are-you/clusters を処理する。管理者のみ。
+// まだクラスタリングしていなければ 404。
+type HueClusterHandler struct {
+	service HueClusterService
+}
+
+func NewHueClusterHandler(service HueClusterService) *HueClusterHandler {
+	return &HueClusterHandler{service: service}
+}
+
+func (h *HueClusterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	report, err := h.service.GetClusters(r.Context(), principal)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewClustersResponse(report))
+}
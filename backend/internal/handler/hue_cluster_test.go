@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+func TestHueClusterHandler_ServeHTTP_Success(t *testing.T) {
+	cluster := buildHueCluster(t)
+	model, err := domain.NewHueClusterModel(uuid.New(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 4, []domain.HueCluster{cluster})
+	if err != nil {
+		t.Fatalf("model error: %v", err)
+	}
+	exemplar := buildHueRecord(t)
+	svc := &fakeHueClusterService{report: domain.NewHueClusterReport(model, map[int][]domain.HueRecord{0: {exemplar}})}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/clusters", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	withTestAuth(NewHueClusterHandler(svc)).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	var body api.ClustersResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.RecordCount != 4 || len(body.Clusters) != 1 || body.Clusters[0].Size != 4 {
+		t.Fatalf("unexpected response: %+v", body)
+	}
+	if associations := body.Clusters[0].Associations; len(associations) != 2 || associations[0].Word != "夜" || associations[0].Weight != 1 {
+		t.Fatalf("unexpected associations: %+v", associations)
+	}
+	if exemplars := body.Clusters[0].Exemplars; len(exemplars) != 1 || exemplars[0].ID != exemplar.ID().String() || exemplars[0].Hue != nil {
+		t.Fatalf("unexpected exemplars: %+v", exemplars)
+	}
+}
+
+func TestHueClusterHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		auth   bool
+		err    error
+		status int
+	}{
+		{name: "method", method: http.MethodPost, auth: true, status: http.StatusMethodNotAllowed},
+		{name: "no session", method: http.MethodGet, status: http.StatusUnauthorized},
+		{name: "not admin", method: http.MethodGet, auth: true, err: domain.ErrInvalidLoginSession, status: http.StatusUnauthorized},
+		{name: "not clustered yet", method: http.MethodGet, auth: true, err: domain.ErrHueClustersNotFound, status: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/hue-are-you/clusters", nil)
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			}
+			res := httptest.NewRecorder()
+
+			withTestAuth(NewHueClusterHandler(&fakeHueClusterService{err: tc.err})).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+		})
+	}
+}
+
+func TestHueResultHandler_IncludesCluster(t *testing.T) {
+	stored, err := domain.NewHueRecordResult(buildHueResult(t), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	record := buildHueRecord(t).WithResult(stored)
+	lookup := &fakeHueClusterService{cluster: buildHueCluster(t), found: true}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+record.ID().String(), nil)
+	req.SetPathValue("id", record.ID().String())
+	res := httptest.NewRecorder()
+
+	NewHueResultHandler(&fakeHueResultService{record: record}, lookup).ServeHTTP(res, req)
+
+	var body api.SharedResultResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Cluster == nil || body.Cluster.Index != 0 || len(body.Cluster.Associations) != 2 {
+		t.Fatalf("expected cluster summary, got %+v", body.Cluster)
+	}
+
+	// 生成中のレコードにはクラスタを添えない。
+	req = httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+record.ID().String(), nil)
+	req.SetPathValue("id", record.ID().String())
+	res = httptest.NewRecorder()
+
+	NewHueResultHandler(&fakeHueResultService{record: record, status: domain.HueJobStatusRunning}, lookup).ServeHTTP(res, req)
+
+	body = api.SharedResultResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Cluster != nil {
+		t.Fatalf("expected no cluster while running, got %+v", body.Cluster)
+	}
+}
+
+type fakeHueClusterService struct {
+	report  domain.HueClusterReport
+	cluster domain.HueCluster
+	found   bool
+	err     error
+}
+
+func (f *fakeHueClusterService) GetClusters(_ context.Context, _ domain.Principal) (domain.HueClusterReport, error) {
+	if f.err != nil {
+		return domain.HueClusterReport{}, f.err
+	}
+	return f.report, nil
+}
+
+func (f *fakeHueClusterService) ClusterOf(_ context.Context, _ domain.HueRecord) (domain.HueCluster, bool) {
+	return f.cluster, f.found
+}
+
+func buildHueCluster(t *testing.T) domain.HueCluster {
+	t.Helper()
+	cluster, err := domain.NewHueCluster(0, 4, map[string]map[string]float64{"夜": {"赤": 1}, "朝": {"青": 0.5}})
+	if err != nil {
+		t.Fatalf("cluster error: %v", err)
+	}
+	return cluster
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/infra/export"
+	"backend/pkg/api"
+)
+
+const (
+	// exportFlushEvery 件ごとにクライアントへ送り出す。
+	exportFlushEvery = 200
+	// exportWriteTimeout は送り出すたびに延長する書き込み期限。サーバ全体の WriteTimeout より長い出力を許す。
+	exportWriteTimeout = 30 * time.Second
+)
+
+// HueExportService は Hue レコード書き出しのユースケース境界。
+type HueExportService interface {
+	Export(ctx context.Context, principal domain.Principal, filter domain.HueRecordFilter, sink domain.HueRecordSink) error
+}
+
+// HueExportHandler は GET /api/hue-are-you/export を処理する。
+// format=csv (既定, 横持ち) / csv-long (縦持ち) / ndjson を選べ、bom=0 で CSV の BOM を外す。
+// 絞り込みは from, to, name_contains, min_words, choice=語彙:色 (複数可)。
+type HueExportHandler struct {
+	service HueExportService
+}
+
+func NewHueExportHandler(service HueExportService) *HueExportHandler {
+	return &HueExportHandler{service: service}
+}
+
+func (h *HueExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		respondInvalidField(w, "format")
+		return
+	}
+
+	filter, err := api.ExportQuery{
+		From:         query.Get("from"),
+		To:           query.Get("to"),
+		NameContains: query.Get("name_contains"),
+		MinWords:     query.Get("min_words"),
+		Choices:      query["choice"],
+	}.ToDomain()
+	if err != nil {
+		respondInvalidField(w, "filter")
+		return
+	}
+
+	omitBOM := false
+	if v := query.Get("bom"); v != "" {
+		keep, err := strconv.ParseBool(v)
+		if err != nil {
+			respondInvalidField(w, "bom")
+			return
+		}
+		omitBOM = !keep
+	}
+
+	encoder, err := export.NewEncoder(format, w, export.Options{OmitBOM: omitBOM})
+	if err != nil {
+		respondInvalidField(w, "format")
+		return
+	}
+
+	sink := &exportResponse{w: w, rc: http.NewResponseController(w), encoder: encoder, format: format}
+	err = h.service.Export(r.Context(), principal, filter, sink)
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	if !sink.started {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	// ステータスは送信済みなので、接続を切って途中までの出力だと分かるようにする。
+	log.Print("error: export aborted: ", err)
+	panic(http.ErrAbortHandler)
+}
+
+// exportResponse は最初のレコードの前にヘッダを送り、一定件数ごとに書き出して期限を延ばす。
+type exportResponse struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	encoder export.Encoder
+	format  export.Format
+	started bool
+	written int
+}
+
+func (s *exportResponse) Begin(words []string) error {
+	s.started = true
+
+	header := s.w.Header()
+	header.Set("Content-Type", s.format.ContentType())
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(s.format, time.Now())))
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Content-Type-Options", "nosniff")
+	s.extendDeadline()
+	s.w.WriteHeader(http.StatusOK)
+
+	return s.encoder.Begin(words)
+}
+
+func (s *exportResponse) Write(record domain.HueRecord) error {
+	if err := s.encoder.Write(record); err != nil {
+		return err
+	}
+
+	s.written++
+	if s.written%exportFlushEvery != 0 {
+		return nil
+	}
+
+	if err := s.encoder.Flush(); err != nil {
+		return err
+	}
+	// テスト用の ResponseRecorder などは未対応なので、失敗しても続ける。
+	_ = s.rc.Flush()
+	s.extendDeadline()
+	return nil
+}
+
+func (s *exportResponse) extendDeadline() {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+}
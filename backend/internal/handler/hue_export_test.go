@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+func TestHueExportHandler_ServeHTTP_CSV(t *testing.T) {
+	record := buildHueRecord(t)
+	svc := &fakeHueExportService{words: []string{"夜"}, records: []domain.HueRecord{record}}
+	handler := withTestAuth(NewHueExportHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/export?name_contains=Test&choice=夜:赤&min_words=1", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if contentType := res.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Fatalf("expected csv, got %s", contentType)
+	}
+	if disposition := res.Header().Get("Content-Disposition"); !strings.Contains(disposition, ".csv") {
+		t.Fatalf("unexpected disposition: %s", disposition)
+	}
+
+	if name, _ := svc.filter.NameContains(); name != "Test" || svc.filter.Choices()["夜"] != "赤" || svc.filter.MinWords() != 1 {
+		t.Fatalf("unexpected filter passed to service")
+	}
+
+	body := res.Body.String()
+	if !strings.HasPrefix(body, "\ufeff") {
+		t.Fatalf("expected BOM at the start of csv")
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv: %v", err)
+	}
+	if len(rows) != 2 || rows[0][3] != "夜" || rows[1][0] != record.ID().String() || rows[1][3] != "赤" {
+		t.Fatalf("unexpected csv rows: %v", rows)
+	}
+}
+
+func TestHueExportHandler_NDJSONWithoutBOM(t *testing.T) {
+	svc := &fakeHueExportService{records: []domain.HueRecord{buildHueRecord(t), buildHueRecord(t)}}
+	handler := withTestAuth(NewHueExportHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/export?format=ndjson", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "{") {
+		t.Fatalf("unexpected ndjson body: %q", res.Body.String())
+	}
+}
+
+func TestHueExportHandler_Unauthorized(t *testing.T) {
+	svc := &fakeHueExportService{err: domain.ErrExpiredToken}
+	handler := withTestAuth(NewHueExportHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/export", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.Code)
+	}
+}
+
+func TestHueExportHandler_InvalidFormat(t *testing.T) {
+	handler := withTestAuth(NewHueExportHandler(&fakeHueExportService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/export?format=xlsx", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
+
+type fakeHueExportService struct {
+	words   []string
+	records []domain.HueRecord
+	filter  domain.HueRecordFilter
+	err     error
+}
+
+func (f *fakeHueExportService) Export(_ context.Context, _ domain.Principal, filter domain.HueRecordFilter, sink domain.HueRecordSink) error {
+	f.filter = filter
+	if f.err != nil {
+		return f.err
+	}
+	if err := sink.Begin(f.words); err != nil {
+		return err
+	}
+	for _, record := range f.records {
+		if err := sink.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
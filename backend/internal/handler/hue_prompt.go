@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+// HuePromptService はプロンプトの版を管理するユースケース境界。
+type HuePromptService interface {
+	List(ctx context.Context, principal domain.Principal) ([]domain.HuePromptVersion, error)
+	Create(ctx context.Context, principal domain.Principal, prompt domain.HuePromptVersion) error
+	Activate(ctx context.Context, principal domain.Principal, version string, weight int, exclusive bool) (domain.HuePromptVersion, error)
+}
+
+// HuePromptHandler は /api/hue-are-you/prompts を処理する。GET で一覧、POST で版を作成する。管理者のみ。
+// セッションは "Authorization: Bearer <user_id>:<token>" で受け取る。
+type HuePromptHandler struct {
+	service HuePromptService
+}
+
+func NewHuePromptHandler(service HuePromptService) *HuePromptHandler {
+	return &HuePromptHandler{service: service}
+}
+
+func (h *HuePromptHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, "GET, POST")
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		prompts, err := h.service.List(r.Context(), principal)
+		if err != nil {
+			handlePromptServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(api.NewPromptListResponse(prompts))
+		return
+	}
+
+	var req api.CreatePromptRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		log.Print("error: ", err)
+		respondInvalidJSON(w)
+		return
+	}
+
+	prompt, err := req.ToDomain()
+	if err != nil {
+		respondInvalidField(w, "prompt")
+		return
+	}
+
+	if err := h.service.Create(r.Context(), principal, prompt); err != nil {
+		handlePromptServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/hue-are-you/prompts/"+prompt.Version())
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(api.NewPromptVersionPayload(prompt))
+}
+
+// HuePromptActivateHandler は POST /api/hue-are-you/prompts/{version}/activate を処理する。管理者のみ。
+type HuePromptActivateHandler struct {
+	service HuePromptService
+}
+
+func NewHuePromptActivateHandler(service HuePromptService) *HuePromptActivateHandler {
+	return &HuePromptActivateHandler{service: service}
+}
+
+func (h *HuePromptActivateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	// 本文は省略できる (既定の重みで有効化)。
+	var req api.ActivatePromptRequest
+	if r.ContentLength != 0 {
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			log.Print("error: ", err)
+			respondInvalidJSON(w)
+			return
+		}
+	}
+
+	prompt, err := h.service.Activate(r.Context(), principal, r.PathValue("version"), req.WeightOrDefault(), req.Exclusive)
+	if err != nil {
+		handlePromptServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewPromptVersionPayload(prompt))
+}
+
+func handlePromptServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPromptVersion):
+		respondInvalidField(w, "prompt")
+	case errors.Is(err, domain.ErrDuplicatePromptVersion):
+		respondDuplicateField(w, "version")
+	case errors.Is(err, domain.ErrPromptVersionNotFound):
+		respondNotFound(w, "prompt")
+	default:
+		handleHueServiceError(w, err)
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+func TestHuePromptHandler_List(t *testing.T) {
+	prompt := buildPromptVersion(t, "v1")
+	svc := &fakeHuePromptService{prompts: []domain.HuePromptVersion{prompt}}
+	handler := withTestAuth(NewHuePromptHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/prompts", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	var body api.PromptListResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Prompts) != 1 || body.Prompts[0].Version != "v1" || body.Prompts[0].UserTemplate != "{{.Name}}" {
+		t.Fatalf("unexpected prompts: %+v", body.Prompts)
+	}
+}
+
+func TestHuePromptHandler_Create(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "created", body: `{"version":"v2","system_template":"s","user_template":"{{.Name}}"}`, wantStatus: http.StatusCreated},
+		{name: "broken template", body: `{"version":"v2","system_template":"{{","user_template":"u"}`, wantStatus: http.StatusBadRequest},
+		{name: "duplicate", body: `{"version":"v1","system_template":"s","user_template":"u"}`, err: domain.ErrDuplicatePromptVersion, wantStatus: http.StatusConflict},
+		{name: "not admin", body: `{"version":"v2","system_template":"s","user_template":"u"}`, err: domain.ErrInvalidLoginSession, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeHuePromptService{err: tc.err}
+			handler := withTestAuth(NewHuePromptHandler(svc))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/hue-are-you/prompts", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+
+			if res.Code != tc.wantStatus {
+				t.Fatalf("expected %d, got %d", tc.wantStatus, res.Code)
+			}
+			if tc.wantStatus == http.StatusCreated && (svc.created.Version() != "v2" || svc.created.Active()) {
+				t.Fatalf("expected inactive v2 to be created, got %+v", svc.created)
+			}
+		})
+	}
+}
+
+func TestHuePromptHandler_MissingAuthorization(t *testing.T) {
+	handler := withTestAuth(NewHuePromptHandler(&fakeHuePromptService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/prompts", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.Code)
+	}
+}
+
+func TestHuePromptActivateHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		body          string
+		err           error
+		wantStatus    int
+		wantWeight    int
+		wantExclusive bool
+	}{
+		{name: "default weight", body: "", wantStatus: http.StatusOK, wantWeight: domain.DefaultPromptWeight},
+		{name: "exclusive", body: `{"weight":20,"exclusive":true}`, wantStatus: http.StatusOK, wantWeight: 20, wantExclusive: true},
+		{name: "invalid weight", body: `{"weight":0}`, err: domain.ErrInvalidPromptVersion, wantStatus: http.StatusBadRequest, wantWeight: 0},
+		{name: "not found", body: "", err: domain.ErrPromptVersionNotFound, wantStatus: http.StatusNotFound, wantWeight: domain.DefaultPromptWeight},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeHuePromptService{err: tc.err, prompts: []domain.HuePromptVersion{buildPromptVersion(t, "v2")}}
+			handler := withTestAuth(NewHuePromptActivateHandler(svc))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/hue-are-you/prompts/v2/activate", strings.NewReader(tc.body))
+			req.SetPathValue("version", "v2")
+			req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+
+			if res.Code != tc.wantStatus {
+				t.Fatalf("expected %d, got %d", tc.wantStatus, res.Code)
+			}
+			if svc.activated != "v2" || svc.weight != tc.wantWeight || svc.exclusive != tc.wantExclusive {
+				t.Fatalf("unexpected activation: %s %d %v", svc.activated, svc.weight, svc.exclusive)
+			}
+		})
+	}
+}
+
+type fakeHuePromptService struct {
+	prompts   []domain.HuePromptVersion
+	created   domain.HuePromptVersion
+	activated string
+	weight    int
+	exclusive bool
+	err       error
+}
+
+func (f *fakeHuePromptService) List(_ context.Context, _ domain.Principal) ([]domain.HuePromptVersion, error) {
+	return f.prompts, f.err
+}
+
+func (f *fakeHuePromptService) Create(_ context.Context, _ domain.Principal, prompt domain.HuePromptVersion) error {
+	f.created = prompt
+	return f.err
+}
+
+func (f *fakeHuePromptService) Activate(_ context.Context, _ domain.Principal, version string, weight int, exclusive bool) (domain.HuePromptVersion, error) {
+	f.activated, f.weight, f.exclusive = version, weight, exclusive
+	if f.err != nil {
+		return domain.HuePromptVersion{}, f.err
+	}
+	return f.prompts[0].Activate(weight, time.Now())
+}
+
+func buildPromptVersion(t *testing.T, version string) domain.HuePromptVersion {
+	t.Helper()
+	prompt, err := domain.NewHuePromptVersion(version, "system", "{{.Name}}")
+	if err != nil {
+		t.Fatalf("prompt error: %v", err)
+	}
+	return prompt
+}
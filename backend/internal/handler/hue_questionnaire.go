@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+// HueQuestionnaireService は質問票取得のユースケース境界。
+type HueQuestionnaireService interface {
+	Questionnaire(version string) (domain.Questionnaire, error)
+}
+
+// HueQuestionnaireHandler は GET /api/hue-are-you/questionnaire を処理する。
+// version を省略すると現行の版を返す。認証は不要。
+type HueQuestionnaireHandler struct {
+	service HueQuestionnaireService
+}
+
+func NewHueQuestionnaireHandler(service HueQuestionnaireService) *HueQuestionnaireHandler {
+	return &HueQuestionnaireHandler{service: service}
+}
+
+func (h *HueQuestionnaireHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	questionnaire, err := h.service.Questionnaire(r.URL.Query().Get("version"))
+	if err != nil {
+		if errors.Is(err, domain.ErrUnknownQuestionnaire) {
+			respondNotFound(w, "version")
+			return
+		}
+		respondInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewQuestionnaireResponse(questionnaire))
+}
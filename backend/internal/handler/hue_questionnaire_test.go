@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+func TestHueQuestionnaireHandler_ServeHTTP_Success(t *testing.T) {
+	handler := NewHueQuestionnaireHandler(fakeHueQuestionnaireService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/questionnaire", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	var body api.QuestionnaireResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if body.Version != domain.ActiveQuestionnaireVersion || len(body.Words) == 0 || body.Words[0] != "夜" {
+		t.Fatalf("unexpected questionnaire: %+v", body)
+	}
+	if len(body.Palette) != 11 || body.Palette[0].Name != "黒" || body.Palette[0].Hex != "#444444" {
+		t.Fatalf("unexpected palette: %+v", body.Palette)
+	}
+}
+
+func TestHueQuestionnaireHandler_UnknownVersion(t *testing.T) {
+	handler := NewHueQuestionnaireHandler(fakeHueQuestionnaireService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/questionnaire?version=v0", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+type fakeHueQuestionnaireService struct{}
+
+func (fakeHueQuestionnaireService) Questionnaire(version string) (domain.Questionnaire, error) {
+	return domain.FindQuestionnaire(version)
+}
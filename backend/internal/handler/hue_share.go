@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// HueCardService は結果カード画像のユースケース境界。
+type HueCardService interface {
+	CardPNG(ctx context.Context, id uuid.UUID) ([]byte, error)
+}
+
+// HueCardHandler は GET /api/hue-are-you/results/{id}/card.png を処理する。
+type HueCardHandler struct {
+	service HueCardService
+}
+
+func NewHueCardHandler(service HueCardService) *HueCardHandler {
+	return &HueCardHandler{service: service}
+}
+
+func (h *HueCardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondInvalidField(w, "id")
+		return
+	}
+
+	// 存在しない ID や未生成の結果に 304 を返さないよう、条件付きリクエストでも先に引く。
+	// カードはサービス側でキャッシュしているので再描画にはならない。
+	png, err := h.service.CardPNG(r.Context(), id)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	// 生成済みの結果は変わらないので、ID をそのまま ETag に使う。
+	etag := fmt.Sprintf(`"%s"`, id)
+	if match := r.Header.Get("If-None-Match"); match == etag {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(png)
+	}
+}
+
+var hueSharePageTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="ahaha craft">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.PageURL}}">
+<meta property="og:image" content="{{.ImageURL}}">
+<meta property="og:image:width" content="{{.ImageWidth}}">
+<meta property="og:image:height" content="{{.ImageHeight}}">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+<meta name="twitter:image" content="{{.ImageURL}}">
+<meta http-equiv="refresh" content="0; url={{.PageURL}}">
+<link rel="canonical" href="{{.PageURL}}">
+</head>
+<body>
+<p><a href="{{.PageURL}}">{{.Title}}</a></p>
+</body>
+</html>
+`))
+
+// HueSharePageHandler は GET /api/hue-are-you/results/{id}/share を処理する。
+// SNS のクローラ向けに OG/Twitter の meta を返し、ブラウザは結果ページへリダイレクトする。
+type HueSharePageHandler struct {
+	service     HueResultService
+	siteURL     string
+	imageWidth  int
+	imageHeight int
+}
+
+// NewHueSharePageHandler は siteURL (例: https://www.ahaha-craft.org) を基準に絶対 URL を組み立てる。
+func NewHueSharePageHandler(service HueResultService, siteURL string, imageWidth, imageHeight int) *HueSharePageHandler {
+	return &HueSharePageHandler{
+		service:     service,
+		siteURL:     strings.TrimRight(siteURL, "/"),
+		imageWidth:  imageWidth,
+		imageHeight: imageHeight,
+	}
+}
+
+func (h *HueSharePageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondInvalidField(w, "id")
+		return
+	}
+
+	record, err := h.service.GetResult(r.Context(), id)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = hueSharePageTemplate.Execute(w, h.pageData(record))
+}
+
+type hueSharePageData struct {
+	Title       string
+	Description string
+	PageURL     string
+	ImageURL    string
+	ImageWidth  int
+	ImageHeight int
+}
+
+func (h *HueSharePageHandler) pageData(record domain.HueRecord) hueSharePageData {
+	result, _ := record.Result()
+	id := record.ID().String()
+	return hueSharePageData{
+		Title:       fmt.Sprintf("%s さんの色 | Hue Are You", record.Name().String()),
+		Description: result.Result().Message(),
+		PageURL:     fmt.Sprintf("%s/hue-are-you?result=%s", h.siteURL, id),
+		ImageURL:    fmt.Sprintf("%s/api/hue-are-you/results/%s/card.png", h.siteURL, id),
+		ImageWidth:  h.imageWidth,
+		ImageHeight: h.imageHeight,
+	}
+}
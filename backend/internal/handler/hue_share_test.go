@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestHueCardHandler_ServeHTTP_Success(t *testing.T) {
+	id := uuid.New()
+	svc := &fakeHueCardService{png: []byte("png-bytes")}
+	handler := NewHueCardHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id.String()+"/card.png", nil)
+	req.SetPathValue("id", id.String())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if contentType := res.Header().Get("Content-Type"); contentType != "image/png" {
+		t.Fatalf("expected image/png, got %s", contentType)
+	}
+	if res.Body.String() != "png-bytes" {
+		t.Fatalf("unexpected body: %s", res.Body.String())
+	}
+	if etag := res.Header().Get("ETag"); etag != `"`+id.String()+`"` {
+		t.Fatalf("unexpected etag: %s", etag)
+	}
+}
+
+func TestHueCardHandler_NotModified(t *testing.T) {
+	id := uuid.New()
+	svc := &fakeHueCardService{png: []byte("png-bytes")}
+	handler := NewHueCardHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id.String()+"/card.png", nil)
+	req.SetPathValue("id", id.String())
+	req.Header.Set("If-None-Match", `"`+id.String()+`"`)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", res.Code)
+	}
+	if res.Body.Len() != 0 {
+		t.Fatalf("expected empty body, got %q", res.Body.String())
+	}
+}
+
+func TestHueCardHandler_NotModifiedUnknownID(t *testing.T) {
+	id := uuid.New()
+	handler := NewHueCardHandler(&fakeHueCardService{err: domain.ErrHueRecordNotFound})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id.String()+"/card.png", nil)
+	req.SetPathValue("id", id.String())
+	req.Header.Set("If-None-Match", `"`+id.String()+`"`)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestHueCardHandler_NotFound(t *testing.T) {
+	id := uuid.New()
+	handler := NewHueCardHandler(&fakeHueCardService{err: domain.ErrHueRecordNotFound})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id.String()+"/card.png", nil)
+	req.SetPathValue("id", id.String())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestHueSharePageHandler_ServeHTTP_Success(t *testing.T) {
+	stored, err := domain.NewHueRecordResult(buildHueResult(t), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	record := buildHueRecord(t).WithResult(stored)
+	handler := NewHueSharePageHandler(&fakeHueResultService{record: record}, "https://example.com/", 1200, 630)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+record.ID().String()+"/share", nil)
+	req.SetPathValue("id", record.ID().String())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	body := res.Body.String()
+	wants := []string{
+		`<meta property="og:image" content="https://example.com/api/hue-are-you/results/` + record.ID().String() + `/card.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta property="og:description" content="ok">`,
+		`Tester さんの色`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected body to contain %q, got %s", want, body)
+		}
+	}
+}
+
+func TestHueSharePageHandler_EscapesName(t *testing.T) {
+	stored, err := domain.NewHueRecordResult(buildHueResult(t), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	record, err := domain.NewHueRecord(buildName(t, `"><script>`), buildHueRecord(t).Choices())
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	handler := NewHueSharePageHandler(&fakeHueResultService{record: record.WithResult(stored)}, "https://example.com", 1200, 630)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/x/share", nil)
+	req.SetPathValue("id", record.ID().String())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if strings.Contains(res.Body.String(), "<script>") {
+		t.Fatalf("expected name to be escaped, got %s", res.Body.String())
+	}
+}
+
+type fakeHueCardService struct {
+	png []byte
+	err error
+}
+
+func (f *fakeHueCardService) CardPNG(_ context.Context, _ uuid.UUID) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.png, nil
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+// HueStatsService は Hue 集計取得のユースケース境界。
+type HueStatsService interface {
+	GetStats(ctx context.Context, principal domain.Principal, window domain.TimeWindow) (domain.HueStats, error)
+}
+
+// HueStatsHandler は GET /api/hue-are-you/stats を処理する。
+// セッションは "Authorization: Bearer <user_id>:<token>" で受け取る。
+type HueStatsHandler struct {
+	service HueStatsService
+}
+
+func NewHueStatsHandler(service HueStatsService) *HueStatsHandler {
+	return &HueStatsHandler{service: service}
+}
+
+func (h *HueStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	window, err := api.StatsQuery{From: query.Get("from"), To: query.Get("to")}.ToDomain()
+	if err != nil {
+		respondInvalidField(w, "from/to")
+		return
+	}
+
+	stats, err := h.service.GetStats(r.Context(), principal, window)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewStatsResponse(stats))
+}
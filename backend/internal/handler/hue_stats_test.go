@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+func TestHueStatsHandler_ServeHTTP_Success(t *testing.T) {
+	session := buildSessionData(t)
+	word, err := domain.NewHueWordStats("夜", map[string]int{"黒": 3, "青": 1})
+	if err != nil {
+		t.Fatalf("word stats error: %v", err)
+	}
+	stats, err := domain.NewHueStats(domain.TimeWindow{}, 4, []domain.HueWordStats{word})
+	if err != nil {
+		t.Fatalf("stats error: %v", err)
+	}
+	svc := &fakeHueStatsService{stats: stats}
+	handler := withTestAuth(NewHueStatsHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/stats?from=2025-01-01&to=2025-02-01T00:00:00Z", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(session).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if svc.principal.UserID() != session.UserID() {
+		t.Fatalf("expected the authenticated principal to be passed through")
+	}
+	if from, ok := svc.window.From(); !ok || from.Month() != 1 {
+		t.Fatalf("expected from to be parsed, got %v", from)
+	}
+
+	var body api.StatsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Participants != 4 || len(body.Words) != 1 || body.Words[0].MostTypical.Color != "黒" || body.Words[0].LeastTypical.Color != "青" {
+		t.Fatalf("unexpected response body: %+v", body)
+	}
+}
+
+func TestHueStatsHandler_MissingAuthorization(t *testing.T) {
+	svc := &fakeHueStatsService{}
+	handler := withTestAuth(NewHueStatsHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/stats", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.Code)
+	}
+	if svc.called {
+		t.Fatalf("service should not be called without a session")
+	}
+}
+
+func TestHueStatsHandler_InvalidWindow(t *testing.T) {
+	handler := withTestAuth(NewHueStatsHandler(&fakeHueStatsService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/stats?from=2025-02-01&to=2025-01-01", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
+
+func TestHueStatsHandler_NotAdmin(t *testing.T) {
+	handler := withTestAuth(NewHueStatsHandler(&fakeHueStatsService{err: domain.ErrInvalidLoginSession}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/stats", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.Code)
+	}
+}
+
+type fakeHueStatsService struct {
+	stats     domain.HueStats
+	principal domain.Principal
+	window    domain.TimeWindow
+	err       error
+	called    bool
+}
+
+func (f *fakeHueStatsService) GetStats(_ context.Context, principal domain.Principal, window domain.TimeWindow) (domain.HueStats, error) {
+	f.called = true
+	f.principal = principal
+	f.window = window
+	if f.err != nil {
+		return domain.HueStats{}, f.err
+	}
+	return f.stats, nil
+}
+
+func buildSessionData(t *testing.T) domain.SessionData {
+	t.Helper()
+	token, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	session, err := domain.NewSessionData(uuid.New(), token)
+	if err != nil {
+		t.Fatalf("session error: %v", err)
+	}
+	return session
+}
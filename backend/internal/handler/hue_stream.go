@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// streamMaxDuration を過ぎたら接続を閉じる。クライアントは results/{id} の問い合わせで続きを待つ。
+	streamMaxDuration = 3 * time.Minute
+	// streamHeartbeat ごとにコメント行を送り、中継するプロキシに接続を切られないようにする。
+	streamHeartbeat = 10 * time.Second
+	// streamWriteTimeout は送り出すたびに延長する書き込み期限。
+	streamWriteTimeout = 30 * time.Second
+)
+
+// HueResultStreamService は結果生成の途中経過を届けるユースケース境界。
+type HueResultStreamService interface {
+	Stream(ctx context.Context, id uuid.UUID, sink domain.HueResultStreamSink) error
+}
+
+// HueStreamHandler は GET /api/hue-are-you/results/{id}/stream を Server-Sent Events で返す。認証は不要。
+// status (状態), message (メッセージの続き), reset (生成のやり直し), result (完成した結果) の各イベントを送る。
+// result の message が正で、SSE を使えないクライアントは results/{id} を問い合わせればよい。
+type HueStreamHandler struct {
+	service HueResultStreamService
+}
+
+func NewHueStreamHandler(service HueResultStreamService) *HueStreamHandler {
+	return &HueStreamHandler{service: service}
+}
+
+func (h *HueStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondInvalidField(w, "id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), streamMaxDuration)
+	defer cancel()
+
+	sink := &streamResponse{w: w, rc: http.NewResponseController(w)}
+	stopHeartbeat := sink.heartbeat(ctx)
+	err = h.service.Stream(ctx, id, sink)
+	stopHeartbeat()
+
+	if err == nil {
+		return
+	}
+	if !sink.isStarted() {
+		handleHueServiceError(w, err)
+		return
+	}
+	// 切断と時間切れは正常な終わり方として扱う。
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	log.Print("error: result stream aborted: ", err)
+}
+
+// streamResponse は最初のイベントの前にヘッダを送り、イベントごとに送り出して期限を延ばす。
+// 心拍のゴルーチンからも書くので、書き込みは mu で直列化する。
+type streamResponse struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func (s *streamResponse) Status(record domain.HueRecord, status domain.HueResultStatus) error {
+	return s.event("status", api.NewResultStatusResponse(record, status))
+}
+
+func (s *streamResponse) Message(delta string) error {
+	return s.event("message", api.StreamMessagePayload{Delta: delta})
+}
+
+func (s *streamResponse) Reset() error {
+	return s.event("reset", struct{}{})
+}
+
+func (s *streamResponse) Result(record domain.HueRecord) error {
+	return s.event("result", api.NewSharedResultResponse(record))
+}
+
+func (s *streamResponse) event(name string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.start()
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *streamResponse) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	header := s.w.Header()
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Content-Type-Options", "nosniff")
+	// nginx などがバッファしないようにする。
+	header.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+func (s *streamResponse) isStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+// heartbeat は最初のイベントを送った後、一定間隔でコメント行を送る。戻り値で止める。
+func (s *streamResponse) heartbeat(ctx context.Context) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(streamHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if s.started {
+					if _, err := fmt.Fprint(s.w, ": ping\n\n"); err == nil {
+						s.flush()
+					}
+				}
+				s.mu.Unlock()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (s *streamResponse) flush() {
+	// テスト用の ResponseRecorder などは未対応なので、失敗しても続ける。
+	_ = s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	_ = s.rc.Flush()
+}
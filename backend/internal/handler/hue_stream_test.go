@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestHueStreamHandler_ServeHTTP_Events(t *testing.T) {
+	record := buildHueRecord(t)
+	service := &fakeHueStreamService{
+		emit: func(sink domain.HueResultStreamSink) error {
+			if err := sink.Status(record, domain.NewHueResultStatus(domain.HueJobStatusRunning, domain.HueJobFailureNone)); err != nil {
+				return err
+			}
+			if err := sink.Message("こん"); err != nil {
+				return err
+			}
+			return sink.Message("にちは")
+		},
+	}
+	handler := NewHueStreamHandler(service)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+record.ID().String()+"/stream", nil)
+	req.SetPathValue("id", record.ID().String())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if got := res.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
+		t.Fatalf("unexpected content type: %s", got)
+	}
+	if service.id != record.ID() {
+		t.Fatalf("expected service to receive the record id")
+	}
+
+	want := "event: status\ndata: {\"id\":\"" + record.ID().String() + "\",\"status\":\"running\",\"name\":\"Tester\"}\n\n" +
+		"event: message\ndata: {\"delta\":\"こん\"}\n\n" +
+		"event: message\ndata: {\"delta\":\"にちは\"}\n\n"
+	if got := res.Body.String(); got != want {
+		t.Fatalf("unexpected body:\n%s", got)
+	}
+}
+
+func TestHueStreamHandler_NotFound(t *testing.T) {
+	handler := NewHueStreamHandler(&fakeHueStreamService{
+		emit: func(domain.HueResultStreamSink) error { return domain.ErrHueRecordNotFound },
+	})
+
+	id := uuid.New().String()
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id+"/stream", nil)
+	req.SetPathValue("id", id)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestHueStreamHandler_InvalidID(t *testing.T) {
+	handler := NewHueStreamHandler(&fakeHueStreamService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/x/stream", nil)
+	req.SetPathValue("id", "x")
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
+
+type fakeHueStreamService struct {
+	id   uuid.UUID
+	emit func(sink domain.HueResultStreamSink) error
+}
+
+func (f *fakeHueStreamService) Stream(_ context.Context, id uuid.UUID, sink domain.HueResultStreamSink) error {
+	f.id = id
+	if f.emit == nil {
+		return nil
+	}
+	return f.emit(sink)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"backend/internal/domain"
 	"backend/pkg/api"
@@ -17,15 +18,14 @@ import (
 
 func TestHueSaveHandler_ServeHTTP_Success(t *testing.T) {
 	record := buildHueRecord(t)
-	result := buildHueResult(t)
 
-	svc := &fakeHueSaveService{record: record, result: result}
-	handler := NewHueSaveHandler(svc)
+	svc := &fakeHueSaveService{}
+	handler := withTestAuth(NewHueSaveHandler(svc))
 
 	reqBody := marshal(t, api.SaveResultRequest{
-		HueRecordPayload: api.HueRecordPayload{
-			record.Name().String(),
-			record.ChoiceMap(),
+		HueAnswerPayload: api.HueAnswerPayload{
+			Name:   record.Name().String(),
+			Choice: record.ChoiceMap(),
 		},
 	})
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(reqBody))
@@ -33,8 +33,8 @@ func TestHueSaveHandler_ServeHTTP_Success(t *testing.T) {
 
 	handler.ServeHTTP(res, req)
 
-	if res.Code != http.StatusCreated {
-		t.Fatalf("expected 201, got %d", res.Code)
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", res.Code)
 	}
 
 	if !svc.called {
@@ -50,13 +50,216 @@ func TestHueSaveHandler_ServeHTTP_Success(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if body.Message != result.Message() || body.Hue.R != result.Hue().R() {
+	if body.Status != "pending" {
 		t.Fatalf("unexpected response body: %+v", body)
 	}
+
+	if body.ID != svc.record.ID().String() {
+		t.Fatalf("expected id %s, got %s", svc.record.ID(), body.ID)
+	}
+
+	if location := res.Header().Get("Location"); location != "/api/hue-are-you/results/"+body.ID {
+		t.Fatalf("unexpected location: %s", location)
+	}
+
+	if svc.record.QuestionnaireVersion() != domain.ActiveQuestionnaireVersion {
+		t.Fatalf("expected record to be tagged with active questionnaire, got %q", svc.record.QuestionnaireVersion())
+	}
+}
+
+func TestHueSaveHandler_BypassCache(t *testing.T) {
+	record := buildHueRecord(t)
+	session := buildSessionData(t)
+	reqBody := marshal(t, api.SaveResultRequest{
+		HueAnswerPayload: api.HueAnswerPayload{Name: record.Name().String(), Choice: record.ChoiceMap()},
+	})
+	bearer := "Bearer " + api.NewSessionPayload(session).BearerCredential()
+
+	cases := []struct {
+		name     string
+		header   string
+		value    string
+		auth     string
+		role     domain.UserRole
+		bypassed bool
+		owned    bool
+	}{
+		{name: "admin", header: HueCacheHeader, value: "Bypass", auth: bearer, role: domain.UserRoleAdmin, bypassed: true},
+		// 管理者以外の迂回指定は無視して、普段どおり保存する。
+		{name: "user", header: HueCacheHeader, value: HueCacheBypass, auth: bearer, role: domain.UserRoleUser, owned: true},
+		{name: "anonymous", header: HueCacheHeader, value: HueCacheBypass},
+		// プロキシやブラウザが付ける Cache-Control は迂回の指定にしない。
+		{name: "cache-control", header: "Cache-Control", value: "no-cache", auth: bearer, role: domain.UserRoleAdmin, owned: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeHueSaveService{}
+			req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(reqBody))
+			req.Header.Set(tc.header, tc.value)
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			res := httptest.NewRecorder()
+
+			WithAuthentication(NewHueSaveHandler(svc), &fakeAuthenticator{role: tc.role}).ServeHTTP(res, req)
+
+			if res.Code != http.StatusAccepted {
+				t.Fatalf("expected 202, got %d", res.Code)
+			}
+			if svc.bypassed != tc.bypassed || svc.owned != tc.owned {
+				t.Fatalf("unexpected save path: bypassed=%v owned=%v", svc.bypassed, svc.owned)
+			}
+			if tc.bypassed && svc.principal.UserID() != session.UserID() {
+				t.Fatalf("expected SaveResultWithoutCache to be called with the session")
+			}
+		})
+	}
+}
+
+func TestHueSaveHandler_UserSession(t *testing.T) {
+	record := buildHueRecord(t)
+	session := buildSessionData(t)
+	payload := api.HueAnswerPayload{Name: record.Name().String(), Choice: record.ChoiceMap()}
+	sessionPayload := api.NewSessionPayload(session)
+
+	cases := []struct {
+		name    string
+		body    api.SaveResultRequest
+		auth    string
+		cookie  bool
+		authErr error
+		status  int
+		owned   bool
+	}{
+		{name: "anonymous", body: api.SaveResultRequest{HueAnswerPayload: payload}, status: http.StatusAccepted},
+		{name: "body session", body: api.SaveResultRequest{HueAnswerPayload: payload, Session: &sessionPayload}, status: http.StatusAccepted, owned: true},
+		{name: "bearer session", body: api.SaveResultRequest{HueAnswerPayload: payload}, auth: "Bearer " + sessionPayload.BearerCredential(), status: http.StatusAccepted, owned: true},
+		// 照合できない資格情報は匿名の回答として受け付ける。
+		{name: "invalid bearer", body: api.SaveResultRequest{HueAnswerPayload: payload}, auth: "Bearer broken", status: http.StatusAccepted},
+		{name: "expired body session", body: api.SaveResultRequest{HueAnswerPayload: payload, Session: &sessionPayload}, authErr: domain.ErrExpiredToken, status: http.StatusAccepted},
+		{name: "expired cookie without csrf", body: api.SaveResultRequest{HueAnswerPayload: payload}, cookie: true, authErr: domain.ErrInvalidLoginSession, status: http.StatusAccepted},
+		// 有効なクッキーのセッションで CSRF ヘッダが無ければ拒否する。
+		{name: "cookie without csrf", body: api.SaveResultRequest{HueAnswerPayload: payload}, cookie: true, status: http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeHueSaveService{}
+			req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(marshal(t, tc.body)))
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			if tc.cookie {
+				req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: sessionPayload.BearerCredential()})
+			}
+			res := httptest.NewRecorder()
+
+			handler := WithAuthentication(NewHueSaveHandler(svc), &fakeAuthenticator{err: tc.authErr})
+			WithSessionCredentials(handler, NewSessionCookies(SessionCookieConfig{})).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+			if svc.called != (tc.status == http.StatusAccepted) {
+				t.Fatalf("unexpected call to service: %v", svc.called)
+			}
+			if svc.owned != tc.owned {
+				t.Fatalf("expected owned=%v, got %v", tc.owned, svc.owned)
+			}
+			if tc.owned && svc.principal.UserID() != session.UserID() {
+				t.Fatalf("expected session to be passed through")
+			}
+		})
+	}
+}
+
+func TestHueSaveHandler_IdempotencyKey(t *testing.T) {
+	record := buildHueRecord(t)
+	reqBody := marshal(t, api.SaveResultRequest{
+		HueAnswerPayload: api.HueAnswerPayload{Name: record.Name().String(), Choice: record.ChoiceMap()},
+	})
+
+	svc := &fakeHueSaveService{}
+	req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(reqBody))
+	req.Header.Set("Idempotency-Key", "retry-1")
+	res := httptest.NewRecorder()
+
+	withTestAuth(NewHueSaveHandler(svc)).ServeHTTP(res, req)
+
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", res.Code)
+	}
+	expected, err := domain.NewIdempotencyKey("retry-1", []byte(reqBody))
+	if err != nil {
+		t.Fatalf("key error: %v", err)
+	}
+	if svc.key != expected {
+		t.Fatalf("expected key with body hash to be passed, got %+v", svc.key)
+	}
+
+	cases := []struct {
+		name   string
+		key    string
+		err    error
+		status int
+	}{
+		{name: "invalid key", key: "has space", status: http.StatusBadRequest},
+		{name: "conflict", key: "retry-1", err: domain.ErrIdempotencyKeyConflict, status: http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeHueSaveService{err: tc.err}
+			req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(reqBody))
+			req.Header.Set("Idempotency-Key", tc.key)
+			res := httptest.NewRecorder()
+
+			withTestAuth(NewHueSaveHandler(svc)).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+		})
+	}
+}
+
+func TestHueSaveHandler_UnknownWordOrVersion(t *testing.T) {
+	cases := map[string]struct {
+		payload api.HueAnswerPayload
+		field   string
+	}{
+		"unknown word": {
+			payload: api.HueAnswerPayload{Name: "Tester", Choice: map[string]string{"海": "青"}},
+			field:   "choice",
+		},
+		"unknown version": {
+			payload: api.HueAnswerPayload{Name: "Tester", Choice: map[string]string{"夜": "青"}, QuestionnaireVersion: "v0"},
+			field:   "questionnaire_version",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc := &fakeHueSaveService{}
+			handler := withTestAuth(NewHueSaveHandler(svc))
+			req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(marshal(t, api.SaveResultRequest{HueAnswerPayload: tc.payload})))
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+
+			if res.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", res.Code)
+			}
+			if !strings.Contains(res.Body.String(), tc.field) {
+				t.Fatalf("expected field %s in error: %s", tc.field, res.Body.String())
+			}
+			if svc.called {
+				t.Fatalf("service should not be called")
+			}
+		})
+	}
 }
 
 func TestHueSaveHandler_InvalidJSON(t *testing.T) {
-	handler := NewHueSaveHandler(&fakeHueSaveService{})
+	handler := withTestAuth(NewHueSaveHandler(&fakeHueSaveService{}))
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(`{"session":1}`))
 	res := httptest.NewRecorder()
 
@@ -67,8 +270,24 @@ func TestHueSaveHandler_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestHueSaveHandler_RejectsResult(t *testing.T) {
+	svc := &fakeHueSaveService{}
+	body := `{"name":"Tester","choice":{"夜":"青"},"result":{"message":"forged"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(body))
+	res := httptest.NewRecorder()
+
+	withTestAuth(NewHueSaveHandler(svc)).ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+	if svc.called {
+		t.Fatalf("service should not be called with a client-supplied result")
+	}
+}
+
 func TestHueSaveHandler_InvalidDomain(t *testing.T) {
-	handler := NewHueSaveHandler(&fakeHueSaveService{})
+	handler := withTestAuth(NewHueSaveHandler(&fakeHueSaveService{}))
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(`{"user_name":" ","record":{"name":"a","choice":{"w":"赤"}}}`))
 	res := httptest.NewRecorder()
 
@@ -81,12 +300,12 @@ func TestHueSaveHandler_InvalidDomain(t *testing.T) {
 
 func TestHueSaveHandler_InternalError(t *testing.T) {
 	svc := &fakeHueSaveService{err: errors.New("boom")}
-	handler := NewHueSaveHandler(svc)
+	handler := withTestAuth(NewHueSaveHandler(svc))
 	record := buildHueRecord(t)
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/save", strings.NewReader(marshal(t, api.SaveResultRequest{
-		HueRecordPayload: api.HueRecordPayload{
-			record.Name().String(),
-			record.ChoiceMap(),
+		HueAnswerPayload: api.HueAnswerPayload{
+			Name:   record.Name().String(),
+			Choice: record.ChoiceMap(),
 		},
 	})))
 	res := httptest.NewRecorder()
@@ -99,7 +318,7 @@ func TestHueSaveHandler_InternalError(t *testing.T) {
 }
 
 func TestHueSaveHandler_MethodNotAllowed(t *testing.T) {
-	handler := NewHueSaveHandler(&fakeHueSaveService{})
+	handler := withTestAuth(NewHueSaveHandler(&fakeHueSaveService{}))
 	req := httptest.NewRequest(http.MethodGet, "/api/hue/save", nil)
 	res := httptest.NewRecorder()
 
@@ -114,8 +333,12 @@ func TestHueGetHandler_ServeHTTP_Success(t *testing.T) {
 	token, _ := domain.NewLoginSessionToken()
 	session, _ := domain.NewSessionData(uuid.New(), token)
 	record := buildHueRecord(t)
-	svc := &fakeHueGetService{records: []domain.HueRecord{record}}
-	handler := NewHueGetHandler(svc)
+	stored, err := domain.NewHueRecordResult(buildHueResult(t), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	svc := &fakeHueGetService{records: []domain.HueRecord{record.WithResult(stored), buildHueRecord(t)}}
+	handler := withTestAuth(NewHueGetHandler(svc))
 
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
 		Session:   api.NewSessionPayload(session),
@@ -138,13 +361,138 @@ func TestHueGetHandler_ServeHTTP_Success(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if len(resp.Records) != 1 || resp.Records[0].Name != record.Name().String() {
+	if len(resp.Records) != 2 || resp.Records[0].Name != record.Name().String() {
 		t.Fatalf("unexpected response payload")
 	}
+
+	if got := resp.Records[0].Result; got == nil || got.Hue.B != 30 || got.Generator != "fake" || got.LatencyMS != 1000 {
+		t.Fatalf("unexpected result payload: %+v", got)
+	}
+
+	if resp.Records[1].Result != nil {
+		t.Fatalf("expected no result for record without generation")
+	}
+}
+
+func TestHueGetHandler_CursorPagination(t *testing.T) {
+	token, _ := domain.NewLoginSessionToken()
+	session, _ := domain.NewSessionData(uuid.New(), token)
+	record := buildHueRecord(t)
+	after, err := domain.NewRecordCursor(time.Now(), uuid.New())
+	if err != nil {
+		t.Fatalf("cursor error: %v", err)
+	}
+	next, err := domain.NewRecordCursor(record.CreatedAt(), record.ID())
+	if err != nil {
+		t.Fatalf("cursor error: %v", err)
+	}
+	svc := &fakeHueGetService{records: []domain.HueRecord{record}, next: &next}
+	handler := withTestAuth(NewHueGetHandler(svc))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
+		Session: api.NewSessionPayload(session),
+		Cursor:  after.String(),
+		Limit:   1,
+	})))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	cursor, ok := svc.query.Cursor()
+	if !ok || cursor.ID() != after.ID() || svc.query.Limit() != 1 {
+		t.Fatalf("unexpected query passed to service")
+	}
+
+	var resp api.GetDataResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.NextCursor != next.String() {
+		t.Fatalf("expected next cursor %s, got %s", next.String(), resp.NextCursor)
+	}
+}
+
+func TestHueGetHandler_InvalidCursor(t *testing.T) {
+	token, _ := domain.NewLoginSessionToken()
+	session, _ := domain.NewSessionData(uuid.New(), token)
+	handler := withTestAuth(NewHueGetHandler(&fakeHueGetService{}))
+	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
+		Session: api.NewSessionPayload(session),
+		Cursor:  "not-a-cursor",
+	})))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+
+	if !strings.Contains(res.Body.String(), "cursor") {
+		t.Fatalf("expected cursor field in error: %s", res.Body.String())
+	}
+}
+
+func TestHueGetHandler_Filter(t *testing.T) {
+	token, _ := domain.NewLoginSessionToken()
+	session, _ := domain.NewSessionData(uuid.New(), token)
+	svc := &fakeHueGetService{}
+	handler := withTestAuth(NewHueGetHandler(svc))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
+		Session: api.NewSessionPayload(session),
+		Filter: &api.RecordFilterPayload{
+			From:         "2026-04-01",
+			NameContains: "Test",
+			Choices:      map[string]string{"夜": "赤"},
+			MinWords:     2,
+		},
+	})))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	filter := svc.query.Filter()
+	if name, _ := filter.NameContains(); name != "Test" || filter.MinWords() != 2 || filter.Choices()["夜"] != "赤" {
+		t.Fatalf("unexpected filter passed to service")
+	}
+	if _, ok := filter.Window().From(); !ok {
+		t.Fatalf("expected from to be set")
+	}
+}
+
+func TestHueGetHandler_InvalidFilter(t *testing.T) {
+	token, _ := domain.NewLoginSessionToken()
+	session, _ := domain.NewSessionData(uuid.New(), token)
+	handler := withTestAuth(NewHueGetHandler(&fakeHueGetService{}))
+	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
+		Session: api.NewSessionPayload(session),
+		Filter:  &api.RecordFilterPayload{From: "yesterday"},
+	})))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+
+	if !strings.Contains(res.Body.String(), "filter") {
+		t.Fatalf("expected filter field in error: %s", res.Body.String())
+	}
 }
 
 func TestHueGetHandler_InvalidJSON(t *testing.T) {
-	handler := NewHueGetHandler(&fakeHueGetService{})
+	handler := withTestAuth(NewHueGetHandler(&fakeHueGetService{}))
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(`{"session":1}`))
 	res := httptest.NewRecorder()
 
@@ -156,7 +504,7 @@ func TestHueGetHandler_InvalidJSON(t *testing.T) {
 }
 
 func TestHueGetHandler_InvalidDomain(t *testing.T) {
-	handler := NewHueGetHandler(&fakeHueGetService{})
+	handler := withTestAuth(NewHueGetHandler(&fakeHueGetService{}))
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(`{"session":{"user_id":"bad","token":""},"data-range":[0,0]}`))
 	res := httptest.NewRecorder()
 
@@ -169,7 +517,7 @@ func TestHueGetHandler_InvalidDomain(t *testing.T) {
 
 func TestHueGetHandler_Unauthorized(t *testing.T) {
 	svc := &fakeHueGetService{err: domain.ErrExpiredToken}
-	handler := NewHueGetHandler(svc)
+	handler := withTestAuth(NewHueGetHandler(svc))
 	token, _ := domain.NewLoginSessionToken()
 	session, _ := domain.NewSessionData(uuid.New(), token)
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
@@ -187,7 +535,7 @@ func TestHueGetHandler_Unauthorized(t *testing.T) {
 
 func TestHueGetHandler_InternalError(t *testing.T) {
 	svc := &fakeHueGetService{err: errors.New("boom")}
-	handler := NewHueGetHandler(svc)
+	handler := withTestAuth(NewHueGetHandler(svc))
 	token, _ := domain.NewLoginSessionToken()
 	session, _ := domain.NewSessionData(uuid.New(), token)
 	req := httptest.NewRequest(http.MethodPost, "/api/hue/get", strings.NewReader(marshal(t, api.GetDataRequest{
@@ -204,7 +552,7 @@ func TestHueGetHandler_InternalError(t *testing.T) {
 }
 
 func TestHueGetHandler_MethodNotAllowed(t *testing.T) {
-	handler := NewHueGetHandler(&fakeHueGetService{})
+	handler := withTestAuth(NewHueGetHandler(&fakeHueGetService{}))
 	req := httptest.NewRequest(http.MethodGet, "/api/hue/get", nil)
 	res := httptest.NewRecorder()
 
@@ -215,32 +563,260 @@ func TestHueGetHandler_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestHueResultHandler_ServeHTTP_Success(t *testing.T) {
+	stored, err := domain.NewHueRecordResult(buildHueResult(t), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	record := buildHueRecord(t).WithResult(stored)
+
+	cases := []struct {
+		name       string
+		share      bool
+		wantChoice bool
+	}{
+		{name: "private", share: false, wantChoice: false},
+		{name: "shared", share: true, wantChoice: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeHueResultService{record: record.WithShareChoices(tc.share)}
+			handler := NewHueResultHandler(svc, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+record.ID().String(), nil)
+			req.SetPathValue("id", record.ID().String())
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+
+			if res.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", res.Code)
+			}
+			if svc.id != record.ID() {
+				t.Fatalf("expected lookup by %s, got %s", record.ID(), svc.id)
+			}
+
+			var body api.SharedResultResponse
+			if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Status != "succeeded" || body.Name != "Tester" || body.Message != "ok" || body.Hue == nil || body.Hue.G != 20 || body.Source != "fallback" {
+				t.Fatalf("unexpected response body: %+v", body)
+			}
+			if (body.Choice != nil) != tc.wantChoice {
+				t.Fatalf("unexpected choice visibility: %+v", body.Choice)
+			}
+		})
+	}
+}
+
+func TestHueResultHandler_Pending(t *testing.T) {
+	record := buildHueRecord(t)
+	handler := NewHueResultHandler(&fakeHueResultService{record: record, status: domain.HueJobStatusPending, failure: domain.HueJobFailureRateLimited}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+record.ID().String(), nil)
+	req.SetPathValue("id", record.ID().String())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", res.Code)
+	}
+	if res.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected Retry-After header")
+	}
+
+	var body api.SharedResultResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Status != "pending" || body.Failure != "rate_limited" || body.Hue != nil || body.Message != "" {
+		t.Fatalf("unexpected response body: %+v", body)
+	}
+}
+
+func TestHueResultHandler_InvalidID(t *testing.T) {
+	svc := &fakeHueResultService{}
+	handler := NewHueResultHandler(svc, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/bad", nil)
+	req.SetPathValue("id", "bad")
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
+
+func TestHueResultHandler_NotFound(t *testing.T) {
+	handler := NewHueResultHandler(&fakeHueResultService{err: domain.ErrHueRecordNotFound}, nil)
+	id := uuid.New().String()
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id, nil)
+	req.SetPathValue("id", id)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestHueSimilarHandler_ServeHTTP_Success(t *testing.T) {
+	stored, err := domain.NewHueRecordResult(buildHueResult(t), "fake", "v1", time.Second, time.Now())
+	if err != nil {
+		t.Fatalf("record result error: %v", err)
+	}
+	similar, err := domain.NewHueSimilarity(buildHueRecord(t), buildHueRecord(t).WithResult(stored))
+	if err != nil {
+		t.Fatalf("similarity error: %v", err)
+	}
+	svc := &fakeHueResultService{similar: []domain.HueSimilarity{similar}}
+	id := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+id.String()+"/similar?limit=3", nil)
+	req.SetPathValue("id", id.String())
+	res := httptest.NewRecorder()
+
+	NewHueSimilarHandler(svc).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if svc.query.RecordID() != id || svc.query.Limit() != 3 {
+		t.Fatalf("unexpected query: %+v", svc.query)
+	}
+
+	var body api.SimilarResultsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Results) != 1 || body.Results[0].Hue.R != 10 || body.Results[0].Similarity != 1 || body.Results[0].Matches != 1 {
+		t.Fatalf("unexpected results: %+v", body.Results)
+	}
+}
+
+func TestHueSimilarHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		id     string
+		query  string
+		err    error
+		status int
+	}{
+		{name: "invalid id", id: "not-a-uuid", status: http.StatusBadRequest},
+		{name: "invalid limit", id: uuid.NewString(), query: "?limit=abc", status: http.StatusBadRequest},
+		{name: "not found", id: uuid.NewString(), err: domain.ErrHueRecordNotFound, status: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/results/"+tc.id+"/similar"+tc.query, nil)
+			req.SetPathValue("id", tc.id)
+			res := httptest.NewRecorder()
+
+			NewHueSimilarHandler(&fakeHueResultService{err: tc.err}).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+		})
+	}
+}
+
+func TestHueResultHandler_MethodNotAllowed(t *testing.T) {
+	handler := NewHueResultHandler(&fakeHueResultService{}, nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/hue-are-you/results/x", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+}
+
+type fakeHueResultService struct {
+	record  domain.HueRecord
+	status  domain.HueJobStatus
+	failure domain.HueJobFailure
+	similar []domain.HueSimilarity
+	query   domain.HueSimilarityQuery
+	id      uuid.UUID
+	err     error
+}
+
+func (f *fakeHueResultService) GetSimilar(_ context.Context, query domain.HueSimilarityQuery) ([]domain.HueSimilarity, error) {
+	f.query = query
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.similar, nil
+}
+
+func (f *fakeHueResultService) GetStatus(ctx context.Context, id uuid.UUID) (domain.HueRecord, domain.HueResultStatus, error) {
+	if f.status != "" {
+		f.id = id
+		return f.record, domain.NewHueResultStatus(f.status, f.failure), f.err
+	}
+	record, err := f.GetResult(ctx, id)
+	return record, domain.NewHueResultStatus(domain.HueJobStatusSucceeded, domain.HueJobFailureNone), err
+}
+
+func (f *fakeHueResultService) GetResult(_ context.Context, id uuid.UUID) (domain.HueRecord, error) {
+	f.id = id
+	if f.err != nil {
+		return domain.HueRecord{}, f.err
+	}
+	return f.record, nil
+}
+
 type fakeHueSaveService struct {
-	record domain.HueRecord
-	result domain.HueResult
-	err    error
-	called bool
+	record    domain.HueRecord
+	principal domain.Principal
+	key       domain.IdempotencyKey
+	err       error
+	called    bool
+	bypassed  bool
+	owned     bool
 }
 
-func (f *fakeHueSaveService) SaveResult(_ context.Context, record domain.HueRecord) (domain.HueResult, error) {
+func (f *fakeHueSaveService) SaveResult(_ context.Context, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error) {
 	f.called = true
 	f.record = record
+	f.key = key
 	if f.err != nil {
-		return domain.HueResult{}, f.err
+		return domain.HueGenerationJob{}, f.err
 	}
-	return f.result, nil
+	return domain.NewHueGenerationJob(record.ID(), 3, time.Now())
+}
+
+func (f *fakeHueSaveService) SaveUserResult(ctx context.Context, principal domain.Principal, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error) {
+	f.owned = true
+	f.principal = principal
+	return f.SaveResult(ctx, record, key)
+}
+
+func (f *fakeHueSaveService) SaveResultWithoutCache(ctx context.Context, principal domain.Principal, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error) {
+	f.bypassed = true
+	f.principal = principal
+	job, err := f.SaveResult(ctx, record, key)
+	return job.WithBypassCache(true), err
 }
 
 type fakeHueGetService struct {
 	records []domain.HueRecord
+	next    *domain.RecordCursor
+	query   domain.HueRecordQuery
 	err     error
 }
 
-func (f *fakeHueGetService) GetData(_ context.Context, _ domain.SessionData, _ domain.RecordRange) ([]domain.HueRecord, error) {
+func (f *fakeHueGetService) GetData(_ context.Context, _ domain.Principal, query domain.HueRecordQuery) (domain.HueRecordPage, error) {
+	f.query = query
 	if f.err != nil {
-		return nil, f.err
+		return domain.HueRecordPage{}, f.err
 	}
-	return f.records, nil
+	return domain.NewHueRecordPage(f.records, f.next), nil
 }
 
 func marshal(t *testing.T, v interface{}) string {
@@ -258,7 +834,7 @@ func buildHueRecord(t *testing.T) domain.HueRecord {
 	if err != nil {
 		t.Fatalf("name error: %v", err)
 	}
-	choices, err := domain.NewHueChoices(map[string]string{"word": "赤"})
+	choices, err := domain.NewHueChoices(map[string]string{"夜": "赤"})
 	if err != nil {
 		t.Fatalf("choices error: %v", err)
 	}
@@ -279,7 +855,7 @@ func buildHueResult(t *testing.T) domain.HueResult {
 	if err != nil {
 		t.Fatalf("result error: %v", err)
 	}
-	return result
+	return result.WithSource(domain.HueResultSourceFallback)
 }
 
 func buildName(t *testing.T, value string) domain.Name {
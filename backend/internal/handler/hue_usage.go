@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+// HueUsageService は LLM の使用量と推定費用を集計するユースケース境界。
+type HueUsageService interface {
+	GetUsage(ctx context.Context, principal domain.Principal, window domain.TimeWindow) (domain.HueUsageReport, error)
+}
+
+// HueCacheStatsService は結果キャッシュの効き具合を取得するユースケース境界。
+type HueCacheStatsService interface {
+	GetCacheStats(ctx context.Context, principal domain.Principal) (domain.HueResultCacheStats, error)
+}
+
+// HueUsageHandler は GET /api/hue-are-you/usage を処理する。from, to は stats と同じ形式。管理者のみ。
+// セッションは "Authorization: Bearer <user_id>:<token>" で受け取る。
+type HueUsageHandler struct {
+	service HueUsageService
+}
+
+func NewHueUsageHandler(service HueUsageService) *HueUsageHandler {
+	return &HueUsageHandler{service: service}
+}
+
+func (h *HueUsageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	window, err := api.StatsQuery{From: query.Get("from"), To: query.Get("to")}.ToDomain()
+	if err != nil {
+		respondInvalidField(w, "from/to")
+		return
+	}
+
+	report, err := h.service.GetUsage(r.Context(), principal, window)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewUsageResponse(report))
+}
+
+// HueCacheStatsHandler は GET /api/hue-are-you/cache を処理する。管理者のみ。
+type HueCacheStatsHandler struct {
+	service HueCacheStatsService
+}
+
+func NewHueCacheStatsHandler(service HueCacheStatsService) *HueCacheStatsHandler {
+	return &HueCacheStatsHandler{service: service}
+}
+
+func (h *HueCacheStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	stats, err := h.service.GetCacheStats(r.Context(), principal)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewCacheStatsResponse(stats))
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+func TestHueUsageHandler_ServeHTTP_Success(t *testing.T) {
+	prices, err := domain.NewLLMPriceTable(map[string]domain.LLMPrice{"gpt-4.1": {InputPerMillion: 2, OutputPerMillion: 8}})
+	if err != nil {
+		t.Fatalf("price table error: %v", err)
+	}
+	priced, err := domain.NewLLMUsageTotal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), "gpt-4.1", 2, 500_000, 0)
+	if err != nil {
+		t.Fatalf("usage total error: %v", err)
+	}
+	unpriced, err := domain.NewLLMUsageTotal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), "other", 1, 10, 10)
+	if err != nil {
+		t.Fatalf("usage total error: %v", err)
+	}
+	svc := &fakeHueUsageService{report: domain.NewHueUsageReport(domain.TimeWindow{}, []domain.LLMUsageTotal{priced, unpriced}, prices, 3)}
+	handler := withTestAuth(NewHueUsageHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/usage?from=2025-01-01", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if from, ok := svc.window.From(); !ok || from.Day() != 1 {
+		t.Fatalf("expected from to be parsed, got %v", from)
+	}
+
+	var body api.UsageResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Days) != 1 || body.Days[0].Date != "2025-01-02" || body.Days[0].Requests != 3 || body.Days[0].EstimatedCostUSD != 1 {
+		t.Fatalf("unexpected days: %+v", body.Days)
+	}
+	if models := body.Days[0].Models; len(models) != 2 || models[0].EstimatedCostUSD == nil || models[1].EstimatedCostUSD != nil {
+		t.Fatalf("unexpected models: %+v", models)
+	}
+	if body.TotalCostUSD != 1 || body.DailyBudgetUSD == nil || *body.DailyBudgetUSD != 3 {
+		t.Fatalf("unexpected totals: %+v", body)
+	}
+}
+
+func TestHueUsageHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		auth   bool
+		err    error
+		status int
+	}{
+		{name: "method", method: http.MethodPost, url: "/api/hue-are-you/usage", auth: true, status: http.StatusMethodNotAllowed},
+		{name: "no session", method: http.MethodGet, url: "/api/hue-are-you/usage", status: http.StatusUnauthorized},
+		{name: "invalid window", method: http.MethodGet, url: "/api/hue-are-you/usage?from=yesterday", auth: true, status: http.StatusBadRequest},
+		{name: "not admin", method: http.MethodGet, url: "/api/hue-are-you/usage", auth: true, err: domain.ErrInvalidLoginSession, status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := withTestAuth(NewHueUsageHandler(&fakeHueUsageService{err: tc.err}))
+
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			}
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+		})
+	}
+}
+
+type fakeHueUsageService struct {
+	report     domain.HueUsageReport
+	cacheStats domain.HueResultCacheStats
+	window     domain.TimeWindow
+	err        error
+}
+
+func (f *fakeHueUsageService) GetUsage(_ context.Context, _ domain.Principal, window domain.TimeWindow) (domain.HueUsageReport, error) {
+	f.window = window
+	if f.err != nil {
+		return domain.HueUsageReport{}, f.err
+	}
+	return f.report, nil
+}
+
+func (f *fakeHueUsageService) GetCacheStats(_ context.Context, _ domain.Principal) (domain.HueResultCacheStats, error) {
+	if f.err != nil {
+		return domain.HueResultCacheStats{}, f.err
+	}
+	return f.cacheStats, nil
+}
+
+func TestHueCacheStatsHandler_ServeHTTP(t *testing.T) {
+	svc := &fakeHueUsageService{cacheStats: domain.HueResultCacheStats{MemoryHits: 3, StoreHits: 1, Misses: 4, MemoryEntries: 2, StoreEntries: 10}}
+	handler := withTestAuth(NewHueCacheStatsHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/hue-are-you/cache", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	var body api.CacheStatsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.MemoryHits != 3 || body.Misses != 4 || body.HitRatio != 0.5 || body.StoreEntries != 10 {
+		t.Fatalf("unexpected response body: %+v", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/hue-are-you/cache", nil)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without session, got %d", res.Code)
+	}
+}
@@ -12,17 +12,18 @@ import (
 
 // LoginService は認証処理を司るユースケース層の抽象インターフェース。
 type LoginService interface {
-	Login(ctx context.Context, credential domain.AdminCredential) (domain.SessionData, domain.UserRole, error)
+	Login(ctx context.Context, credential domain.AdminCredential, client domain.SessionClient) (domain.SessionData, domain.UserRole, error)
 }
 
 // LoginHandler は /api/login の HTTP リクエストを処理する。
 type LoginHandler struct {
 	service LoginService
+	cookies *SessionCookies
 }
 
-// NewLoginHandler はログイン用ハンドラを初期化する。
-func NewLoginHandler(service LoginService) *LoginHandler {
-	return &LoginHandler{service: service}
+// NewLoginHandler はログイン用ハンドラを初期化する。cookies を渡すとトークンを HttpOnly クッキーで返す。nil なら JSON で返す。
+func NewLoginHandler(service LoginService, cookies *SessionCookies) *LoginHandler {
+	return &LoginHandler{service: service, cookies: cookies}
 }
 
 // ServeHTTP は JSON リクエストをデコードし、ドメインに変換してサービスへ委譲する。
@@ -46,7 +47,7 @@ func (h *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, role, err := h.service.Login(r.Context(), credential)
+	session, role, err := h.service.Login(r.Context(), credential, sessionClient(r))
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidCredential) {
 			respondInvalidCredential(w, http.StatusUnauthorized)
@@ -56,7 +57,5 @@ func (h *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(api.NewLoginResponse(session, role))
+	h.cookies.respondSession(w, session, role)
 }
@@ -23,13 +23,14 @@ func TestLoginHandler_ServeHTTP_Success(t *testing.T) {
 	}
 
 	svc := &fakeLoginService{token: token, userID: uuid.New(), role: domain.UserRoleAdmin}
-	handler := NewLoginHandler(svc)
+	handler := NewLoginHandler(svc, nil)
 
 	name := "admin"
 	password := "secret"
 	body := fmt.Sprintf(`{"name":"%v","password":"%v"}`, name, password)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(body))
+	req.Header.Set("User-Agent", "hue-test/1.0")
 	res := httptest.NewRecorder()
 
 	handler.ServeHTTP(res, req)
@@ -38,6 +39,10 @@ func TestLoginHandler_ServeHTTP_Success(t *testing.T) {
 		t.Fatalf("expected status 200, got %d", res.Code)
 	}
 
+	if svc.client.UserAgent() != "hue-test/1.0" || svc.client.IPAddress() != "192.0.2.1" {
+		t.Fatalf("unexpected session client: %q %q", svc.client.UserAgent(), svc.client.IPAddress())
+	}
+
 	if contentType := res.Header().Get("Content-Type"); contentType != "application/json" {
 		t.Fatalf("expected Content-Type application/json, got %s", contentType)
 	}
@@ -74,7 +79,7 @@ func TestLoginHandler_ServeHTTP_Success(t *testing.T) {
 
 func TestLoginHandler_ServeHTTP_InvalidJSON(t *testing.T) {
 	svc := &fakeLoginService{}
-	handler := NewLoginHandler(svc)
+	handler := NewLoginHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"name":1}`))
 	res := httptest.NewRecorder()
@@ -92,7 +97,7 @@ func TestLoginHandler_ServeHTTP_InvalidJSON(t *testing.T) {
 
 func TestLoginHandler_ServeHTTP_InvalidDomainInput(t *testing.T) {
 	svc := &fakeLoginService{}
-	handler := NewLoginHandler(svc)
+	handler := NewLoginHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"name":" ","hashed_password":"secret"}`))
 	res := httptest.NewRecorder()
@@ -110,7 +115,7 @@ func TestLoginHandler_ServeHTTP_InvalidDomainInput(t *testing.T) {
 
 func TestLoginHandler_ServeHTTP_InvalidCredential(t *testing.T) {
 	svc := &fakeLoginService{err: domain.ErrInvalidCredential}
-	handler := NewLoginHandler(svc)
+	handler := NewLoginHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"name":"admin","password":"secret"}`))
 	res := httptest.NewRecorder()
@@ -124,7 +129,7 @@ func TestLoginHandler_ServeHTTP_InvalidCredential(t *testing.T) {
 
 func TestLoginHandler_ServeHTTP_InternalError(t *testing.T) {
 	svc := &fakeLoginService{err: errors.New("boom")}
-	handler := NewLoginHandler(svc)
+	handler := NewLoginHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"name":"admin","password":"secret"}`))
 	res := httptest.NewRecorder()
@@ -138,7 +143,7 @@ func TestLoginHandler_ServeHTTP_InternalError(t *testing.T) {
 
 func TestLoginHandler_ServeHTTP_MethodNotAllowed(t *testing.T) {
 	svc := &fakeLoginService{}
-	handler := NewLoginHandler(svc)
+	handler := NewLoginHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/login", nil)
 	res := httptest.NewRecorder()
@@ -161,11 +166,13 @@ type fakeLoginService struct {
 	role       domain.UserRole
 	err        error
 	called     bool
+	client     domain.SessionClient
 }
 
-func (f *fakeLoginService) Login(_ context.Context, credential domain.AdminCredential) (domain.SessionData, domain.UserRole, error) {
+func (f *fakeLoginService) Login(_ context.Context, credential domain.AdminCredential, client domain.SessionClient) (domain.SessionData, domain.UserRole, error) {
 	f.called = true
 	f.credential = credential
+	f.client = client
 	if f.err != nil {
 		return domain.SessionData{}, "", f.err
 	}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+// UserHueResultsService はログイン中のユーザー自身の回答を取得するユースケース境界。
+type UserHueResultsService interface {
+	GetUserResults(ctx context.Context, principal domain.Principal, query domain.HueRecordQuery) (domain.HueRecordPage, error)
+}
+
+// UserHueResultsHandler は GET /api/me/hue-results を処理する。cursor, limit は get-data と同じ意味。
+// セッションは "Authorization: Bearer <user_id>:<token>" で受け取る。ロールは問わない。
+type UserHueResultsHandler struct {
+	service UserHueResultsService
+}
+
+func NewUserHueResultsHandler(service UserHueResultsService) *UserHueResultsHandler {
+	return &UserHueResultsHandler{service: service}
+}
+
+func (h *UserHueResultsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	recordQuery, err := api.UserResultsQuery{Cursor: query.Get("cursor"), Limit: query.Get("limit")}.ToDomain()
+	if err != nil {
+		respondInvalidField(w, "cursor/limit")
+		return
+	}
+
+	page, err := h.service.GetUserResults(r.Context(), principal, recordQuery)
+	if err != nil {
+		handleHueServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(api.NewUserResultsResponse(page))
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+func TestUserHueResultsHandler_ServeHTTP_Success(t *testing.T) {
+	record := buildHueRecord(t)
+	next, err := domain.NewRecordCursor(record.CreatedAt(), record.ID())
+	if err != nil {
+		t.Fatalf("cursor error: %v", err)
+	}
+	svc := &fakeUserHueResultsService{page: domain.NewHueRecordPage([]domain.HueRecord{record}, &next)}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/hue-results?limit=1", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	withTestAuth(NewUserHueResultsHandler(svc)).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if svc.query.Limit() != 1 {
+		t.Fatalf("expected limit 1, got %d", svc.query.Limit())
+	}
+
+	var body api.UserResultsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Results) != 1 || body.Results[0].ID != record.ID().String() || body.Results[0].Name != record.Name().String() {
+		t.Fatalf("unexpected results: %+v", body.Results)
+	}
+	if body.NextCursor != next.String() {
+		t.Fatalf("expected next cursor %q, got %q", next.String(), body.NextCursor)
+	}
+}
+
+func TestUserHueResultsHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		auth   bool
+		err    error
+		status int
+	}{
+		{name: "method", method: http.MethodPost, url: "/api/me/hue-results", auth: true, status: http.StatusMethodNotAllowed},
+		{name: "no session", method: http.MethodGet, url: "/api/me/hue-results", status: http.StatusUnauthorized},
+		{name: "invalid cursor", method: http.MethodGet, url: "/api/me/hue-results?cursor=not*a*cursor", auth: true, status: http.StatusBadRequest},
+		{name: "invalid limit", method: http.MethodGet, url: "/api/me/hue-results?limit=-1", auth: true, status: http.StatusBadRequest},
+		{name: "expired", method: http.MethodGet, url: "/api/me/hue-results", auth: true, err: domain.ErrExpiredToken, status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			}
+			res := httptest.NewRecorder()
+
+			withTestAuth(NewUserHueResultsHandler(&fakeUserHueResultsService{err: tc.err})).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+		})
+	}
+}
+
+type fakeUserHueResultsService struct {
+	page  domain.HueRecordPage
+	query domain.HueRecordQuery
+	err   error
+}
+
+func (f *fakeUserHueResultsService) GetUserResults(_ context.Context, _ domain.Principal, query domain.HueRecordQuery) (domain.HueRecordPage, error) {
+	f.query = query
+	if f.err != nil {
+		return domain.HueRecordPage{}, f.err
+	}
+	return f.page, nil
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+// SessionService はログアウトとセッション管理のユースケース境界。
+// セッションは "Authorization: Bearer <user_id>:<token>" かセッションクッキーで受け取り、認証ミドルウェアが照合する。
+type SessionService interface {
+	Logout(ctx context.Context, principal domain.Principal) error
+	ListSessions(ctx context.Context, principal domain.Principal) ([]domain.LoginSession, error)
+	RevokeSession(ctx context.Context, principal domain.Principal, id uuid.UUID) error
+	RevokeAllSessions(ctx context.Context, principal domain.Principal) (int64, error)
+}
+
+// LogoutHandler は POST /api/logout で呼び出しに使ったセッションを失効させる。クッキーモードならクッキーも消す。
+type LogoutHandler struct {
+	service SessionService
+	cookies *SessionCookies
+}
+
+func NewLogoutHandler(service SessionService, cookies *SessionCookies) *LogoutHandler {
+	return &LogoutHandler{service: service, cookies: cookies}
+}
+
+func (h *LogoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	if err := h.service.Logout(r.Context(), principal); err != nil {
+		handleSessionServiceError(w, err)
+		return
+	}
+
+	if h.cookies != nil {
+		h.cookies.clear(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SessionsHandler は /api/sessions を処理する。
+// GET は自分の期限内のセッション一覧、DELETE は呼び出しに使ったものも含めた全セッションの失効 (すべての端末からログアウト)。
+type SessionsHandler struct {
+	service SessionService
+	cookies *SessionCookies
+}
+
+func NewSessionsHandler(service SessionService, cookies *SessionCookies) *SessionsHandler {
+	return &SessionsHandler{service: service, cookies: cookies}
+}
+
+func (h *SessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		respondMethodNotAllowed(w, http.MethodGet+", "+http.MethodDelete)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	var resp any
+	if r.Method == http.MethodDelete {
+		revoked, err := h.service.RevokeAllSessions(r.Context(), principal)
+		if err != nil {
+			handleSessionServiceError(w, err)
+			return
+		}
+		if h.cookies != nil {
+			h.cookies.clear(w)
+		}
+		resp = api.RevokeSessionsResponse{Revoked: revoked}
+	} else {
+		sessions, err := h.service.ListSessions(r.Context(), principal)
+		if err != nil {
+			handleSessionServiceError(w, err)
+			return
+		}
+		resp = api.NewSessionsResponse(sessions, principal.SessionID())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// SessionHandler は DELETE /api/sessions/{id} で自分のセッションを 1 つ失効させる。
+type SessionHandler struct {
+	service SessionService
+}
+
+func NewSessionHandler(service SessionService) *SessionHandler {
+	return &SessionHandler{service: service}
+}
+
+func (h *SessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondMethodNotAllowed(w, http.MethodDelete)
+		return
+	}
+
+	principal, err := requestPrincipal(r)
+	if err != nil {
+		respondAuthError(w, err)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondInvalidField(w, "id")
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), principal, id); err != nil {
+		handleSessionServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SessionRefreshService は refresh token でセッションを作り直すユースケース境界。
+type SessionRefreshService interface {
+	Refresh(ctx context.Context, token domain.LoginSessionToken, client domain.SessionClient) (domain.SessionData, domain.UserRole, error)
+}
+
+// SessionRefreshHandler は POST /api/session/refresh を処理する。
+// ボディの refresh_token を回転させ、ログインと同じ形で新しいセッションと refresh token を返す。
+// クッキーモードではボディを省略でき、X-CSRF-Token を添えれば refresh token のクッキーを使う。
+type SessionRefreshHandler struct {
+	service SessionRefreshService
+	cookies *SessionCookies
+}
+
+func NewSessionRefreshHandler(service SessionRefreshService, cookies *SessionCookies) *SessionRefreshHandler {
+	return &SessionRefreshHandler{service: service, cookies: cookies}
+}
+
+func (h *SessionRefreshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req api.RefreshSessionRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondInvalidJSON(w)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		cookie, ok := h.cookies.refreshTokenCookie(r)
+		if !ok {
+			respondUnauthorizedSession(w)
+			return
+		}
+		if !validCSRF(r) {
+			respondCSRFRejected(w)
+			return
+		}
+		req.RefreshToken = cookie
+	}
+
+	token, err := req.ToDomain()
+	if err != nil {
+		respondUnauthorizedSession(w)
+		return
+	}
+
+	session, role, err := h.service.Refresh(r.Context(), token, sessionClient(r))
+	if err != nil {
+		handleSessionServiceError(w, err)
+		return
+	}
+
+	h.cookies.respondSession(w, session, role)
+}
+
+func handleSessionServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrLoginSessionNotFound):
+		respondNotFound(w, "session")
+	case errors.Is(err, domain.ErrInvalidSessionToken),
+		errors.Is(err, domain.ErrInvalidLoginSession),
+		errors.Is(err, domain.ErrExpiredToken),
+		errors.Is(err, domain.ErrInvalidRefreshToken),
+		errors.Is(err, domain.ErrRefreshTokenReused):
+		respondUnauthorizedSession(w)
+	default:
+		respondInternalServerError(w)
+	}
+}
+
+// sessionClient はセッション一覧に出す端末情報をリクエストから読む。
+// IP アドレスは直接の接続元で、プロキシの X-Forwarded-For は信用しない。
+func sessionClient(r *http.Request) domain.SessionClient {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return domain.NewSessionClient(r.UserAgent(), host)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+)
+
+const (
+	sessionCookieName = "hue_session"
+	refreshCookieName = "hue_refresh"
+	csrfCookieName    = "hue_csrf"
+	// CSRFHeader はクッキーで認証する状態変更リクエストに、csrf_token と同じ値を載せるヘッダ。
+	CSRFHeader = "X-CSRF-Token"
+	// refreshCookiePath の外には refresh token のクッキーを送らせない。
+	refreshCookiePath   = "/api/session/refresh"
+	csrfTokenByteLength = 32
+)
+
+// SessionCookieConfig はクッキーでセッションを渡すモードの設定。
+type SessionCookieConfig struct {
+	// Secure はローカルの http で試すときだけ false にする。
+	Secure bool
+	// SameSite は既定で Lax。フロントエンドとAPIが別サイトなら None (Secure 必須) にする。
+	SameSite http.SameSite
+	// Domain はフロントエンドとAPIがサブドメインで分かれているときに共通の親ドメインを指定する。
+	Domain string
+}
+
+// SessionCookies はセッションを HttpOnly クッキーで渡すモード。nil なら従来どおり JSON でトークンを返す。
+// CSRF は double-submit で防ぐ。csrf_token をクッキーとレスポンスの両方で渡し、
+// クッキーで認証する GET 以外のリクエストでは X-CSRF-Token ヘッダに同じ値を求める。
+type SessionCookies struct {
+	cfg SessionCookieConfig
+}
+
+func NewSessionCookies(cfg SessionCookieConfig) *SessionCookies {
+	if cfg.SameSite == 0 {
+		cfg.SameSite = http.SameSiteLaxMode
+	}
+	return &SessionCookies{cfg: cfg}
+}
+
+// issue はセッションと refresh token のクッキー、新しい CSRF トークンを書き、CSRF トークンを返す。
+func (c *SessionCookies) issue(w http.ResponseWriter, session domain.SessionData) (string, error) {
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, c.cookie(sessionCookieName, api.NewSessionPayload(session).BearerCredential(), "/", domain.MaxLoginSessionLifetime, true))
+	if refreshToken, ok := session.RefreshToken(); ok {
+		http.SetCookie(w, c.cookie(refreshCookieName, refreshToken.String(), refreshCookiePath, domain.DefaultRefreshTokenTTL, true))
+	}
+	// JavaScript から読めるよう HttpOnly にしない。値はレスポンスの csrf_token でも渡す。
+	http.SetCookie(w, c.cookie(csrfCookieName, csrf, "/", domain.DefaultRefreshTokenTTL, false))
+	return csrf, nil
+}
+
+// clear はクッキーをすべて消す。
+func (c *SessionCookies) clear(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(sessionCookieName, "", "/", -1, true))
+	http.SetCookie(w, c.cookie(refreshCookieName, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, c.cookie(csrfCookieName, "", "/", -1, false))
+}
+
+func (c *SessionCookies) cookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.cfg.Domain,
+		Secure:   c.cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.cfg.SameSite,
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	return cookie
+}
+
+// respondSession はログイン・サインイン・更新の結果を返す。
+// クッキーモードではトークンをクッキーにだけ載せ、JSON には user_id, role と csrf_token を入れる。
+func (c *SessionCookies) respondSession(w http.ResponseWriter, session domain.SessionData, role domain.UserRole) {
+	resp := api.NewLoginResponse(session, role)
+	if c != nil {
+		csrf, err := c.issue(w, session)
+		if err != nil {
+			respondInternalServerError(w)
+			return
+		}
+		resp = api.NewCookieLoginResponse(session, role, csrf)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// refreshTokenCookie はクッキーモードで refresh token のクッキーを読む。
+func (c *SessionCookies) refreshTokenCookie(r *http.Request) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenByteLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type sessionCredentialKey struct{}
+
+// sessionCredential はミドルウェアがリクエストから読んだセッション。err は読めなかったか CSRF の検証に失敗したとき。
+type sessionCredential struct {
+	session domain.SessionData
+	err     error
+}
+
+// WithSessionCredentials は Authorization: Bearer ヘッダ、無ければセッションクッキーからセッションを読み、
+// ハンドラが requestSession で取り出せるようにする。ヘッダがあればクッキーより優先する。
+// クッキーで認証する GET, HEAD, OPTIONS 以外のリクエストは、X-CSRF-Token がクッキーの csrf と一致しなければ
+// ErrInvalidCSRFToken になる。認証しない API には影響しないよう、拒否はセッションを読むハンドラに任せる。
+// cookies が nil ならクッキーは読まない。
+func WithSessionCredentials(next http.Handler, cookies *SessionCookies) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var credential *sessionCredential
+		if header := r.Header.Get("Authorization"); header != "" {
+			session, err := api.ParseBearerSession(header)
+			credential = &sessionCredential{session: session, err: err}
+		} else if cookies != nil {
+			if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+				session, err := api.ParseBearerSession("Bearer " + cookie.Value)
+				if err == nil && !safeMethod(r.Method) && !validCSRF(r) {
+					err = domain.ErrInvalidCSRFToken
+				}
+				credential = &sessionCredential{session: session, err: err}
+			}
+		}
+
+		if credential != nil {
+			r = r.WithContext(context.WithValue(r.Context(), sessionCredentialKey{}, *credential))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestSession はリクエストのセッションを返す。WithSessionCredentials を通っていなければ
+// Authorization ヘッダだけを読む。セッションが無ければ ErrInvalidSessionData。
+func requestSession(r *http.Request) (domain.SessionData, error) {
+	if credential, ok := r.Context().Value(sessionCredentialKey{}).(sessionCredential); ok {
+		return credential.session, credential.err
+	}
+	return api.ParseBearerSession(r.Header.Get("Authorization"))
+}
+
+// hasRequestSession はヘッダかクッキーでセッションが送られているかを返す。匿名でも使える API で使う。
+func hasRequestSession(r *http.Request) bool {
+	if _, ok := r.Context().Value(sessionCredentialKey{}).(sessionCredential); ok {
+		return true
+	}
+	return r.Header.Get("Authorization") != ""
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func validCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(csrfCookieName)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) == 1
+}
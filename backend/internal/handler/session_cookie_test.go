@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+func TestLoginHandler_CookieMode(t *testing.T) {
+	token, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	svc := &fakeLoginService{token: token, userID: uuid.New()}
+	handler := NewLoginHandler(svc, NewSessionCookies(SessionCookieConfig{Secure: true}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"name":"admin","password":"secret"}`))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.Code)
+	}
+
+	var resp api.LoginResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Token != "" || resp.UserID != svc.userID.String() || resp.CSRFToken == "" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	cookies := map[string]*http.Cookie{}
+	for _, c := range res.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	session := cookies[sessionCookieName]
+	if session == nil || !session.HttpOnly || !session.Secure || session.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected session cookie: %+v", session)
+	}
+	if session.Value != svc.userID.String()+":"+token.String() {
+		t.Fatalf("unexpected session cookie value %q", session.Value)
+	}
+	csrf := cookies[csrfCookieName]
+	if csrf == nil || csrf.HttpOnly || csrf.Value != resp.CSRFToken {
+		t.Fatalf("unexpected csrf cookie: %+v", csrf)
+	}
+}
+
+func TestWithSessionCredentials(t *testing.T) {
+	cookieSession := buildSessionData(t)
+	headerSession := buildSessionData(t)
+	cookieValue := api.NewSessionPayload(cookieSession).BearerCredential()
+
+	cases := []struct {
+		name   string
+		method string
+		header bool
+		csrf   string
+		want   domain.SessionData
+		status int
+	}{
+		{name: "get without csrf", method: http.MethodGet, want: cookieSession, status: http.StatusOK},
+		{name: "post with csrf", method: http.MethodPost, csrf: "csrf-value", want: cookieSession, status: http.StatusOK},
+		{name: "post without csrf", method: http.MethodPost, status: http.StatusForbidden},
+		{name: "post with wrong csrf", method: http.MethodPost, csrf: "other", status: http.StatusForbidden},
+		{name: "bearer wins", method: http.MethodPost, header: true, want: headerSession, status: http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got domain.SessionData
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				session, err := requestSession(r)
+				if err != nil {
+					respondAuthError(w, err)
+					return
+				}
+				got = session
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tc.method, "/api/sessions", nil)
+			req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: cookieValue})
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "csrf-value"})
+			if tc.csrf != "" {
+				req.Header.Set(CSRFHeader, tc.csrf)
+			}
+			if tc.header {
+				req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(headerSession).BearerCredential())
+			}
+			res := httptest.NewRecorder()
+
+			WithSessionCredentials(next, NewSessionCookies(SessionCookieConfig{})).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+			if tc.status == http.StatusOK && (got.UserID() != tc.want.UserID() || got.Token().String() != tc.want.Token().String()) {
+				t.Fatalf("unexpected session for user %s", got.UserID())
+			}
+		})
+	}
+}
+
+func TestWithSessionCredentials_CookiesDisabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := requestSession(r); err != nil {
+			respondAuthError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: api.NewSessionPayload(buildSessionData(t)).BearerCredential()})
+	res := httptest.NewRecorder()
+
+	WithSessionCredentials(next, nil).ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.Code)
+	}
+}
+
+func TestSessionRefreshHandler_CookieMode(t *testing.T) {
+	refreshToken, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	next, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	cookies := NewSessionCookies(SessionCookieConfig{})
+
+	cases := []struct {
+		name   string
+		csrf   string
+		status int
+	}{
+		{name: "success", csrf: "csrf-value", status: http.StatusOK},
+		{name: "missing csrf", status: http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeSessionRefreshService{session: buildSessionData(t).WithRefreshToken(next), role: domain.UserRoleAdmin}
+			req := httptest.NewRequest(http.MethodPost, "/api/session/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: refreshCookieName, Value: refreshToken.String()})
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "csrf-value"})
+			if tc.csrf != "" {
+				req.Header.Set(CSRFHeader, tc.csrf)
+			}
+			res := httptest.NewRecorder()
+
+			NewSessionRefreshHandler(svc, cookies).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			if svc.token.String() != refreshToken.String() {
+				t.Fatalf("expected refresh token from cookie, got %q", svc.token.String())
+			}
+			var resp api.LoginResponse
+			if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Token != "" || resp.RefreshToken != "" || resp.CSRFToken == "" {
+				t.Fatalf("expected tokens only in cookies, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestLogoutHandler_ClearsCookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	withTestAuth(NewLogoutHandler(&fakeSessionService{}, NewSessionCookies(SessionCookieConfig{}))).ServeHTTP(res, req)
+
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", res.Code)
+	}
+	cleared := 0
+	for _, c := range res.Result().Cookies() {
+		if c.MaxAge < 0 {
+			cleared++
+		}
+	}
+	if cleared != 3 {
+		t.Fatalf("expected 3 cleared cookies, got %d", cleared)
+	}
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+func TestLogoutHandler_ServeHTTP(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		auth   bool
+		err    error
+		status int
+	}{
+		{name: "success", method: http.MethodPost, auth: true, status: http.StatusNoContent},
+		{name: "method", method: http.MethodGet, auth: true, status: http.StatusMethodNotAllowed},
+		{name: "no session", method: http.MethodPost, status: http.StatusUnauthorized},
+		{name: "expired", method: http.MethodPost, auth: true, err: domain.ErrExpiredToken, status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeSessionService{err: tc.err}
+			req := httptest.NewRequest(tc.method, "/api/logout", nil)
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			}
+			res := httptest.NewRecorder()
+
+			withTestAuth(NewLogoutHandler(svc, nil)).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+			if tc.status == http.StatusNoContent && !svc.loggedOut {
+				t.Fatalf("expected Logout to be called")
+			}
+		})
+	}
+}
+
+func TestSessionsHandler_List(t *testing.T) {
+	current := buildLoginSession(t, "Mozilla/5.0", "203.0.113.7")
+	used := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	current = current.WithLastUsedAt(used)
+	other := buildLoginSession(t, "curl/8.0", "")
+	svc := &fakeSessionService{sessions: []domain.LoginSession{current, other}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	WithAuthentication(NewSessionsHandler(svc, nil), &fakeAuthenticator{sessionID: current.ID()}).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	var body api.SessionsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(body.Sessions))
+	}
+	first, second := body.Sessions[0], body.Sessions[1]
+	if first.ID != current.ID().String() || !first.Current || first.UserAgent != "Mozilla/5.0" || first.IPAddress != "203.0.113.7" {
+		t.Fatalf("unexpected current session: %+v", first)
+	}
+	if first.LastUsedAt == nil || !first.LastUsedAt.Equal(used) {
+		t.Fatalf("expected last_used_at %v, got %v", used, first.LastUsedAt)
+	}
+	if second.Current || second.LastUsedAt != nil || second.IPAddress != "" {
+		t.Fatalf("unexpected other session: %+v", second)
+	}
+}
+
+func TestSessionsHandler_RevokeAll(t *testing.T) {
+	svc := &fakeSessionService{revoked: 3}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+	res := httptest.NewRecorder()
+
+	withTestAuth(NewSessionsHandler(svc, nil)).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	var body api.RevokeSessionsResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Revoked != 3 {
+		t.Fatalf("expected 3 revoked, got %d", body.Revoked)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/sessions", nil)
+	res = httptest.NewRecorder()
+	NewSessionsHandler(svc, nil).ServeHTTP(res, req)
+	if res.Code != http.StatusMethodNotAllowed || res.Header().Get("Allow") != "GET, DELETE" {
+		t.Fatalf("expected 405 with Allow GET, DELETE, got %d %q", res.Code, res.Header().Get("Allow"))
+	}
+}
+
+func TestSessionHandler_Revoke(t *testing.T) {
+	id := uuid.New()
+	cases := []struct {
+		name   string
+		id     string
+		err    error
+		status int
+	}{
+		{name: "success", id: id.String(), status: http.StatusNoContent},
+		{name: "invalid id", id: "not-a-uuid", status: http.StatusBadRequest},
+		{name: "not found", id: id.String(), err: domain.ErrLoginSessionNotFound, status: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeSessionService{err: tc.err}
+			req := httptest.NewRequest(http.MethodDelete, "/api/sessions/"+tc.id, nil)
+			req.SetPathValue("id", tc.id)
+			req.Header.Set("Authorization", "Bearer "+api.NewSessionPayload(buildSessionData(t)).BearerCredential())
+			res := httptest.NewRecorder()
+
+			withTestAuth(NewSessionHandler(svc)).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+			if tc.status == http.StatusNoContent && svc.revokedID != id {
+				t.Fatalf("expected %s to be revoked, got %s", id, svc.revokedID)
+			}
+		})
+	}
+}
+
+func buildLoginSession(t *testing.T, userAgent, ip string) domain.LoginSession {
+	t.Helper()
+	hashed, err := domain.ParseHashedLoginSessionToken("selector", "digest")
+	if err != nil {
+		t.Fatalf("hashed token error: %v", err)
+	}
+	session, err := domain.NewLoginSession(uuid.New(), hashed, time.Now())
+	if err != nil {
+		t.Fatalf("session error: %v", err)
+	}
+	return session.WithClient(domain.NewSessionClient(userAgent, ip))
+}
+
+type fakeSessionService struct {
+	sessions  []domain.LoginSession
+	revoked   int64
+	err       error
+	loggedOut bool
+	revokedID uuid.UUID
+}
+
+func (f *fakeSessionService) Logout(_ context.Context, _ domain.Principal) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.loggedOut = true
+	return nil
+}
+
+func (f *fakeSessionService) ListSessions(_ context.Context, _ domain.Principal) ([]domain.LoginSession, error) {
+	return f.sessions, f.err
+}
+
+func (f *fakeSessionService) RevokeSession(_ context.Context, _ domain.Principal, id uuid.UUID) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.revokedID = id
+	return nil
+}
+
+func (f *fakeSessionService) RevokeAllSessions(_ context.Context, _ domain.Principal) (int64, error) {
+	return f.revoked, f.err
+}
+
+func TestSessionRefreshHandler_ServeHTTP(t *testing.T) {
+	refreshToken, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	issued := buildSessionData(t)
+	next, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	svc := &fakeSessionRefreshService{session: issued.WithRefreshToken(next), role: domain.UserRoleAdmin}
+
+	body := `{"refresh_token":"` + refreshToken.String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/session/refresh", strings.NewReader(body))
+	res := httptest.NewRecorder()
+
+	NewSessionRefreshHandler(svc, nil).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if svc.token.String() != refreshToken.String() {
+		t.Fatalf("expected refresh token to be passed through, got %q", svc.token.String())
+	}
+	var resp api.LoginResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Token != issued.Token().String() || resp.RefreshToken != next.String() || resp.Role != domain.UserRoleAdmin.String() {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestSessionRefreshHandler_Errors(t *testing.T) {
+	valid, err := domain.NewLoginSessionToken()
+	if err != nil {
+		t.Fatalf("token error: %v", err)
+	}
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "method", method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "invalid json", method: http.MethodPost, body: `{`, status: http.StatusBadRequest},
+		{name: "malformed token", method: http.MethodPost, body: `{"refresh_token":"nope"}`, status: http.StatusUnauthorized},
+		{name: "reused", method: http.MethodPost, body: `{"refresh_token":"` + valid.String() + `"}`, err: domain.ErrRefreshTokenReused, status: http.StatusUnauthorized},
+		{name: "expired", method: http.MethodPost, body: `{"refresh_token":"` + valid.String() + `"}`, err: domain.ErrExpiredToken, status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/session/refresh", strings.NewReader(tc.body))
+			res := httptest.NewRecorder()
+
+			NewSessionRefreshHandler(&fakeSessionRefreshService{err: tc.err}, nil).ServeHTTP(res, req)
+
+			if res.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, res.Code)
+			}
+		})
+	}
+}
+
+type fakeSessionRefreshService struct {
+	session domain.SessionData
+	role    domain.UserRole
+	err     error
+	token   domain.LoginSessionToken
+}
+
+func (f *fakeSessionRefreshService) Refresh(_ context.Context, token domain.LoginSessionToken, _ domain.SessionClient) (domain.SessionData, domain.UserRole, error) {
+	f.token = token
+	return f.session, f.role, f.err
+}
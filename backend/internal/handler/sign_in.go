@@ -12,16 +12,18 @@ import (
 
 // SignInService はサインイン処理を司るユースケース層の抽象インターフェース。
 type SignInService interface {
-	SignIn(ctx context.Context, credential domain.SignInCredential) (domain.SessionData, domain.UserRole, error)
+	SignIn(ctx context.Context, credential domain.SignInCredential, client domain.SessionClient) (domain.SessionData, domain.UserRole, error)
 }
 
 // SignInHandler は /api/sign-in の HTTP リクエストを処理する。
 type SignInHandler struct {
 	service SignInService
+	cookies *SessionCookies
 }
 
-func NewSignInHandler(service SignInService) *SignInHandler {
-	return &SignInHandler{service: service}
+// cookies を渡すとトークンを HttpOnly クッキーで返す。nil なら JSON で返す。
+func NewSignInHandler(service SignInService, cookies *SessionCookies) *SignInHandler {
+	return &SignInHandler{service: service, cookies: cookies}
 }
 
 func (h *SignInHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -44,7 +46,7 @@ func (h *SignInHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, role, err := h.service.SignIn(r.Context(), credential)
+	session, role, err := h.service.SignIn(r.Context(), credential, sessionClient(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrDuplicateUsername):
@@ -59,7 +61,5 @@ func (h *SignInHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(api.NewSignInResponse(session, role))
+	h.cookies.respondSession(w, session, role)
 }
@@ -26,7 +26,7 @@ func TestSignInHandler_ServeHTTP_Success(t *testing.T) {
 	}
 
 	svc := &fakeSignInService{session: session}
-	handler := NewSignInHandler(svc)
+	handler := NewSignInHandler(svc, nil)
 
 	body := `{"name":"alice","email":"alice@example.com","password":"secret"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/sign-in", strings.NewReader(body))
@@ -62,7 +62,7 @@ func TestSignInHandler_ServeHTTP_Success(t *testing.T) {
 
 func TestSignInHandler_InvalidJSON(t *testing.T) {
 	svc := &fakeSignInService{}
-	handler := NewSignInHandler(svc)
+	handler := NewSignInHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/sign-in", strings.NewReader(`{"name":1}`))
 	res := httptest.NewRecorder()
@@ -80,7 +80,7 @@ func TestSignInHandler_InvalidJSON(t *testing.T) {
 
 func TestSignInHandler_InvalidDomain(t *testing.T) {
 	svc := &fakeSignInService{}
-	handler := NewSignInHandler(svc)
+	handler := NewSignInHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/sign-in", strings.NewReader(`{"name":" ","email":"bad","password":"secret"}`))
 	res := httptest.NewRecorder()
@@ -98,7 +98,7 @@ func TestSignInHandler_InvalidDomain(t *testing.T) {
 
 func TestSignInHandler_Conflict(t *testing.T) {
 	svc := &fakeSignInService{err: domain.ErrInvalidCredential}
-	handler := NewSignInHandler(svc)
+	handler := NewSignInHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/sign-in", strings.NewReader(`{"name":"alice","email":"alice@example.com","password":"secret"}`))
 	res := httptest.NewRecorder()
@@ -112,7 +112,7 @@ func TestSignInHandler_Conflict(t *testing.T) {
 
 func TestSignInHandler_InternalError(t *testing.T) {
 	svc := &fakeSignInService{err: errors.New("boom")}
-	handler := NewSignInHandler(svc)
+	handler := NewSignInHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/sign-in", strings.NewReader(`{"name":"alice","email":"alice@example.com","password":"secret"}`))
 	res := httptest.NewRecorder()
@@ -126,7 +126,7 @@ func TestSignInHandler_InternalError(t *testing.T) {
 
 func TestSignInHandler_MethodNotAllowed(t *testing.T) {
 	svc := &fakeSignInService{}
-	handler := NewSignInHandler(svc)
+	handler := NewSignInHandler(svc, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/sign-in", nil)
 	res := httptest.NewRecorder()
@@ -149,7 +149,7 @@ type fakeSignInService struct {
 	called  bool
 }
 
-func (f *fakeSignInService) SignIn(_ context.Context, _ domain.SignInCredential) (domain.SessionData, domain.UserRole, error) {
+func (f *fakeSignInService) SignIn(_ context.Context, _ domain.SignInCredential, _ domain.SessionClient) (domain.SessionData, domain.UserRole, error) {
 	f.called = true
 	if f.err != nil {
 		return domain.SessionData{}, "", f.err
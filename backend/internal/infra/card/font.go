@@ -0,0 +1,128 @@
+package card
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sync"
+	"unicode"
+)
+
+// 形式は font/README.md を参照。
+//
+//go:embed font/bitmap12.bin.gz
+var fontData []byte
+
+const (
+	fontMagic  = "HUEFONT1"
+	fontRunes  = 0x10000
+	headerSize = len(fontMagic) + 3
+)
+
+// bitmapFace は埋め込みフォントを展開したもの。読み取り専用なので並行に使ってよい。
+type bitmapFace struct {
+	cellWidth  int
+	cellHeight int
+	ascent     int
+	wide       []byte
+	rows       []uint16
+}
+
+// loadFace は初回だけ埋め込みフォントを展開する。
+var loadFace = sync.OnceValues(func() (*bitmapFace, error) {
+	return parseFace(fontData)
+})
+
+func parseFace(data []byte) (*bitmapFace, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("open font: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read font: %w", err)
+	}
+	if len(raw) < headerSize || string(raw[:len(fontMagic)]) != fontMagic {
+		return nil, errors.New("read font: unknown format")
+	}
+
+	f := &bitmapFace{
+		cellWidth:  int(raw[len(fontMagic)]),
+		cellHeight: int(raw[len(fontMagic)+1]),
+		ascent:     int(raw[len(fontMagic)+2]),
+	}
+	body := raw[headerSize:]
+	if len(body) != fontRunes/8+fontRunes*f.cellHeight*2 || f.cellWidth > 16 {
+		return nil, errors.New("read font: unexpected size")
+	}
+	f.wide = body[:fontRunes/8]
+	f.rows = make([]uint16, fontRunes*f.cellHeight)
+	if err := binary.Read(bytes.NewReader(body[fontRunes/8:]), binary.BigEndian, f.rows); err != nil {
+		return nil, fmt.Errorf("read font: %w", err)
+	}
+	return f, nil
+}
+
+// glyph は r のグリフ番号を返す。BMP 外は収録していないので代替文字にする。
+func (f *bitmapFace) glyph(r rune) int {
+	if r < 0 || r >= fontRunes {
+		return unicode.ReplacementChar
+	}
+	return int(r)
+}
+
+// glyphWidth は r のグリフの幅。
+func (f *bitmapFace) glyphWidth(r rune) int {
+	g := f.glyph(r)
+	if f.wide[g/8]&(0x80>>(g%8)) != 0 {
+		return f.cellWidth
+	}
+	return f.cellWidth / 2
+}
+
+// advance は r を描いたあとにペンを進める幅。結合文字は直前の文字に重ねるので進めない。
+func (f *bitmapFace) advance(r rune) int {
+	if unicode.Is(unicode.Mn, r) {
+		return 0
+	}
+	return f.glyphWidth(r)
+}
+
+func (f *bitmapFace) measure(text string) int {
+	width := 0
+	for _, r := range text {
+		width += f.advance(r)
+	}
+	return width
+}
+
+// draw は (x, y) をベースラインの左端として text を描く。
+func (f *bitmapFace) draw(dst *image.RGBA, text string, x, y int, c color.RGBA) {
+	for _, r := range text {
+		adv := f.advance(r)
+		left := x
+		if adv == 0 {
+			left -= f.glyphWidth(r)
+		}
+		g := f.glyph(r)
+		top := y - f.ascent
+		for row := 0; row < f.cellHeight; row++ {
+			bits := f.rows[g*f.cellHeight+row]
+			for col := 0; bits != 0; col++ {
+				if bits&0x8000 != 0 {
+					if p := image.Pt(left+col, top+row); p.In(dst.Bounds()) {
+						dst.SetRGBA(p.X, p.Y, c)
+					}
+				}
+				bits <<= 1
+			}
+		}
+		x += adv
+	}
+}
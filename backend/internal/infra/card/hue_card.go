@@ -0,0 +1,163 @@
+// Package card は Hue Are You の結果共有用カード画像 (PNG) を描画する。
+package card
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+
+	"backend/internal/domain"
+)
+
+// 1x で描いてから scale 倍に拡大し、OGP 推奨の 1200x630 にする。
+const (
+	baseWidth  = 600
+	baseHeight = 315
+	scale      = 2
+
+	lineHeight      = 16
+	maxMessageLines = 7
+	maxPairs        = 4
+)
+
+var (
+	textColor  = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	mutedColor = color.RGBA{R: 0x77, G: 0x77, B: 0x77, A: 0xff}
+)
+
+// Pair はカード下部に並べる語彙と色の組。
+type Pair struct {
+	Word  string
+	Color domain.HueColor
+}
+
+// HueCard はカードに載せる内容。
+type HueCard struct {
+	Name    string
+	Hue     domain.HueRGB
+	Message string
+	Pairs   []Pair
+}
+
+// Size は出力画像の大きさを返す。
+func Size() (width, height int) {
+	return baseWidth * scale, baseHeight * scale
+}
+
+// RenderPNG はカードを描画して PNG で w に書き出す。
+func RenderPNG(w io.Writer, c HueCard) error {
+	face, err := loadFace()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, upscale(render(face, c), scale))
+}
+
+func render(face *bitmapFace, c HueCard) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, baseWidth, baseHeight))
+	hue := rgba(c.Hue)
+
+	fill(img, img.Bounds(), tint(hue, 0.88))
+	fill(img, image.Rect(0, 0, baseWidth, 6), hue)
+
+	// 左: 結果の色見本
+	swatch := image.Rect(32, 48, 232, 248)
+	fill(img, swatch.Inset(-2), textColor)
+	fill(img, swatch, hue)
+	face.draw(img, fmt.Sprintf("#%02X%02X%02X", c.Hue.R(), c.Hue.G(), c.Hue.B()), 32, 270, mutedColor)
+
+	// 右: 名前とメッセージ
+	const textX, textWidth = 256, baseWidth - 256 - 28
+	face.draw(img, "Hue Are You", textX, 40, mutedColor)
+	face.draw(img, truncate(face, fmt.Sprintf("%s さんの色", c.Name), textWidth), textX, 64, textColor)
+
+	lines := wrap(face, c.Message, textWidth)
+	if len(lines) > maxMessageLines {
+		lines = lines[:maxMessageLines]
+		lines[maxMessageLines-1] = truncate(face, lines[maxMessageLines-1]+"……", textWidth)
+	}
+	for i, line := range lines {
+		face.draw(img, line, textX, 96+i*lineHeight, textColor)
+	}
+
+	// 下: 特徴的な回答
+	x := textX
+	for i, pair := range c.Pairs {
+		if i >= maxPairs {
+			break
+		}
+		rgb, ok := pair.Color.RGB()
+		if !ok {
+			continue
+		}
+		fill(img, image.Rect(x, 268, x+10, 278), rgba(rgb))
+		label := truncate(face, pair.Word, 60)
+		face.draw(img, label, x+14, 278, textColor)
+		x += 14 + face.measure(label) + 12
+		if x > baseWidth-40 {
+			break
+		}
+	}
+
+	return img
+}
+
+// wrap は width に収まるように文字単位で折り返す (日本語は単語区切りが無いため)。
+func wrap(face *bitmapFace, text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		var line []rune
+		for _, r := range paragraph {
+			if face.measure(string(append(line, r))) > width && len(line) > 0 {
+				lines = append(lines, string(line))
+				line = line[:0]
+			}
+			line = append(line, r)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+func truncate(face *bitmapFace, text string, width int) string {
+	if face.measure(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && face.measure(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func fill(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func rgba(h domain.HueRGB) color.RGBA {
+	return color.RGBA{R: uint8(h.R()), G: uint8(h.G()), B: uint8(h.B()), A: 0xff}
+}
+
+// tint は c を白へ ratio だけ寄せた色を返す。
+func tint(c color.RGBA, ratio float64) color.RGBA {
+	mix := func(v uint8) uint8 {
+		return uint8(float64(v) + (255-float64(v))*ratio)
+	}
+	return color.RGBA{R: mix(c.R), G: mix(c.G), B: mix(c.B), A: 0xff}
+}
+
+// upscale はビットマップフォントが潰れないよう最近傍で拡大する。
+func upscale(src *image.RGBA, factor int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx()*factor, b.Dy()*factor))
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			dst.SetRGBA(x, y, src.RGBAAt(b.Min.X+x/factor, b.Min.Y+y/factor))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,56 @@
+package card
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestRenderPNG(t *testing.T) {
+	hue, err := domain.NewHueRGB(0x12, 0x34, 0x56)
+	if err != nil {
+		t.Fatalf("hue error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = RenderPNG(&buf, HueCard{
+		Name:    "テスター",
+		Hue:     hue,
+		Message: strings.Repeat("とても長いメッセージです。", 20),
+		Pairs:   []Pair{{Word: "海", Color: "青"}, {Word: "太陽", Color: "赤"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+
+	width, height := Size()
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Fatalf("expected %dx%d, got %v", width, height, img.Bounds())
+	}
+
+	// 色見本の中央は結果の色そのものになる。
+	got := color.RGBAModel.Convert(img.At(132*scale, 148*scale)).(color.RGBA)
+	if got != (color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}) {
+		t.Fatalf("unexpected swatch color: %+v", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	face, err := loadFace()
+	if err != nil {
+		t.Fatalf("font error: %v", err)
+	}
+	lines := wrap(face, "あいうえおかきくけこ", face.measure("あいう"))
+	if len(lines) != 4 || lines[0] != "あいう" || lines[3] != "こ" {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+}
@@ -0,0 +1,271 @@
+// Package export は hue_records を CSV (横持ち・縦持ち) や NDJSON に書き出す。
+// レコードを 1 件ずつ受け取って書くので、件数に比例したメモリを使わない。
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// Format は出力形式。
+type Format string
+
+const (
+	// FormatCSV は 1 行 1 レコードで、語彙ごとに列を持つ横持ちの CSV。
+	FormatCSV Format = "csv"
+	// FormatCSVLong は 1 行に 1 回答 (レコード, 語彙, 色) を置く縦持ちの CSV。
+	FormatCSVLong Format = "csv-long"
+	// FormatNDJSON は 1 行 1 レコードの JSON。
+	FormatNDJSON Format = "ndjson"
+)
+
+// ErrUnknownFormat は対応していない形式を指定されたときに返る。
+var ErrUnknownFormat = errors.New("export: unknown format")
+
+// utf8BOM を先頭に付けると Excel が UTF-8 として開く。
+const utf8BOM = "\ufeff"
+
+// ParseFormat は空なら FormatCSV を返す。
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(value))); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatCSVLong, FormatNDJSON:
+		return f, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType は HTTP 応答の Content-Type。
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension はダウンロード時のファイル拡張子。
+func (f Format) Extension() string {
+	if f == FormatNDJSON {
+		return "ndjson"
+	}
+	return "csv"
+}
+
+// Encoder はレコードを順に書き出す。Begin を最初に 1 回だけ呼ぶ。
+type Encoder interface {
+	// Begin は出力に現れる語彙の一覧を受け取り、ヘッダを書く。
+	Begin(words []string) error
+	Write(record domain.HueRecord) error
+	// Flush はバッファ済みの内容を下層の Writer へ送る。
+	Flush() error
+}
+
+// Options は書き出しの細かな指定。
+type Options struct {
+	// OmitBOM が true なら CSV の先頭に BOM を付けない。
+	OmitBOM bool
+}
+
+// NewEncoder は format に応じた Encoder を返す。
+func NewEncoder(format Format, w io.Writer, opts Options) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return &wideCSVEncoder{csvBase: newCSVBase(w, opts)}, nil
+	case FormatCSVLong:
+		return &longCSVEncoder{csvBase: newCSVBase(w, opts)}, nil
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &ndjsonEncoder{buf: buf, enc: json.NewEncoder(buf)}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+var (
+	recordHeader = []string{"ID", "名前", "回答日時"}
+	resultHeader = []string{"結果の色", "結果メッセージ", "生成元"}
+	longHeader   = []string{"ID", "名前", "回答日時", "語彙", "色"}
+)
+
+type csvBase struct {
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	omitBOM bool
+}
+
+func newCSVBase(w io.Writer, opts Options) csvBase {
+	buf := bufio.NewWriter(w)
+	return csvBase{buf: buf, csv: csv.NewWriter(buf), omitBOM: opts.OmitBOM}
+}
+
+func (b csvBase) writeHeader(header []string) error {
+	if !b.omitBOM {
+		if _, err := b.buf.WriteString(utf8BOM); err != nil {
+			return err
+		}
+	}
+	return b.csv.Write(header)
+}
+
+func (b csvBase) Flush() error {
+	b.csv.Flush()
+	if err := b.csv.Error(); err != nil {
+		return err
+	}
+	return b.buf.Flush()
+}
+
+type wideCSVEncoder struct {
+	csvBase
+	words []string
+}
+
+func (e *wideCSVEncoder) Begin(words []string) error {
+	e.words = append([]string(nil), words...)
+	sort.Strings(e.words)
+
+	header := make([]string, 0, len(recordHeader)+len(e.words)+len(resultHeader))
+	header = append(header, recordHeader...)
+	for _, word := range e.words {
+		header = append(header, sanitizeCell(word))
+	}
+	header = append(header, resultHeader...)
+	return e.writeHeader(header)
+}
+
+func (e *wideCSVEncoder) Write(record domain.HueRecord) error {
+	choices := record.ChoiceMap()
+	row := make([]string, 0, len(recordHeader)+len(e.words)+len(resultHeader))
+	row = append(row, recordCells(record)...)
+	for _, word := range e.words {
+		row = append(row, choices[word])
+	}
+	row = append(row, resultCells(record)...)
+	return e.csv.Write(row)
+}
+
+type longCSVEncoder struct {
+	csvBase
+}
+
+func (e *longCSVEncoder) Begin(_ []string) error {
+	return e.writeHeader(longHeader)
+}
+
+func (e *longCSVEncoder) Write(record domain.HueRecord) error {
+	choices := record.ChoiceMap()
+	words := make([]string, 0, len(choices))
+	for word := range choices {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	prefix := recordCells(record)
+	for _, word := range words {
+		row := append(append([]string(nil), prefix...), sanitizeCell(word), choices[word])
+		if err := e.csv.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordCells(record domain.HueRecord) []string {
+	return []string{
+		record.ID().String(),
+		sanitizeCell(record.Name().String()),
+		record.CreatedAt().Format(time.RFC3339),
+	}
+}
+
+func resultCells(record domain.HueRecord) []string {
+	stored, ok := record.Result()
+	if !ok {
+		return []string{"", "", ""}
+	}
+	result := stored.Result()
+	hue := result.Hue()
+	return []string{
+		fmt.Sprintf("#%02X%02X%02X", hue.R(), hue.G(), hue.B()),
+		sanitizeCell(result.Message()),
+		result.Source().String(),
+	}
+}
+
+// sanitizeCell は Excel が数式として解釈しないよう、先頭が = + - @ のセルに ' を前置する。
+func sanitizeCell(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
+
+type ndjsonEncoder struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+type ndjsonRecord struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	Choices   map[string]string `json:"choices"`
+	Result    *ndjsonResult     `json:"result,omitempty"`
+}
+
+type ndjsonResult struct {
+	Hue           string `json:"hue"`
+	Message       string `json:"message"`
+	Source        string `json:"source"`
+	Generator     string `json:"generator"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+func (e *ndjsonEncoder) Begin(_ []string) error {
+	return nil
+}
+
+func (e *ndjsonEncoder) Write(record domain.HueRecord) error {
+	line := ndjsonRecord{
+		ID:        record.ID().String(),
+		Name:      record.Name().String(),
+		CreatedAt: record.CreatedAt(),
+		Choices:   record.ChoiceMap(),
+	}
+	if stored, ok := record.Result(); ok {
+		hue := stored.Result().Hue()
+		line.Result = &ndjsonResult{
+			Hue:           fmt.Sprintf("#%02X%02X%02X", hue.R(), hue.G(), hue.B()),
+			Message:       stored.Result().Message(),
+			Source:        stored.Result().Source().String(),
+			Generator:     stored.Generator(),
+			PromptVersion: stored.PromptVersion(),
+		}
+	}
+	// json.Encoder は 1 値ごとに改行を付ける。
+	return e.enc.Encode(line)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return e.buf.Flush()
+}
+
+// Filename は保存時のファイル名 (例: hue-records-20260501.csv)。
+func Filename(format Format, now time.Time) string {
+	return "hue-records-" + now.Format("20060102") + "." + format.Extension()
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestNewEncoder_WideCSV(t *testing.T) {
+	record := buildRecord(t, "=cmd", map[string]string{"朝": "青", "夜": "黒"})
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(FormatCSV, &buf, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := enc.Begin([]string{"朝", "夜", "未来"}); err != nil {
+		t.Fatalf("begin error: %v", err)
+	}
+	if err := enc.Write(record); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("flush error: %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), utf8BOM) {
+		t.Fatalf("expected BOM")
+	}
+	rows := readCSV(t, buf.String())
+	want := []string{"ID", "名前", "回答日時", "夜", "朝", "未来", "結果の色", "結果メッセージ", "生成元"}
+	if strings.Join(rows[0], ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected header: %v", rows[0])
+	}
+	if rows[1][1] != "'=cmd" || rows[1][3] != "黒" || rows[1][4] != "青" || rows[1][5] != "" {
+		t.Fatalf("unexpected row: %v", rows[1])
+	}
+}
+
+func TestNewEncoder_LongCSVWithoutBOM(t *testing.T) {
+	record := buildRecord(t, "テスター", map[string]string{"朝": "青", "夜": "黒"})
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(FormatCSVLong, &buf, Options{OmitBOM: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := enc.Begin(nil); err != nil {
+		t.Fatalf("begin error: %v", err)
+	}
+	if err := enc.Write(record); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("flush error: %v", err)
+	}
+
+	if strings.HasPrefix(buf.String(), utf8BOM) {
+		t.Fatalf("expected no BOM")
+	}
+	rows := readCSV(t, buf.String())
+	if len(rows) != 3 || rows[1][3] != "夜" || rows[2][3] != "朝" || rows[2][4] != "青" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatCSV {
+		t.Fatalf("expected csv by default, got %q %v", f, err)
+	}
+	if f, err := ParseFormat(" NDJSON "); err != nil || f != FormatNDJSON {
+		t.Fatalf("expected ndjson, got %q %v", f, err)
+	}
+	if _, err := ParseFormat("xlsx"); err != ErrUnknownFormat {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func buildRecord(t *testing.T, name string, choices map[string]string) domain.HueRecord {
+	t.Helper()
+	parsedName, err := domain.NewName(name)
+	if err != nil {
+		t.Fatalf("name error: %v", err)
+	}
+	parsedChoices, err := domain.NewHueChoices(choices)
+	if err != nil {
+		t.Fatalf("choices error: %v", err)
+	}
+	record, err := domain.NewHueRecord(parsedName, parsedChoices)
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	return record
+}
+
+func readCSV(t *testing.T, body string) [][]string {
+	t.Helper()
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, utf8BOM))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv: %v", err)
+	}
+	return rows
+}
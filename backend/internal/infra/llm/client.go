@@ -0,0 +1,388 @@
+// Package llm は LLM ベンダーへの HTTP 呼び出しを受け持つ。
+// 試行ごとのタイムアウト、429/5xx の再試行 (Retry-After を尊重するジッタ付きバックオフ)、
+// 連続失敗で呼び出しを止めるサーキットブレーカーを備え、失敗は domain の生成器エラーに対応付ける。
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// MaxResponseBytes はベンダー応答の読み込み上限。
+const MaxResponseBytes = 1 << 20
+
+const (
+	defaultAttemptTimeout   = 30 * time.Second
+	defaultMaxAttempts      = 3
+	defaultBaseBackoff      = 500 * time.Millisecond
+	defaultMaxBackoff       = 8 * time.Second
+	defaultMaxRetryAfter    = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+
+	// maxErrorBodyBytes だけエラー応答の本文をエラーメッセージに含める。
+	maxErrorBodyBytes = 512
+)
+
+// Config は再試行やブレーカーの設定。0 の項目は既定値を使う。
+type Config struct {
+	// AttemptTimeout は 1 回の試行の上限。ストリーミングでは応答ヘッダが届くまでの上限。
+	AttemptTimeout time.Duration
+	MaxAttempts    int
+	BaseBackoff    time.Duration
+	MaxBackoff     time.Duration
+	// MaxRetryAfter より長い Retry-After を指定されたら待たずに諦める。
+	MaxRetryAfter time.Duration
+	// BreakerThreshold 回続けて失敗したら BreakerCooldown の間は呼び出さない。
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.AttemptTimeout <= 0 {
+		c.AttemptTimeout = defaultAttemptTimeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = defaultBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.MaxRetryAfter <= 0 {
+		c.MaxRetryAfter = defaultMaxRetryAfter
+	}
+	if c.BreakerThreshold <= 0 {
+		c.BreakerThreshold = defaultBreakerThreshold
+	}
+	if c.BreakerCooldown <= 0 {
+		c.BreakerCooldown = defaultBreakerCooldown
+	}
+	return c
+}
+
+// ErrCircuitOpen は連続失敗でブレーカーが開いている間に返る。
+var ErrCircuitOpen = fmt.Errorf("llm: circuit open: %w", domain.ErrGeneratorUnavailable)
+
+// StatusError は 2xx 以外の応答。429 は ErrGeneratorRateLimited、5xx は ErrGeneratorUnavailable、
+// それ以外は ErrGeneratorRejected として errors.Is で判定できる。
+type StatusError struct {
+	StatusCode int
+	// Retry は Retry-After ヘッダの値。無ければ 0。
+	Retry time.Duration
+	Body  string
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("llm: endpoint returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return domain.ErrGeneratorRateLimited
+	case e.StatusCode >= 500:
+		return domain.ErrGeneratorUnavailable
+	default:
+		return domain.ErrGeneratorRejected
+	}
+}
+
+// RetryAfter はベンダーが指定した待ち時間。
+func (e *StatusError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+func (e *StatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Client は LLM ベンダーへ JSON を POST する。並行して使ってよい。
+type Client struct {
+	http    *http.Client
+	cfg     Config
+	breaker *breaker
+	now     func() time.Time
+	jitter  func(d time.Duration) time.Duration
+}
+
+func NewClient(httpClient *http.Client, cfg Config) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	cfg = cfg.withDefaults()
+	return &Client{
+		http:    httpClient,
+		cfg:     cfg,
+		breaker: &breaker{threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown},
+		now:     time.Now,
+		jitter:  fullJitter,
+	}
+}
+
+// PostJSON は payload を JSON で POST し、2xx の応答本文を返す。
+func (c *Client) PostJSON(ctx context.Context, endpoint, apiKey string, payload interface{}) ([]byte, error) {
+	res, cancel, err := c.post(ctx, endpoint, apiKey, "application/json", payload, false)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer res.Body.Close()
+
+	return io.ReadAll(io.LimitReader(res.Body, MaxResponseBytes))
+}
+
+// PostStream は payload を POST し、2xx なら SSE の応答本文を返す。呼び出し側が Close する。
+// 本文を読み始めてからの失敗は再試行しない。
+func (c *Client) PostStream(ctx context.Context, endpoint, apiKey string, payload interface{}) (io.ReadCloser, error) {
+	res, cancel, err := c.post(ctx, endpoint, apiKey, "text/event-stream", payload, true)
+	if err != nil {
+		return nil, err
+	}
+	return &cancelOnClose{ReadCloser: res.Body, cancel: cancel}, nil
+}
+
+// post は 2xx が返るまで再試行する。返した cancel は本文を読み終えてから呼ぶ。
+func (c *Client) post(ctx context.Context, endpoint, apiKey, accept string, payload interface{}, stream bool) (*http.Response, context.CancelFunc, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if !c.breaker.allow(c.now()) {
+			if lastErr != nil {
+				return nil, nil, errors.Join(ErrCircuitOpen, lastErr)
+			}
+			return nil, nil, ErrCircuitOpen
+		}
+
+		res, cancel, err := c.attempt(ctx, endpoint, apiKey, accept, body, stream)
+		if err == nil {
+			c.breaker.success()
+			return res, cancel, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			c.breaker.release()
+			return nil, nil, err
+		}
+
+		retryable, delay := c.classify(err, attempt)
+		if retryable {
+			c.breaker.failure(c.now())
+		} else {
+			// 要求そのものが不正な場合はベンダーの不調ではないので、ブレーカーは動かさない。
+			c.breaker.success()
+		}
+		if !retryable || attempt >= c.cfg.MaxAttempts {
+			return nil, nil, err
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, nil, errors.Join(err, lastErr)
+		}
+	}
+}
+
+// attempt は 1 回だけ POST する。ストリーミングではヘッダが届いた時点でタイムアウトを外す。
+func (c *Client) attempt(ctx context.Context, endpoint, apiKey, accept string, body []byte, stream bool) (*http.Response, context.CancelFunc, error) {
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(c.cfg.AttemptTimeout, func() {
+		cancel(fmt.Errorf("llm: attempt exceeded %s: %w", c.cfg.AttemptTimeout, domain.ErrGeneratorTimeout))
+	})
+	stop := func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		stop()
+		if cause := context.Cause(attemptCtx); ctx.Err() == nil && errors.Is(cause, domain.ErrGeneratorTimeout) {
+			return nil, nil, cause
+		}
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("llm: request failed: %w: %w", domain.ErrGeneratorUnavailable, err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		defer stop()
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyBytes))
+		return nil, nil, &StatusError{
+			StatusCode: res.StatusCode,
+			Retry:      parseRetryAfter(res.Header.Get("Retry-After"), c.now()),
+			Body:       strings.TrimSpace(string(msg)),
+		}
+	}
+
+	if stream {
+		timer.Stop()
+	}
+	return res, stop, nil
+}
+
+// classify は再試行するか、するならどれだけ待つかを返す。
+func (c *Client) classify(err error, attempt int) (bool, time.Duration) {
+	delay := c.backoff(attempt)
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if !statusErr.retryable() {
+			return false, 0
+		}
+		if statusErr.Retry > c.cfg.MaxRetryAfter {
+			return false, 0
+		}
+		return true, max(delay, statusErr.Retry)
+	}
+
+	if errors.Is(err, domain.ErrGeneratorUnavailable) || errors.Is(err, domain.ErrGeneratorTimeout) {
+		return true, delay
+	}
+	return false, 0
+}
+
+// backoff は attempt 回目の失敗後に待つ時間。BaseBackoff から倍々にし、ジッタを掛ける。
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < c.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return c.jitter(min(delay, c.cfg.MaxBackoff))
+}
+
+// fullJitter は [d/2, d) の範囲でばらし、同時に失敗した呼び出しの再試行が揃わないようにする。
+func fullJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
+
+// parseRetryAfter は秒数か HTTP 日付の Retry-After を解釈する。解釈できなければ 0。
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// breaker は連続失敗の回数で開閉する。開いている間は cooldown が過ぎるまで呼び出しを断り、
+// 過ぎたら 1 回だけ試し (半開き)、成功すれば閉じ、失敗すればまた開く。
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// release は結果を判定できなかった試行 (呼び出し元の取り消しなど) の後に半開きを解く。
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
@@ -0,0 +1,228 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+func TestClient_PostJSONRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected authorization header: %s", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"a":1}` {
+			t.Errorf("expected body to be resent, got %s", body)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, Config{})
+	body, err := client.PostJSON(context.Background(), server.URL, "secret", map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != `{"ok":true}` || calls.Load() != 3 {
+		t.Fatalf("unexpected result %s after %d calls", body, calls.Load())
+	}
+}
+
+func TestClient_PostJSONDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server, Config{}).PostJSON(context.Background(), server.URL, "secret", struct{}{})
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized || statusErr.Body != "bad key" {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if !errors.Is(err, domain.ErrGeneratorRejected) {
+		t.Fatalf("expected rejected error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single call, got %d", calls.Load())
+	}
+}
+
+func TestClient_PostJSONHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	started := time.Now()
+	if _, err := newTestClient(server, Config{}).PostJSON(context.Background(), server.URL, "secret", struct{}{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Fatalf("expected to wait for Retry-After, waited %v", elapsed)
+	}
+}
+
+func TestClient_PostJSONGivesUpOnLongRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server, Config{}).PostJSON(context.Background(), server.URL, "secret", struct{}{})
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter() != 2*time.Minute {
+		t.Fatalf("expected retry-after to be reported, got %v", err)
+	}
+	if !errors.Is(err, domain.ErrGeneratorRateLimited) || calls.Load() != 1 {
+		t.Fatalf("expected rate limited error after one call, got %v (%d calls)", err, calls.Load())
+	}
+}
+
+func TestClient_PostJSONAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			<-release
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newTestClient(server, Config{AttemptTimeout: 50 * time.Millisecond})
+	if _, err := client.PostJSON(context.Background(), server.URL, "secret", struct{}{}); err != nil {
+		t.Fatalf("expected retry after timeout, got %v", err)
+	}
+
+	client = newTestClient(server, Config{AttemptTimeout: 50 * time.Millisecond, MaxAttempts: 1})
+	calls.Store(0)
+	_, err := client.PostJSON(context.Background(), server.URL, "secret", struct{}{})
+	if !errors.Is(err, domain.ErrGeneratorTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(server, Config{MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	client.now = func() time.Time { return now }
+	post := func() error {
+		_, err := client.PostJSON(context.Background(), server.URL, "secret", struct{}{})
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := post(); !errors.Is(err, domain.ErrGeneratorUnavailable) {
+			t.Fatalf("expected unavailable error, got %v", err)
+		}
+	}
+	if err := post(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the open circuit to skip the call, got %d calls", calls.Load())
+	}
+
+	// 冷却期間が過ぎたら 1 回だけ試し、成功すれば閉じる。
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+	if err := post(); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if err := post(); err != nil {
+		t.Fatalf("expected closed circuit, got %v", err)
+	}
+}
+
+func TestClient_PostStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("unexpected accept header: %s", got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("data: hi\n\n"))
+	}))
+	defer server.Close()
+
+	// ヘッダが届いた後は試行のタイムアウトで切らない。
+	client := newTestClient(server, Config{AttemptTimeout: 50 * time.Millisecond})
+	body, err := client.PostStream(context.Background(), server.URL, "secret", struct{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil || string(b) != "data: hi\n\n" {
+		t.Fatalf("unexpected stream %q: %v", b, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Fri, 01 May 2026 12:00:30 GMT": 30 * time.Second,
+		"Fri, 01 May 2026 11:00:00 GMT": 0,
+	}
+	for value, want := range cases {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Fatalf("%q: expected %v, got %v", value, want, got)
+		}
+	}
+}
+
+// newTestClient はバックオフを短くし、ジッタを外したクライアント。
+func newTestClient(server *httptest.Server, cfg Config) *Client {
+	if cfg.BaseBackoff == 0 {
+		cfg.BaseBackoff = time.Millisecond
+	}
+	client := NewClient(server.Client(), cfg)
+	client.jitter = func(d time.Duration) time.Duration { return d }
+	return client
+}
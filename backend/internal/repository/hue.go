@@ -4,8 +4,13 @@ import (
 	"backend/internal/domain"
 	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,9 +24,38 @@ func NewHueRepository(db *pgxpool.Pool) *HueRepository {
 
 // Save は hue_records テーブルへ新しいレコードを保存する。
 func (r *HueRepository) Save(ctx context.Context, record domain.HueRecord) error {
+	return insertHueRecord(ctx, r.db, record)
+}
+
+// SaveWithJob はレコードと結果生成ジョブを同じトランザクションで保存する。
+// key がゼロ値でなければ、受け付けたジョブをキーと一緒に保存する。期限内の同じキーがあれば
+// domain.ErrDuplicateIdempotencyKey を返し、レコードも保存しない。
+func (r *HueRepository) SaveWithJob(ctx context.Context, record domain.HueRecord, job domain.HueGenerationJob, key domain.IdempotencyKey) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := insertHueRecord(ctx, tx, record); err != nil {
+		return err
+	}
+	if err := insertHueJob(ctx, tx, job); err != nil {
+		return err
+	}
+	if !key.IsZero() {
+		if err := insertIdempotencyKey(ctx, tx, key, job, record.CreatedAt()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertHueRecord(ctx context.Context, db execer, record domain.HueRecord) error {
 	const query = `
-		INSERT INTO hue_records (id, user_name, choices)
-		VALUES ($1, $2, $3)
+		INSERT INTO hue_records (id, user_name, choices, share_choices, created_at, questionnaire_version, prompt_version, user_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
 	`
 
 	choiceJSON, err := json.Marshal(record.ChoiceMap())
@@ -29,26 +63,251 @@ func (r *HueRepository) Save(ctx context.Context, record domain.HueRecord) error
 		return err
 	}
 
-	_, err = r.db.Exec(ctx, query, record.ID(), record.Name().String(), choiceJSON)
+	var userID *uuid.UUID
+	if id, ok := record.UserID(); ok {
+		userID = &id
+	}
+
+	_, err = db.Exec(ctx, query, record.ID(), record.Name().String(), choiceJSON, record.SharesChoices(), record.CreatedAt(), record.QuestionnaireVersion(), record.PromptVersion(), userID)
 	return err
 }
 
-// FindRange は作成順で並んだレコードの指定範囲を返す。
-func (r *HueRepository) FindRange(ctx context.Context, recordRange domain.RecordRange) ([]domain.HueRecord, error) {
+// FindByID は ID でレコードを検索し、見つからなければ pgx.ErrNoRows を返す。
+func (r *HueRepository) FindByID(ctx context.Context, id uuid.UUID) (domain.HueRecord, error) {
+	const query = `
+		SELECT ` + hueRecordColumns + `
+		FROM hue_records
+		WHERE id = $1
+	`
+
+	row := r.db.QueryRow(ctx, query, id)
+	return scanHueRecord(row)
+}
+
+// UpdateResult は生成済みの結果をレコードへ書き込む。
+func (r *HueRepository) UpdateResult(ctx context.Context, id uuid.UUID, result domain.HueRecordResult) error {
 	const query = `
-		SELECT id, user_name, choices
+		UPDATE hue_records
+		SET result_r = $2,
+		    result_g = $3,
+		    result_b = $4,
+		    result_message = $5,
+		    result_source = $6,
+		    result_generator = $7,
+		    result_prompt_version = NULLIF($8, ''),
+		    result_latency_ms = $9,
+		    result_generated_at = $10
+		WHERE id = $1
+	`
+
+	hue := result.Result().Hue()
+	_, err := r.db.Exec(ctx, query,
+		id,
+		hue.R(),
+		hue.G(),
+		hue.B(),
+		result.Result().Message(),
+		result.Result().Source().String(),
+		result.Generator(),
+		result.PromptVersion(),
+		result.Latency().Milliseconds(),
+		result.GeneratedAt(),
+	)
+	return err
+}
+
+// FindPage は絞り込み条件を満たすレコードを (created_at, id) 順で取得する。
+// カーソル指定ならキーセット、旧来の位置指定なら OFFSET で読み、続きがあれば次カーソルを添える。
+func (r *HueRepository) FindPage(ctx context.Context, query domain.HueRecordQuery) (domain.HueRecordPage, error) {
+	var where sqlConditions
+	applyHueRecordFilter(&where, query.Filter())
+
+	recordRange, isRange := query.Range()
+	if cursor, ok := query.Cursor(); ok && !isRange {
+		where.add("(created_at, id) > (%s, %s)", cursor.CreatedAt(), cursor.ID())
+	}
+
+	sql := `
+		SELECT ` + hueRecordColumns + `
 		FROM hue_records
+		` + where.clause() + `
 		ORDER BY created_at, id
-		OFFSET $1
-		LIMIT $2
 	`
+	if isRange {
+		sql += " OFFSET " + where.arg(recordRange.Begin())
+	}
+	// 1 件多く読んで、次ページの有無を判定する。
+	sql += " LIMIT " + where.arg(query.Limit()+1)
+
+	rows, err := r.db.Query(ctx, sql, where.args...)
+	if err != nil {
+		return domain.HueRecordPage{}, err
+	}
+	defer rows.Close()
+
+	records, err := collectHueRecords(rows)
+	if err != nil {
+		return domain.HueRecordPage{}, err
+	}
+
+	if len(records) <= query.Limit() {
+		return domain.NewHueRecordPage(records, nil), nil
+	}
+
+	records = records[:query.Limit()]
+	last := records[len(records)-1]
+	next, err := domain.NewRecordCursor(last.CreatedAt(), last.ID())
+	if err != nil {
+		return domain.HueRecordPage{}, err
+	}
+	return domain.NewHueRecordPage(records, &next), nil
+}
 
-	rows, err := r.db.Query(ctx, query, recordRange.Begin(), recordRange.Count())
+// Export は絞り込み条件を満たすレコードに現れる語彙を昇順で begin へ渡してから、レコードを
+// (created_at, id) 順に 1 件ずつ fn へ渡す。語彙とレコードが食い違わないよう、両方を
+// REPEATABLE READ の読み取り専用トランザクションで同じスナップショットから読む。
+func (r *HueRepository) Export(ctx context.Context, filter domain.HueRecordFilter, begin func(words []string) error, fn func(domain.HueRecord) error) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	words, err := queryHueWords(ctx, tx, filter)
+	if err != nil {
+		return err
+	}
+	if err := begin(words); err != nil {
+		return err
+	}
+	if err := eachHueRecord(ctx, tx, filter, fn); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// queryHueWords は絞り込み条件を満たすレコードに現れる語彙を昇順で返す。
+func queryHueWords(ctx context.Context, db querier, filter domain.HueRecordFilter) ([]string, error) {
+	var where sqlConditions
+	applyHueRecordFilter(&where, filter)
+
+	rows, err := db.Query(ctx, `
+		SELECT DISTINCT w.word
+		FROM hue_records, jsonb_object_keys(hue_records.choices) AS w(word)
+		`+where.clause()+`
+		ORDER BY w.word
+	`, where.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var words []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+
+	return words, rows.Err()
+}
+
+// Each は絞り込み条件を満たすレコードを (created_at, id) 順に 1 件ずつ fn へ渡す。
+// pgx は行を受信しながら返すので、全件をメモリに載せない。fn がエラーを返すとそこで止める。
+func (r *HueRepository) Each(ctx context.Context, filter domain.HueRecordFilter, fn func(domain.HueRecord) error) error {
+	return eachHueRecord(ctx, r.db, filter, fn)
+}
+
+func eachHueRecord(ctx context.Context, db querier, filter domain.HueRecordFilter, fn func(domain.HueRecord) error) error {
+	var where sqlConditions
+	applyHueRecordFilter(&where, filter)
+
+	rows, err := db.Query(ctx, `
+		SELECT `+hueRecordColumns+`
+		FROM hue_records
+		`+where.clause()+`
+		ORDER BY created_at, id
+	`, where.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanHueRecord(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// applyHueRecordFilter は絞り込み条件を WHERE 句へ積む。
+// choices は GIN (jsonb_path_ops)、名前は pg_trgm の GIN インデックスが効く形で書く。
+func applyHueRecordFilter(where *sqlConditions, filter domain.HueRecordFilter) {
+	if from, ok := filter.Window().From(); ok {
+		where.add("created_at >= %s", from)
+	}
+	if to, ok := filter.Window().To(); ok {
+		where.add("created_at < %s", to)
+	}
+	if name, ok := filter.NameContains(); ok {
+		where.add(`user_name ILIKE '%%' || %s || '%%' ESCAPE '\'`, escapeLike(name))
+	}
+	if choices := filter.Choices(); choices != nil {
+		// Marshal は map[string]string に対して失敗しない。
+		choiceJSON, _ := json.Marshal(choices)
+		where.add("choices @> %s::jsonb", string(choiceJSON))
+	}
+	if minWords := filter.MinWords(); minWords > 0 {
+		where.add("(SELECT COUNT(*) FROM jsonb_object_keys(choices)) >= %s", minWords)
+	}
+	if userID, ok := filter.UserID(); ok {
+		where.add("user_id = %s", userID)
+	}
+}
+
+// escapeLike は LIKE のワイルドカードを文字として扱わせる。
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// sqlConditions は AND で繋ぐ条件とプレースホルダ引数を組み立てる。
+type sqlConditions struct {
+	conds []string
+	args  []any
+}
+
+// add は cond 中の %s を引数のプレースホルダ ($n) に置き換えて条件を追加する。
+func (c *sqlConditions) add(cond string, args ...any) {
+	placeholders := make([]any, len(args))
+	for i, arg := range args {
+		placeholders[i] = c.arg(arg)
+	}
+	c.conds = append(c.conds, fmt.Sprintf(cond, placeholders...))
+}
+
+// arg は引数を追加してそのプレースホルダを返す。
+func (c *sqlConditions) arg(value any) string {
+	c.args = append(c.args, value)
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+func (c *sqlConditions) clause() string {
+	if len(c.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(c.conds, " AND ")
+}
+
+func collectHueRecords(rows pgx.Rows) ([]domain.HueRecord, error) {
 	var records []domain.HueRecord
 	for rows.Next() {
 		record, err := scanHueRecord(rows)
@@ -65,14 +324,147 @@ func (r *HueRepository) FindRange(ctx context.Context, recordRange domain.Record
 	return records, nil
 }
 
+// CountChoices は指定した語彙ごとに、各色が選ばれた回数を集計する。
+func (r *HueRepository) CountChoices(ctx context.Context, words []string) (map[string]map[string]int, error) {
+	const query = `
+		SELECT c.key, c.value, COUNT(*)
+		FROM hue_records, jsonb_each_text(hue_records.choices) AS c(key, value)
+		WHERE c.key = ANY($1)
+		GROUP BY c.key, c.value
+	`
+
+	rows, err := r.db.Query(ctx, query, words)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int, len(words))
+	for rows.Next() {
+		var (
+			word  string
+			color string
+			count int
+		)
+		if err := rows.Scan(&word, &color, &count); err != nil {
+			return nil, err
+		}
+		if counts[word] == nil {
+			counts[word] = make(map[string]int)
+		}
+		counts[word][color] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// Stats は期間内の回答を語彙・色ごとに Postgres 側で集計する。
+func (r *HueRepository) Stats(ctx context.Context, window domain.TimeWindow) (domain.HueStats, error) {
+	const countQuery = `
+		SELECT c.key, c.value, COUNT(*)
+		FROM hue_records, jsonb_each_text(hue_records.choices) AS c(key, value)
+		WHERE ($1::timestamptz IS NULL OR hue_records.created_at >= $1)
+		  AND ($2::timestamptz IS NULL OR hue_records.created_at < $2)
+		GROUP BY c.key, c.value
+	`
+	const participantQuery = `
+		SELECT COUNT(*)
+		FROM hue_records
+		WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+		  AND ($2::timestamptz IS NULL OR created_at < $2)
+	`
+
+	from, to := timeWindowArgs(window)
+
+	var participants int
+	if err := r.db.QueryRow(ctx, participantQuery, from, to).Scan(&participants); err != nil {
+		return domain.HueStats{}, err
+	}
+
+	rows, err := r.db.Query(ctx, countQuery, from, to)
+	if err != nil {
+		return domain.HueStats{}, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var (
+			word  string
+			color string
+			count int
+		)
+		if err := rows.Scan(&word, &color, &count); err != nil {
+			return domain.HueStats{}, err
+		}
+		if counts[word] == nil {
+			counts[word] = make(map[string]int)
+		}
+		counts[word][color] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.HueStats{}, err
+	}
+
+	words := make([]domain.HueWordStats, 0, len(counts))
+	for word, colors := range counts {
+		stats, err := domain.NewHueWordStats(word, colors)
+		if err != nil {
+			return domain.HueStats{}, err
+		}
+		words = append(words, stats)
+	}
+
+	return domain.NewHueStats(window, participants, words)
+}
+
+// timeWindowArgs は無制限の端を NULL として渡せるようポインタへ変換する。
+func timeWindowArgs(window domain.TimeWindow) (*time.Time, *time.Time) {
+	var from, to *time.Time
+	if t, ok := window.From(); ok {
+		from = &t
+	}
+	if t, ok := window.To(); ok {
+		to = &t
+	}
+	return from, to
+}
+
+const hueRecordColumns = `id, user_name, choices, share_choices, created_at, questionnaire_version, prompt_version, user_id,
+		result_r, result_g, result_b, result_message, result_source,
+		result_generator, result_prompt_version, result_latency_ms, result_generated_at`
+
 func scanHueRecord(row rowScanner) (domain.HueRecord, error) {
 	var (
-		id         uuid.UUID
-		userName   string
-		choiceJSON []byte
+		id                   uuid.UUID
+		userName             string
+		choiceJSON           []byte
+		shareChoices         bool
+		createdAt            time.Time
+		questionnaireVersion *string
+		assignedPrompt       *string
+		userID               *uuid.UUID
+		resultR              *int
+		resultG              *int
+		resultB              *int
+		message              *string
+		source               *string
+		generator            *string
+		promptVersion        *string
+		latencyMS            *int64
+		generatedAt          *time.Time
 	)
 
-	if err := row.Scan(&id, &userName, &choiceJSON); err != nil {
+	if err := row.Scan(
+		&id, &userName, &choiceJSON, &shareChoices, &createdAt, &questionnaireVersion, &assignedPrompt, &userID,
+		&resultR, &resultG, &resultB, &message, &source,
+		&generator, &promptVersion, &latencyMS, &generatedAt,
+	); err != nil {
 		return domain.HueRecord{}, err
 	}
 
@@ -86,10 +478,57 @@ func scanHueRecord(row rowScanner) (domain.HueRecord, error) {
 		return domain.HueRecord{}, err
 	}
 
-	choices, err := domain.NewHueChoices(raw)
+	var answeredVersion string
+	if questionnaireVersion != nil {
+		answeredVersion = *questionnaireVersion
+	}
+	choices, err := domain.NewHueChoicesFromPersistence(raw, answeredVersion)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+
+	record, err := domain.NewHueRecordFromPersistence(id, name, choices, createdAt)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+	record = record.WithShareChoices(shareChoices)
+	if assignedPrompt != nil {
+		record = record.WithPromptVersion(*assignedPrompt)
+	}
+	if userID != nil {
+		record = record.WithUserID(*userID)
+	}
+
+	if resultR == nil || resultG == nil || resultB == nil || message == nil || source == nil ||
+		generator == nil || latencyMS == nil || generatedAt == nil {
+		return record, nil
+	}
+
+	hueResult, err := domain.NewHueResultFromRaw(*resultR, *resultG, *resultB, *message)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+
+	resultSource, err := domain.ParseHueResultSource(*source)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+
+	version := ""
+	if promptVersion != nil {
+		version = *promptVersion
+	}
+
+	result, err := domain.NewHueRecordResult(
+		hueResult.WithSource(resultSource),
+		*generator,
+		version,
+		time.Duration(*latencyMS)*time.Millisecond,
+		*generatedAt,
+	)
 	if err != nil {
 		return domain.HueRecord{}, err
 	}
 
-	return domain.NewHueRecordFromPersistence(id, name, choices)
+	return record.WithResult(result), nil
 }
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HueClusterRepository は k-means の実行結果 (hue_cluster_runs, hue_clusters, hue_cluster_assignments) を扱う。
+// 保存するのは最新の実行だけで、新しい実行を保存すると古い実行は消す。
+type HueClusterRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHueClusterRepository(db *pgxpool.Pool) *HueClusterRepository {
+	return &HueClusterRepository{db: db}
+}
+
+// Save はクラスタと各回答の割り当てを同じトランザクションで保存し、古い実行を消す。
+// 読み込んだ後に消された回答の割り当ては捨てる。
+func (r *HueClusterRepository) Save(ctx context.Context, model domain.HueClusterModel, assignments []domain.HueClusterAssignment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO hue_cluster_runs (id, record_count, created_at)
+		VALUES ($1, $2, $3)
+	`, model.ID(), model.RecordCount(), model.CreatedAt()); err != nil {
+		return err
+	}
+
+	for _, cluster := range model.Clusters() {
+		centroid, err := json.Marshal(cluster.Centroid())
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO hue_clusters (run_id, cluster_index, size, centroid)
+			VALUES ($1, $2, $3, $4)
+		`, model.ID(), cluster.Index(), cluster.Size(), centroid); err != nil {
+			return err
+		}
+	}
+
+	recordIDs := make([]uuid.UUID, len(assignments))
+	clusters := make([]int32, len(assignments))
+	distances := make([]float64, len(assignments))
+	for i, assignment := range assignments {
+		recordIDs[i] = assignment.RecordID()
+		clusters[i] = int32(assignment.Cluster())
+		distances[i] = assignment.Distance()
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO hue_cluster_assignments (run_id, record_id, cluster_index, distance)
+		SELECT $1, a.record_id, a.cluster_index, a.distance
+		FROM unnest($2::uuid[], $3::integer[], $4::double precision[]) AS a(record_id, cluster_index, distance)
+		JOIN hue_records ON hue_records.id = a.record_id
+	`, model.ID(), recordIDs, clusters, distances); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM hue_cluster_runs WHERE id <> $1`, model.ID()); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Latest は最新の実行のクラスタを返す。まだ実行していなければ pgx.ErrNoRows。
+func (r *HueClusterRepository) Latest(ctx context.Context) (domain.HueClusterModel, error) {
+	var (
+		id          uuid.UUID
+		recordCount int
+		createdAt   time.Time
+	)
+	if err := r.db.QueryRow(ctx, `
+		SELECT id, record_count, created_at
+		FROM hue_cluster_runs
+		ORDER BY created_at DESC
+		LIMIT 1
+	`).Scan(&id, &recordCount, &createdAt); err != nil {
+		return domain.HueClusterModel{}, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT cluster_index, size, centroid
+		FROM hue_clusters
+		WHERE run_id = $1
+		ORDER BY cluster_index
+	`, id)
+	if err != nil {
+		return domain.HueClusterModel{}, err
+	}
+	defer rows.Close()
+
+	var clusters []domain.HueCluster
+	for rows.Next() {
+		var (
+			index        int
+			size         int
+			centroidJSON []byte
+		)
+		if err := rows.Scan(&index, &size, &centroidJSON); err != nil {
+			return domain.HueClusterModel{}, err
+		}
+		var centroid map[string]map[string]float64
+		if err := json.Unmarshal(centroidJSON, &centroid); err != nil {
+			return domain.HueClusterModel{}, err
+		}
+		cluster, err := domain.NewHueCluster(index, size, centroid)
+		if err != nil {
+			return domain.HueClusterModel{}, err
+		}
+		clusters = append(clusters, cluster)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.HueClusterModel{}, err
+	}
+
+	return domain.NewHueClusterModel(id, createdAt, recordCount, clusters)
+}
+
+// Exemplars はクラスタごとに、重心に近い回答を limit 件ずつ返す。
+func (r *HueClusterRepository) Exemplars(ctx context.Context, runID uuid.UUID, limit int) (map[int][]domain.HueRecord, error) {
+	const query = `
+		SELECT ranked.cluster_index, ` + hueRecordColumns + `
+		FROM (
+			SELECT record_id, cluster_index,
+			       ROW_NUMBER() OVER (PARTITION BY cluster_index ORDER BY distance, record_id) AS rank
+			FROM hue_cluster_assignments
+			WHERE run_id = $1
+		) AS ranked
+		JOIN hue_records ON hue_records.id = ranked.record_id
+		WHERE ranked.rank <= $2
+		ORDER BY ranked.cluster_index, ranked.rank
+	`
+
+	rows, err := r.db.Query(ctx, query, runID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exemplars := make(map[int][]domain.HueRecord)
+	for rows.Next() {
+		var cluster int
+		record, err := scanHueRecord(prefixedScanner{row: rows, prefix: []any{&cluster}})
+		if err != nil {
+			return nil, err
+		}
+		exemplars[cluster] = append(exemplars[cluster], record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exemplars, nil
+}
+
+// prefixedScanner は hueRecordColumns の前に置いた列を先に読ませる。
+type prefixedScanner struct {
+	row    rowScanner
+	prefix []any
+}
+
+func (s prefixedScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(append([]any(nil), s.prefix...), dest...)...)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// insertIdempotencyKey は送信を受け付けたときのジョブをキーと一緒に保存する。
+// 期限内の同じキーが既にあれば domain.ErrDuplicateIdempotencyKey。期限切れのキーは上書きする。
+func insertIdempotencyKey(ctx context.Context, db execer, key domain.IdempotencyKey, job domain.HueGenerationJob, now time.Time) error {
+	const query = `
+		INSERT INTO hue_idempotency_keys (idempotency_key, request_hash, record_id, job_status, max_attempts, run_at, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    record_id = EXCLUDED.record_id,
+		    job_status = EXCLUDED.job_status,
+		    max_attempts = EXCLUDED.max_attempts,
+		    run_at = EXCLUDED.run_at,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+		WHERE hue_idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+
+	tag, err := db.Exec(ctx, query,
+		key.Key(), key.RequestHash(), job.RecordID(), job.Status().String(), job.MaxAttempts(), job.RunAt(),
+		now, now.Add(domain.IdempotencyKeyTTL),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	return nil
+}
+
+// FindIdempotentJob は now の時点で期限内のキーについて、送信内容のハッシュと受け付けたときのジョブを返す。
+// 無ければ pgx.ErrNoRows を返す。
+func (r *HueRepository) FindIdempotentJob(ctx context.Context, key string, now time.Time) (string, domain.HueGenerationJob, error) {
+	const query = `
+		SELECT request_hash, record_id, job_status, max_attempts, run_at
+		FROM hue_idempotency_keys
+		WHERE idempotency_key = $1 AND expires_at > $2
+	`
+
+	var (
+		requestHash string
+		recordID    uuid.UUID
+		status      string
+		maxAttempts int
+		runAt       time.Time
+	)
+	if err := r.db.QueryRow(ctx, query, key, now).Scan(&requestHash, &recordID, &status, &maxAttempts, &runAt); err != nil {
+		return "", domain.HueGenerationJob{}, err
+	}
+
+	jobStatus, err := domain.ParseHueJobStatus(status)
+	if err != nil {
+		return "", domain.HueGenerationJob{}, err
+	}
+	job, err := domain.NewHueGenerationJobFromPersistence(recordID, jobStatus, 0, maxAttempts, runAt, "")
+	if err != nil {
+		return "", domain.HueGenerationJob{}, err
+	}
+	return requestHash, job, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HueJobRepository は hue_generation_jobs をキューとして扱う。
+type HueJobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHueJobRepository(db *pgxpool.Pool) *HueJobRepository {
+	return &HueJobRepository{db: db}
+}
+
+func insertHueJob(ctx context.Context, db execer, job domain.HueGenerationJob) error {
+	const query = `
+		INSERT INTO hue_generation_jobs (record_id, status, attempts, max_attempts, run_at, bypass_cache)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := db.Exec(ctx, query, job.RecordID(), job.Status().String(), job.Attempts(), job.MaxAttempts(), job.RunAt(), job.BypassesCache())
+	return err
+}
+
+// Claim は実行可能なジョブを 1 件取り出して running にし、lease の間だけ占有する。
+// FOR UPDATE SKIP LOCKED で他のワーカーと同じジョブを取り合わない。
+// lease が切れた running のジョブ (処理中にプロセスが落ちたもの) も取り直す。無ければ false。
+func (r *HueJobRepository) Claim(ctx context.Context, lease time.Duration) (domain.HueGenerationJob, bool, error) {
+	const query = `
+		UPDATE hue_generation_jobs
+		SET status = 'running',
+		    attempts = attempts + 1,
+		    locked_until = NOW() + $1::interval,
+		    updated_at = NOW()
+		WHERE record_id = (
+			SELECT record_id
+			FROM hue_generation_jobs
+			WHERE (status = 'pending' AND run_at <= NOW())
+			   OR (status = 'running' AND locked_until < NOW())
+			ORDER BY run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + hueJobColumns + `
+	`
+
+	job, err := scanHueJob(r.db.QueryRow(ctx, query, lease))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HueGenerationJob{}, false, nil
+		}
+		return domain.HueGenerationJob{}, false, err
+	}
+	return job, true, nil
+}
+
+// Complete はジョブを成功で確定する。
+func (r *HueJobRepository) Complete(ctx context.Context, recordID uuid.UUID) error {
+	const query = `
+		UPDATE hue_generation_jobs
+		SET status = 'succeeded', locked_until = NULL, last_error = NULL, failure = NULL, updated_at = NOW()
+		WHERE record_id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, recordID)
+	return err
+}
+
+// Retry はジョブを pending に戻し、runAt 以降に再実行させる。失敗の原因は次の成功まで残す。
+func (r *HueJobRepository) Retry(ctx context.Context, recordID uuid.UUID, runAt time.Time, failure domain.HueJobFailure, cause string) error {
+	const query = `
+		UPDATE hue_generation_jobs
+		SET status = 'pending', run_at = $2, locked_until = NULL, failure = $3, last_error = $4, updated_at = NOW()
+		WHERE record_id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, recordID, runAt, failure.String(), cause)
+	return err
+}
+
+// Fail はジョブを失敗で確定する。
+func (r *HueJobRepository) Fail(ctx context.Context, recordID uuid.UUID, failure domain.HueJobFailure, cause string) error {
+	const query = `
+		UPDATE hue_generation_jobs
+		SET status = 'failed', locked_until = NULL, failure = $2, last_error = $3, updated_at = NOW()
+		WHERE record_id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, recordID, failure.String(), cause)
+	return err
+}
+
+// FindByRecordID はレコードに紐づくジョブを返し、無ければ pgx.ErrNoRows を返す。
+func (r *HueJobRepository) FindByRecordID(ctx context.Context, recordID uuid.UUID) (domain.HueGenerationJob, error) {
+	const query = `
+		SELECT ` + hueJobColumns + `
+		FROM hue_generation_jobs
+		WHERE record_id = $1
+	`
+
+	return scanHueJob(r.db.QueryRow(ctx, query, recordID))
+}
+
+const hueJobColumns = `record_id, status, attempts, max_attempts, run_at, last_error, failure, bypass_cache`
+
+func scanHueJob(row rowScanner) (domain.HueGenerationJob, error) {
+	var (
+		recordID    uuid.UUID
+		status      string
+		attempts    int
+		maxAttempts int
+		runAt       time.Time
+		lastError   *string
+		failure     *string
+		bypass      bool
+	)
+
+	if err := row.Scan(&recordID, &status, &attempts, &maxAttempts, &runAt, &lastError, &failure, &bypass); err != nil {
+		return domain.HueGenerationJob{}, err
+	}
+
+	jobStatus, err := domain.ParseHueJobStatus(status)
+	if err != nil {
+		return domain.HueGenerationJob{}, err
+	}
+
+	var cause string
+	if lastError != nil {
+		cause = *lastError
+	}
+	var jobFailure domain.HueJobFailure
+	if failure != nil {
+		if jobFailure, err = domain.ParseHueJobFailure(*failure); err != nil {
+			return domain.HueGenerationJob{}, err
+		}
+	}
+
+	job, err := domain.NewHueGenerationJobFromPersistence(recordID, jobStatus, attempts, maxAttempts, runAt, cause)
+	if err != nil {
+		return domain.HueGenerationJob{}, err
+	}
+	return job.WithFailure(jobFailure).WithBypassCache(bypass), nil
+}
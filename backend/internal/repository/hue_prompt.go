@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HuePromptRepository は hue_prompt_versions を扱う。
+type HuePromptRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHuePromptRepository(db *pgxpool.Pool) *HuePromptRepository {
+	return &HuePromptRepository{db: db}
+}
+
+// Create は新しい版を保存する。同じ版があれば domain.ErrDuplicatePromptVersion。
+func (r *HuePromptRepository) Create(ctx context.Context, prompt domain.HuePromptVersion) error {
+	const query = `
+		INSERT INTO hue_prompt_versions (version, system_template, user_template, weight, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		prompt.Version(), prompt.SystemTemplate(), prompt.UserTemplate(),
+		prompt.Weight(), prompt.Active(), prompt.CreatedAt(),
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return domain.ErrDuplicatePromptVersion
+	}
+	return err
+}
+
+// List はすべての版を作成順に返す。
+func (r *HuePromptRepository) List(ctx context.Context) ([]domain.HuePromptVersion, error) {
+	const query = `
+		SELECT ` + huePromptColumns + `
+		FROM hue_prompt_versions
+		ORDER BY created_at, version
+	`
+	return r.query(ctx, query)
+}
+
+// FindActive は有効な版を版名順に返す。
+func (r *HuePromptRepository) FindActive(ctx context.Context) ([]domain.HuePromptVersion, error) {
+	const query = `
+		SELECT ` + huePromptColumns + `
+		FROM hue_prompt_versions
+		WHERE active AND weight > 0
+		ORDER BY version
+	`
+	return r.query(ctx, query)
+}
+
+// FindByVersion は版を探す。無ければ pgx.ErrNoRows。
+func (r *HuePromptRepository) FindByVersion(ctx context.Context, version string) (domain.HuePromptVersion, error) {
+	const query = `
+		SELECT ` + huePromptColumns + `
+		FROM hue_prompt_versions
+		WHERE version = $1
+	`
+	return scanHuePrompt(r.db.QueryRow(ctx, query, version))
+}
+
+// Activate は prompt の重みと有効化日時を書き込む。exclusive なら他の版をすべて無効にする。
+// 版が無ければ pgx.ErrNoRows。
+func (r *HuePromptRepository) Activate(ctx context.Context, prompt domain.HuePromptVersion, exclusive bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	activatedAt, _ := prompt.ActivatedAt()
+	tag, err := tx.Exec(ctx, `
+		UPDATE hue_prompt_versions
+		SET weight = $2, active = $3, activated_at = $4
+		WHERE version = $1
+	`, prompt.Version(), prompt.Weight(), prompt.Active(), activatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if exclusive {
+		if _, err := tx.Exec(ctx, `
+			UPDATE hue_prompt_versions
+			SET active = FALSE
+			WHERE version <> $1 AND active
+		`, prompt.Version()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *HuePromptRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.HuePromptVersion, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []domain.HuePromptVersion
+	for rows.Next() {
+		prompt, err := scanHuePrompt(rows)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts, rows.Err()
+}
+
+const huePromptColumns = `version, system_template, user_template, weight, active, created_at, activated_at`
+
+func scanHuePrompt(row rowScanner) (domain.HuePromptVersion, error) {
+	var (
+		version        string
+		systemTemplate string
+		userTemplate   string
+		weight         int
+		active         bool
+		createdAt      time.Time
+		activatedAt    *time.Time
+	)
+	if err := row.Scan(&version, &systemTemplate, &userTemplate, &weight, &active, &createdAt, &activatedAt); err != nil {
+		return domain.HuePromptVersion{}, err
+	}
+
+	var activated time.Time
+	if activatedAt != nil {
+		activated = *activatedAt
+	}
+	return domain.NewHuePromptVersionFromPersistence(version, systemTemplate, userTemplate, weight, active, createdAt, activated)
+}
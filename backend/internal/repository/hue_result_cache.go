@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HueResultCacheRepository は hue_result_cache (入力が同じ回答の生成結果) を扱う。
+type HueResultCacheRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHueResultCacheRepository(db *pgxpool.Pool) *HueResultCacheRepository {
+	return &HueResultCacheRepository{db: db}
+}
+
+// Find は now の時点で期限内の結果と期限を返す。無ければ pgx.ErrNoRows を返す。
+func (r *HueResultCacheRepository) Find(ctx context.Context, key domain.HueResultCacheKey, now time.Time) (domain.HueResult, time.Time, error) {
+	const query = `
+		SELECT result_r, result_g, result_b, result_message, expires_at
+		FROM hue_result_cache
+		WHERE cache_key = $1 AND expires_at > $2
+	`
+
+	var (
+		red, green, blue int
+		message          string
+		expiresAt        time.Time
+	)
+	if err := r.db.QueryRow(ctx, query, key.String(), now).Scan(&red, &green, &blue, &message, &expiresAt); err != nil {
+		return domain.HueResult{}, time.Time{}, err
+	}
+
+	result, err := domain.NewHueResultFromRaw(red, green, blue, message)
+	if err != nil {
+		return domain.HueResult{}, time.Time{}, err
+	}
+	return result.WithSource(domain.HueResultSourceModel), expiresAt, nil
+}
+
+// Put は結果を expiresAt まで保存する。同じキーがあれば上書きする。
+func (r *HueResultCacheRepository) Put(ctx context.Context, key domain.HueResultCacheKey, result domain.HueResult, now, expiresAt time.Time) error {
+	const query = `
+		INSERT INTO hue_result_cache (cache_key, prompt_version, generator, result_r, result_g, result_b, result_message, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (cache_key) DO UPDATE
+		SET result_r = EXCLUDED.result_r,
+		    result_g = EXCLUDED.result_g,
+		    result_b = EXCLUDED.result_b,
+		    result_message = EXCLUDED.result_message,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+	`
+
+	hue := result.Hue()
+	_, err := r.db.Exec(ctx, query,
+		key.String(), key.PromptVersion(), key.Generator(),
+		hue.R(), hue.G(), hue.B(), result.Message(), now, expiresAt,
+	)
+	return err
+}
+
+// DeleteExpired は now の時点で期限切れの結果を消す。
+func (r *HueResultCacheRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	const query = `DELETE FROM hue_result_cache WHERE expires_at <= $1`
+
+	_, err := r.db.Exec(ctx, query, now)
+	return err
+}
+
+// Count は now の時点で期限内の件数を返す。
+func (r *HueResultCacheRepository) Count(ctx context.Context, now time.Time) (int64, error) {
+	const query = `SELECT COUNT(*) FROM hue_result_cache WHERE expires_at > $1`
+
+	var count int64
+	err := r.db.QueryRow(ctx, query, now).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HueUsageRepository は hue_llm_usage (ベンダー呼び出しごとのトークン数) を扱う。
+type HueUsageRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHueUsageRepository(db *pgxpool.Pool) *HueUsageRepository {
+	return &HueUsageRepository{db: db}
+}
+
+// Record は recordID の結果生成で消費したトークン数を保存する。
+func (r *HueUsageRepository) Record(ctx context.Context, recordID uuid.UUID, generator string, usage domain.LLMUsage, at time.Time) error {
+	const query = `
+		INSERT INTO hue_llm_usage (record_id, generator, model, input_tokens, output_tokens, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.Exec(ctx, query, recordID, generator, usage.Model(), usage.InputTokens(), usage.OutputTokens(), at)
+	return err
+}
+
+// DailyTotals は期間内の使用量を UTC の日付とモデルごとに合計する。
+func (r *HueUsageRepository) DailyTotals(ctx context.Context, window domain.TimeWindow) ([]domain.LLMUsageTotal, error) {
+	const query = `
+		SELECT date_trunc('day', created_at AT TIME ZONE 'UTC') AS day, model,
+		       COUNT(*), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0)
+		FROM hue_llm_usage
+		WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+		  AND ($2::timestamptz IS NULL OR created_at < $2)
+		GROUP BY day, model
+		ORDER BY day, model
+	`
+
+	from, to := timeWindowArgs(window)
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []domain.LLMUsageTotal
+	for rows.Next() {
+		var (
+			day                     time.Time
+			model                   string
+			requests, input, output int64
+		)
+		if err := rows.Scan(&day, &model, &requests, &input, &output); err != nil {
+			return nil, err
+		}
+		total, err := domain.NewLLMUsageTotal(day, model, requests, input, output)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RefreshTokenRepository は login_refresh_tokens テーブルを扱う。
+type RefreshTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+const refreshTokenColumns = `id, family_id, user_id, session_id, selector, token, expires_at, created_at, used_at, revoked_at`
+
+// Create は refresh token を保存する。
+func (r *RefreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) error {
+	return insertRefreshToken(ctx, r.db, token)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, token domain.RefreshToken) error {
+	const query = `
+		INSERT INTO login_refresh_tokens (id, family_id, user_id, session_id, selector, token, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	var sessionID *uuid.UUID
+	if id, ok := token.SessionID(); ok {
+		sessionID = &id
+	}
+
+	_, err := db.Exec(ctx, query,
+		token.ID(),
+		token.FamilyID(),
+		token.UserID(),
+		sessionID,
+		token.Selector(),
+		token.HashedToken(),
+		token.ExpiresAt(),
+		token.CreatedAt(),
+	)
+	return err
+}
+
+// FindBySelector は selector で 1 行引く。使用済みや失効済みの行も返す。無ければ pgx.ErrNoRows。
+func (r *RefreshTokenRepository) FindBySelector(ctx context.Context, selector string) (domain.RefreshToken, error) {
+	const query = `
+		SELECT ` + refreshTokenColumns + `
+		FROM login_refresh_tokens
+		WHERE selector = $1
+	`
+
+	return scanRefreshToken(r.db.QueryRow(ctx, query, selector))
+}
+
+// Rotate は used を使用済みにし、新しいセッション session と同じ系列の次のトークン next を保存して、
+// used と一緒に発行した前のセッションを消す。すべて同じトランザクションで行うので、途中で失敗すれば
+// used は未使用のまま残る。used が同時に別のリクエストで使われていたか失効していれば pgx.ErrNoRows。
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, used domain.RefreshToken, session domain.LoginSession, next domain.RefreshToken, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE login_refresh_tokens
+		SET used_at = $2
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+	`, used.ID(), at.UTC())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := insertLoginSession(ctx, tx, session); err != nil {
+		return err
+	}
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+	if previous, ok := used.SessionID(); ok {
+		if _, err := tx.Exec(ctx, `DELETE FROM login_sessions WHERE id = $1`, previous); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeFamily は family のトークンをすべて失効させ、それらと一緒に発行したセッションも消す。
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE login_refresh_tokens
+		SET revoked_at = $2
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID, at.UTC()); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM login_sessions
+		WHERE id IN (
+			SELECT session_id
+			FROM login_refresh_tokens
+			WHERE family_id = $1 AND session_id IS NOT NULL
+		)
+	`, familyID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeBySession は userID のセッションと一緒に発行した family を失効させる。セッション自体は消さない。
+// セッションを消すと session_id は NULL になるので、消す前に呼ぶ。
+func (r *RefreshTokenRepository) RevokeBySession(ctx context.Context, userID, sessionID uuid.UUID, at time.Time) error {
+	const query = `
+		UPDATE login_refresh_tokens
+		SET revoked_at = $3
+		WHERE revoked_at IS NULL
+		  AND family_id IN (
+			SELECT family_id
+			FROM login_refresh_tokens
+			WHERE user_id = $1 AND session_id = $2
+		  )
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, sessionID, at.UTC())
+	return err
+}
+
+// RevokeByUser はユーザーの refresh token をすべて失効させる。
+func (r *RefreshTokenRepository) RevokeByUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	const query = `
+		UPDATE login_refresh_tokens
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, at.UTC())
+	return err
+}
+
+func scanRefreshToken(row rowScanner) (domain.RefreshToken, error) {
+	var (
+		id        uuid.UUID
+		familyID  uuid.UUID
+		userID    uuid.UUID
+		sessionID *uuid.UUID
+		selector  string
+		token     string
+		expiresAt time.Time
+		createdAt time.Time
+		usedAt    *time.Time
+		revokedAt *time.Time
+	)
+
+	if err := row.Scan(&id, &familyID, &userID, &sessionID, &selector, &token, &expiresAt, &createdAt, &usedAt, &revokedAt); err != nil {
+		return domain.RefreshToken{}, err
+	}
+
+	hashedToken, err := domain.ParseHashedLoginSessionToken(selector, token)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+
+	var session uuid.UUID
+	if sessionID != nil {
+		session = *sessionID
+	}
+	refreshToken, err := domain.NewRefreshTokenFromPersistence(id, familyID, userID, session, hashedToken, expiresAt, createdAt)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+
+	if usedAt != nil {
+		refreshToken = refreshToken.WithUsedAt(*usedAt)
+	}
+	if revokedAt != nil {
+		refreshToken = refreshToken.WithRevokedAt(*revokedAt)
+	}
+	return refreshToken, nil
+}
@@ -8,41 +8,80 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // LoginSessionRepository は login_sessions テーブルを扱う。
 type LoginSessionRepository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	pepper domain.SessionTokenPepper
 }
 
-func NewLoginSessionRepository(db *pgxpool.Pool) *LoginSessionRepository {
-	return &LoginSessionRepository{db: db}
+func NewLoginSessionRepository(db *pgxpool.Pool, pepper domain.SessionTokenPepper) *LoginSessionRepository {
+	return &LoginSessionRepository{db: db, pepper: pepper}
 }
 
 // Create はセッションを永続化する。
 func (r *LoginSessionRepository) Create(ctx context.Context, session domain.LoginSession) error {
+	return insertLoginSession(ctx, r.db, session)
+}
+
+func insertLoginSession(ctx context.Context, db execer, session domain.LoginSession) error {
 	const query = `
-		INSERT INTO login_sessions (id, user_id, token, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO login_sessions (id, user_id, selector, token, user_agent, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	var selector *string
+	if value := session.Selector(); value != "" {
+		selector = &value
+	}
+
+	_, err := db.Exec(ctx, query,
 		session.ID(),
 		session.UserID(),
+		selector,
 		session.HashedToken(),
+		session.Client().UserAgent(),
+		session.Client().IPAddress(),
 		session.ExpiresAt(),
 		session.CreatedAt(),
 	)
 	return err
 }
 
-// Find は指定ユーザーのセッション群から入力トークンと一致するハッシュを探索する。
+const loginSessionColumns = `id, user_id, selector, token, user_agent, ip_address, expires_at, created_at, last_used_at`
+
+// Find は入力トークンと一致する指定ユーザーのセッションを返す。見つからなければ pgx.ErrNoRows。
+// 新形式のトークンは selector で 1 行だけ引いて照合する。
 func (r *LoginSessionRepository) Find(ctx context.Context, userID uuid.UUID, token domain.LoginSessionToken) (domain.LoginSession, error) {
+	selector, ok := token.Selector()
+	if !ok {
+		return r.findLegacy(ctx, userID, token)
+	}
+
 	const query = `
-		SELECT id, user_id, token, expires_at, created_at
+		SELECT ` + loginSessionColumns + `
 		FROM login_sessions
-		WHERE user_id = $1
+		WHERE selector = $1
+	`
+
+	session, err := scanLoginSession(r.db.QueryRow(ctx, query, selector))
+	if err != nil {
+		return domain.LoginSession{}, err
+	}
+	if session.UserID() != userID || session.Verify(token, r.pepper) != nil {
+		return domain.LoginSession{}, pgx.ErrNoRows
+	}
+	return session, nil
+}
+
+// findLegacy は selector を持たない旧形式のセッションを bcrypt で探す。
+// 期限切れの行は照合しないので、旧形式のセッションが期限切れになれば bcrypt は走らなくなる。
+func (r *LoginSessionRepository) findLegacy(ctx context.Context, userID uuid.UUID, token domain.LoginSessionToken) (domain.LoginSession, error) {
+	const query = `
+		SELECT ` + loginSessionColumns + `
+		FROM login_sessions
+		WHERE user_id = $1 AND selector IS NULL AND expires_at > NOW()
 	`
 
 	rows, err := r.db.Query(ctx, query, userID)
@@ -57,7 +96,7 @@ func (r *LoginSessionRepository) Find(ctx context.Context, userID uuid.UUID, tok
 			return domain.LoginSession{}, err
 		}
 
-		if bcrypt.CompareHashAndPassword([]byte(session.HashedToken()), []byte(token.String())) == nil {
+		if session.Verify(token, r.pepper) == nil {
 			return session, nil
 		}
 	}
@@ -69,6 +108,49 @@ func (r *LoginSessionRepository) Find(ctx context.Context, userID uuid.UUID, tok
 	return domain.LoginSession{}, pgx.ErrNoRows
 }
 
+// ListActiveByUser は at の時点で期限内のセッションを、最近使われた順に返す。
+func (r *LoginSessionRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID, at time.Time) ([]domain.LoginSession, error) {
+	const query = `
+		SELECT ` + loginSessionColumns + `
+		FROM login_sessions
+		WHERE user_id = $1 AND expires_at > $2
+		ORDER BY COALESCE(last_used_at, created_at) DESC, id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.LoginSession
+	for rows.Next() {
+		session, err := scanLoginSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch は session の last_used_at と延長した expires_at を保存する。
+func (r *LoginSessionRepository) Touch(ctx context.Context, session domain.LoginSession) error {
+	const query = `
+		UPDATE login_sessions
+		SET last_used_at = $2, expires_at = GREATEST(expires_at, $3)
+		WHERE id = $1
+	`
+
+	lastUsedAt, _ := session.LastUsedAt()
+	_, err := r.db.Exec(ctx, query, session.ID(), lastUsedAt, session.ExpiresAt())
+	return err
+}
+
 // DeleteByID は指定したセッションを削除する。
 func (r *LoginSessionRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
 	const query = `
@@ -80,23 +162,71 @@ func (r *LoginSessionRepository) DeleteByID(ctx context.Context, id uuid.UUID) e
 	return err
 }
 
+// DeleteByUserAndID は userID のセッション id を削除する。他人のセッションや存在しない id なら pgx.ErrNoRows。
+func (r *LoginSessionRepository) DeleteByUserAndID(ctx context.Context, userID, id uuid.UUID) error {
+	const query = `
+		DELETE FROM login_sessions
+		WHERE user_id = $1 AND id = $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteByUser は userID のセッションをすべて削除し、削除した件数を返す。
+func (r *LoginSessionRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	const query = `
+		DELETE FROM login_sessions
+		WHERE user_id = $1
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
 func scanLoginSession(row rowScanner) (domain.LoginSession, error) {
 	var (
-		id        uuid.UUID
-		userID    uuid.UUID
-		token     string
-		expiresAt time.Time
-		createdAt time.Time
+		id         uuid.UUID
+		userID     uuid.UUID
+		selector   *string
+		token      string
+		userAgent  string
+		ipAddress  string
+		expiresAt  time.Time
+		createdAt  time.Time
+		lastUsedAt *time.Time
 	)
 
-	if err := row.Scan(&id, &userID, &token, &expiresAt, &createdAt); err != nil {
+	if err := row.Scan(&id, &userID, &selector, &token, &userAgent, &ipAddress, &expiresAt, &createdAt, &lastUsedAt); err != nil {
+		return domain.LoginSession{}, err
+	}
+
+	var selectorValue string
+	if selector != nil {
+		selectorValue = *selector
+	}
+	hashedToken, err := domain.ParseHashedLoginSessionToken(selectorValue, token)
+	if err != nil {
 		return domain.LoginSession{}, err
 	}
 
-	hashedToken, err := domain.ParseHashedLoginSessionToken(token)
+	session, err := domain.NewLoginSessionFromPersistence(id, userID, hashedToken, expiresAt, createdAt)
 	if err != nil {
 		return domain.LoginSession{}, err
 	}
 
-	return domain.NewLoginSessionFromPersistence(id, userID, hashedToken, expiresAt, createdAt)
+	session = session.WithClient(domain.NewSessionClient(userAgent, ipAddress))
+	if lastUsedAt != nil {
+		session = session.WithLastUsedAt(*lastUsedAt)
+	}
+	return session, nil
 }
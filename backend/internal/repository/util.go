@@ -1,5 +1,22 @@
 package repository
 
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
 type rowScanner interface {
 	Scan(dest ...interface{}) error
 }
+
+// execer はプールとトランザクションのどちらでも SQL を実行できるようにする。
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// querier はプールとトランザクションのどちらでも行を取得できるようにする。
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AuthService はセッションの照合・期限切れの掃除・ユーザーの読み込みをまとめ、認証済みの主体を作る。
+// 認証ミドルウェアがリクエストごとに 1 度だけ呼び、保護されたユースケースは domain.Principal を受け取る。
+type AuthService struct {
+	sessionRepo *repository.LoginSessionRepository
+	userRepo    *repository.UserRepository
+	logger      *log.Logger
+	now         func() time.Time
+}
+
+func NewAuthService(sessionRepo *repository.LoginSessionRepository, userRepo *repository.UserRepository, logger *log.Logger) *AuthService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &AuthService{sessionRepo: sessionRepo, userRepo: userRepo, logger: logger, now: time.Now}
+}
+
+// Authenticate は session が有効なら、そのユーザーとロール、照合できたセッションの ID を主体として返す。
+// 使われた時刻と延長した有効期限は SessionTouchInterval ごとに記録する。
+func (s *AuthService) Authenticate(ctx context.Context, session domain.SessionData) (domain.Principal, error) {
+	loginSession, err := s.sessionRepo.Find(ctx, session.UserID(), session.Token())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logError("session not found", err)
+			return domain.Principal{}, domain.ErrInvalidLoginSession
+		}
+		s.logError("find session", err)
+		return domain.Principal{}, err
+	}
+
+	now := s.now()
+	if loginSession.IsExpired(now) {
+		s.logError("session expired", domain.ErrExpiredToken)
+		if delErr := s.sessionRepo.DeleteByID(ctx, loginSession.ID()); delErr != nil {
+			s.logError("cleanup expired session", delErr)
+		}
+		return domain.Principal{}, domain.ErrExpiredToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, session.UserID())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logError("user not found", err)
+			return domain.Principal{}, domain.ErrInvalidLoginSession
+		}
+		s.logError("find user by id", err)
+		return domain.Principal{}, err
+	}
+
+	if loginSession.NeedsTouch(now) {
+		// 使われたセッションは期限を延ばす。旧形式のセッションは対象外。記録できなくても認証は通す。
+		if err := s.sessionRepo.Touch(ctx, loginSession.WithLastUsedAt(now).Extend(now)); err != nil {
+			s.logError("touch session", err)
+		}
+	}
+
+	return domain.NewPrincipal(user.ID(), user.Role(), loginSession.ID())
+}
+
+func (s *AuthService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[AuthService] %s: %v", action, err)
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultHueCacheTTL           = 7 * 24 * time.Hour
+	defaultHueCacheMemoryEntries = 1024
+	// hueCachePurgeInterval ごとに期限切れの行を消す。
+	hueCachePurgeInterval = time.Hour
+)
+
+// HueResultCacheConfig は結果キャッシュの期限と件数。0 の項目は既定値を使う。
+type HueResultCacheConfig struct {
+	// TTL は結果を使い回す期間。
+	TTL time.Duration
+	// MemoryEntries はプロセス内に持つ件数。
+	MemoryEntries int
+}
+
+// HueResultCache は回答が同じなら LLM の結果を使い回すキャッシュ。
+// プロセス内の LRU を先に引き、外れたら Postgres を引く。
+type HueResultCache struct {
+	repo   *repository.HueResultCacheRepository
+	memory *hueResultLRU
+	ttl    time.Duration
+	logger *log.Logger
+	now    func() time.Time
+
+	memoryHits atomic.Int64
+	storeHits  atomic.Int64
+	misses     atomic.Int64
+	bypasses   atomic.Int64
+
+	purgeMu   sync.Mutex
+	lastPurge time.Time
+}
+
+// NewHueResultCache は repo (nil 可) を裏に持つキャッシュを作る。nil ならプロセス内だけで持つ。
+func NewHueResultCache(repo *repository.HueResultCacheRepository, logger *log.Logger, cfg HueResultCacheConfig) *HueResultCache {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultHueCacheTTL
+	}
+	if cfg.MemoryEntries <= 0 {
+		cfg.MemoryEntries = defaultHueCacheMemoryEntries
+	}
+	return &HueResultCache{
+		repo:   repo,
+		memory: newHueResultLRU(cfg.MemoryEntries),
+		ttl:    cfg.TTL,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Get は key の結果を返す。無ければ false。保存先の障害は外れとして扱う。
+func (c *HueResultCache) Get(ctx context.Context, key domain.HueResultCacheKey) (domain.HueResult, bool) {
+	now := c.now()
+	if result, ok := c.memory.get(key.String(), now); ok {
+		c.memoryHits.Add(1)
+		return result, true
+	}
+
+	if c.repo != nil {
+		result, expiresAt, err := c.repo.Find(ctx, key, now)
+		switch {
+		case err == nil:
+			c.memory.put(key.String(), result, expiresAt)
+			c.storeHits.Add(1)
+			return result, true
+		case !errors.Is(err, pgx.ErrNoRows):
+			c.logError("find cached hue result", err)
+		}
+	}
+
+	c.misses.Add(1)
+	return domain.HueResult{}, false
+}
+
+// Put は key の結果を TTL の間保存する。保存先に書けなくても生成は成功として扱う。
+func (c *HueResultCache) Put(ctx context.Context, key domain.HueResultCacheKey, result domain.HueResult) {
+	now := c.now()
+	expiresAt := now.Add(c.ttl)
+	c.memory.put(key.String(), result, expiresAt)
+
+	if c.repo == nil {
+		return
+	}
+	if err := c.repo.Put(ctx, key, result, now, expiresAt); err != nil {
+		c.logError("store hue result", err)
+	}
+	c.purge(ctx, now)
+}
+
+// Bypass はキャッシュを使わずに生成した回数を数える。
+func (c *HueResultCache) Bypass() {
+	c.bypasses.Add(1)
+}
+
+// Stats は起動してからの当たり外れと現在の件数を返す。
+func (c *HueResultCache) Stats(ctx context.Context) (domain.HueResultCacheStats, error) {
+	stats := domain.HueResultCacheStats{
+		MemoryHits:    c.memoryHits.Load(),
+		StoreHits:     c.storeHits.Load(),
+		Misses:        c.misses.Load(),
+		Bypasses:      c.bypasses.Load(),
+		MemoryEntries: c.memory.len(),
+	}
+	if c.repo != nil {
+		count, err := c.repo.Count(ctx, c.now())
+		if err != nil {
+			c.logError("count cached hue results", err)
+			return domain.HueResultCacheStats{}, err
+		}
+		stats.StoreEntries = count
+	}
+	return stats, nil
+}
+
+// purge は前回から hueCachePurgeInterval 経っていれば期限切れの行を消す。
+func (c *HueResultCache) purge(ctx context.Context, now time.Time) {
+	c.purgeMu.Lock()
+	if now.Sub(c.lastPurge) < hueCachePurgeInterval {
+		c.purgeMu.Unlock()
+		return
+	}
+	c.lastPurge = now
+	c.purgeMu.Unlock()
+
+	if err := c.repo.DeleteExpired(ctx, now); err != nil {
+		c.logError("purge cached hue results", err)
+	}
+}
+
+func (c *HueResultCache) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	c.logger.Printf("[HueResultCache] %s: %v", action, err)
+}
+
+// hueResultLRU は件数に上限のあるプロセス内の結果キャッシュ。最も長く使われていないものから捨てる。
+type hueResultLRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type hueResultLRUEntry struct {
+	key       string
+	result    domain.HueResult
+	expiresAt time.Time
+}
+
+func newHueResultLRU(capacity int) *hueResultLRU {
+	return &hueResultLRU{capacity: capacity, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (l *hueResultLRU) get(key string, now time.Time) (domain.HueResult, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return domain.HueResult{}, false
+	}
+	entry := elem.Value.(*hueResultLRUEntry)
+	if !now.Before(entry.expiresAt) {
+		l.order.Remove(elem)
+		delete(l.items, key)
+		return domain.HueResult{}, false
+	}
+	l.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+func (l *hueResultLRU) put(key string, result domain.HueResult, expiresAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*hueResultLRUEntry)
+		entry.result, entry.expiresAt = result, expiresAt
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(&hueResultLRUEntry{key: key, result: result, expiresAt: expiresAt})
+	for l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*hueResultLRUEntry).key)
+	}
+}
+
+func (l *hueResultLRU) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+)
+
+func TestHueResultLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lru := newHueResultLRU(2)
+	lru.put("a", buildCacheResult(t, "a"), now.Add(time.Hour))
+	lru.put("b", buildCacheResult(t, "b"), now.Add(time.Hour))
+
+	// a を使うと、次に追い出されるのは b になる。
+	if _, ok := lru.get("a", now); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	lru.put("c", buildCacheResult(t, "c"), now.Add(time.Hour))
+
+	if _, ok := lru.get("b", now); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if result, ok := lru.get("a", now); !ok || result.Message() != "a" {
+		t.Fatalf("expected a to survive, got %v %v", result, ok)
+	}
+	if lru.len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", lru.len())
+	}
+}
+
+func TestHueResultLRU_Expires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lru := newHueResultLRU(2)
+	lru.put("a", buildCacheResult(t, "a"), now.Add(time.Minute))
+
+	if _, ok := lru.get("a", now.Add(time.Minute)); ok {
+		t.Fatalf("expected expired entry to miss")
+	}
+	if lru.len() != 0 {
+		t.Fatalf("expected expired entry to be dropped, got %d", lru.len())
+	}
+}
+
+func TestHueResultCache_MemoryOnly(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewHueResultCache(nil, nil, HueResultCacheConfig{TTL: time.Hour, MemoryEntries: 4})
+	cache.now = func() time.Time { return now }
+
+	record, err := domain.NewHueRecordFromRaw("tester", map[string]string{"夜": "赤"})
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	prompt, err := domain.NewHuePromptVersion("v1", "system", "user")
+	if err != nil {
+		t.Fatalf("prompt error: %v", err)
+	}
+	key := domain.NewHueResultCacheKey(record, prompt, "fake")
+
+	if _, ok := cache.Get(context.Background(), key); ok {
+		t.Fatalf("expected miss before put")
+	}
+	cache.Put(context.Background(), key, buildCacheResult(t, "cached"))
+	if result, ok := cache.Get(context.Background(), key); !ok || result.Message() != "cached" {
+		t.Fatalf("expected hit, got %v %v", result, ok)
+	}
+	cache.Bypass()
+
+	now = now.Add(time.Hour)
+	if _, ok := cache.Get(context.Background(), key); ok {
+		t.Fatalf("expected miss after ttl")
+	}
+
+	stats, err := cache.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("stats error: %v", err)
+	}
+	if stats.MemoryHits != 1 || stats.StoreHits != 0 || stats.Misses != 2 || stats.Bypasses != 1 || stats.MemoryEntries != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func buildCacheResult(t *testing.T, message string) domain.HueResult {
+	t.Helper()
+	result, err := domain.NewHueResultFromRaw(1, 2, 3, message)
+	if err != nil {
+		t.Fatalf("result error: %v", err)
+	}
+	return result.WithSource(domain.HueResultSourceModel)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+
+	"backend/internal/domain"
+	"backend/internal/infra/card"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultHueCardCacheSize は PNG をメモリに保持する件数の上限。
+const defaultHueCardCacheSize = 256
+
+// HueCardService は結果共有用のカード画像を描画し、レコード ID 単位でキャッシュする。
+// 生成済みの結果は書き換わらないため、キャッシュの無効化は行わない。
+type HueCardService struct {
+	hueRepo *repository.HueRepository
+	cache   *pngCache
+	logger  *log.Logger
+}
+
+func NewHueCardService(hueRepo *repository.HueRepository, logger *log.Logger) *HueCardService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueCardService{
+		hueRepo: hueRepo,
+		cache:   newPNGCache(defaultHueCardCacheSize),
+		logger:  logger,
+	}
+}
+
+// CardPNG は結果カードの PNG を返す。未生成または存在しない場合は ErrHueRecordNotFound。
+func (s *HueCardService) CardPNG(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	if cached, ok := s.cache.get(id); ok {
+		return cached, nil
+	}
+
+	record, err := s.hueRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrHueRecordNotFound
+		}
+		s.logError("find hue record", err)
+		return nil, err
+	}
+
+	stored, ok := record.Result()
+	if !ok {
+		return nil, domain.ErrHueRecordNotFound
+	}
+
+	c := card.HueCard{
+		Name:    record.Name().String(),
+		Hue:     stored.Result().Hue(),
+		Message: stored.Result().Message(),
+	}
+	// 回答の公開を許可していない人のカードには語彙と色の組を載せない。
+	if record.SharesChoices() {
+		c.Pairs = s.distinctivePairs(ctx, record.Choices())
+	}
+
+	var buf bytes.Buffer
+	if err := card.RenderPNG(&buf, c); err != nil {
+		s.logError("render hue card", err)
+		return nil, err
+	}
+
+	png := buf.Bytes()
+	s.cache.put(id, png)
+	return png, nil
+}
+
+// distinctivePairs は母集団の中で選ばれた割合が低い順に回答を並べる。
+func (s *HueCardService) distinctivePairs(ctx context.Context, choices domain.HueChoices) []card.Pair {
+	m := choices.ToMap()
+	words := make([]string, 0, len(m))
+	for word := range m {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	population, err := s.hueRepo.CountChoices(ctx, words)
+	if err != nil {
+		s.logError("count hue choices", err)
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return choiceShare(population[words[i]], m[words[i]]) < choiceShare(population[words[j]], m[words[j]])
+	})
+
+	pairs := make([]card.Pair, len(words))
+	for i, word := range words {
+		pairs[i] = card.Pair{Word: word, Color: domain.HueColor(m[word])}
+	}
+	return pairs
+}
+
+func (s *HueCardService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueCardService] %s: %v", action, err)
+}
+
+// pngCache は件数上限付きの LRU キャッシュ。
+type pngCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[uuid.UUID]*list.Element
+}
+
+type pngCacheEntry struct {
+	id   uuid.UUID
+	data []byte
+}
+
+func newPNGCache(capacity int) *pngCache {
+	return &pngCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[uuid.UUID]*list.Element, capacity),
+	}
+}
+
+func (c *pngCache) get(id uuid.UUID) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*pngCacheEntry).data, true
+}
+
+func (c *pngCache) put(id uuid.UUID, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		elem.Value.(*pngCacheEntry).data = data
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(&pngCacheEntry{id: id, data: data})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*pngCacheEntry).id)
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPNGCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newPNGCache(2)
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	cache.put(a, []byte("a"))
+	cache.put(b, []byte("b"))
+	if _, ok := cache.get(a); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	cache.put(c, []byte("c"))
+
+	if _, ok := cache.get(b); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if got, ok := cache.get(a); !ok || string(got) != "a" {
+		t.Fatalf("expected a to survive, got %q", got)
+	}
+	if got, ok := cache.get(c); !ok || string(got) != "c" {
+		t.Fatalf("expected c to be cached, got %q", got)
+	}
+}
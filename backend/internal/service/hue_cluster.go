@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultHueClusterInterval  = 6 * time.Hour
+	defaultHueClusterExemplars = 3
+	// hueClusterModelTTL だけ経ったら、他のプロセスが作り直したクラスタを読み直す。
+	hueClusterModelTTL = time.Minute
+)
+
+// HueClusterConfig はクラスタリングの K と実行間隔。0 の項目は既定値を使う。
+type HueClusterConfig struct {
+	K             int
+	Interval      time.Duration
+	MaxIterations int
+	// Exemplars は管理画面にクラスタごとに出す代表的な回答の数。
+	Exemplars int
+}
+
+func (c HueClusterConfig) withDefaults() HueClusterConfig {
+	if c.K <= 0 {
+		c.K = domain.DefaultHueClusterCount
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultHueClusterInterval
+	}
+	if c.MaxIterations <= 0 {
+		c.MaxIterations = domain.DefaultHueClusterIterations
+	}
+	if c.Exemplars <= 0 {
+		c.Exemplars = defaultHueClusterExemplars
+	}
+	return c
+}
+
+// HueClusterService は回答を k-means で「色の性格」に分ける分析ジョブと、その結果の参照。
+// Run をサーバープロセス内で動かすと、Interval ごとに全回答からクラスタを作り直す。
+type HueClusterService struct {
+	hueRepo     *repository.HueRepository
+	clusterRepo *repository.HueClusterRepository
+	logger      *log.Logger
+	cfg         HueClusterConfig
+	now         func() time.Time
+	rng         *rand.Rand
+
+	mu       sync.RWMutex
+	model    domain.HueClusterModel
+	loadedAt time.Time
+}
+
+func NewHueClusterService(hueRepo *repository.HueRepository, clusterRepo *repository.HueClusterRepository, logger *log.Logger, cfg HueClusterConfig) *HueClusterService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueClusterService{
+		hueRepo:     hueRepo,
+		clusterRepo: clusterRepo,
+		logger:      logger,
+		cfg:         cfg.withDefaults(),
+		now:         time.Now,
+		rng:         rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// Run は ctx が終わるまで、前回の実行から Interval 経つたびにクラスタを作り直す。
+// 複数のプロセスで動かしても、前回の実行時刻を DB で見るので間隔はおおむね保たれる。
+func (s *HueClusterService) Run(ctx context.Context) {
+	for {
+		wait, err := s.untilNextRun(ctx)
+		if err != nil {
+			s.logError("check last clustering", err)
+			wait = s.cfg.Interval
+		}
+		if wait <= 0 {
+			if err := s.Recluster(ctx); err != nil && !errors.Is(err, domain.ErrNotEnoughHueRecords) {
+				s.logError("recluster", err)
+			}
+			wait = s.cfg.Interval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (s *HueClusterService) untilNextRun(ctx context.Context) (time.Duration, error) {
+	model, err := s.clusterRepo.Latest(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return model.CreatedAt().Add(s.cfg.Interval).Sub(s.now()), nil
+}
+
+// Recluster は全回答を読み込んでクラスタを作り直し、各回答の割り当てと一緒に保存する。
+func (s *HueClusterService) Recluster(ctx context.Context) error {
+	var records []domain.HueRecord
+	if err := s.hueRepo.Each(ctx, domain.HueRecordFilter{}, func(record domain.HueRecord) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	model, assignments, err := domain.ClusterHueRecords(records, s.cfg.K, s.cfg.MaxIterations, s.rng, s.now())
+	if err != nil {
+		return err
+	}
+
+	if err := s.clusterRepo.Save(ctx, model, assignments); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.model = model
+	s.loadedAt = s.now()
+	s.mu.Unlock()
+
+	s.logger.Printf("[HueClusterService] clustered %d records into %d clusters", model.RecordCount(), len(model.Clusters()))
+	return nil
+}
+
+// GetClusters は最新のクラスタと、それぞれの代表的な回答を返す。管理者のみ。
+// まだクラスタを作っていなければ ErrHueClustersNotFound。
+func (s *HueClusterService) GetClusters(ctx context.Context, principal domain.Principal) (domain.HueClusterReport, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HueClusterReport{}, err
+	}
+
+	model, err := s.clusterRepo.Latest(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HueClusterReport{}, domain.ErrHueClustersNotFound
+		}
+		s.logError("find latest clusters", err)
+		return domain.HueClusterReport{}, err
+	}
+
+	exemplars, err := s.clusterRepo.Exemplars(ctx, model.ID(), s.cfg.Exemplars)
+	if err != nil {
+		s.logError("find cluster exemplars", err)
+		return domain.HueClusterReport{}, err
+	}
+
+	return domain.NewHueClusterReport(model, exemplars), nil
+}
+
+// ClusterOf は回答に最も近いクラスタを返す。クラスタリング後に保存された回答にも使える。
+// まだクラスタが無いか読めなければ false。
+func (s *HueClusterService) ClusterOf(ctx context.Context, record domain.HueRecord) (domain.HueCluster, bool) {
+	model, err := s.currentModel(ctx)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.logError("load clusters", err)
+		}
+		return domain.HueCluster{}, false
+	}
+
+	cluster, _, ok := model.Nearest(record.Choices())
+	return cluster, ok
+}
+
+func (s *HueClusterService) currentModel(ctx context.Context) (domain.HueClusterModel, error) {
+	s.mu.RLock()
+	model, loadedAt := s.model, s.loadedAt
+	s.mu.RUnlock()
+	if !loadedAt.IsZero() && s.now().Sub(loadedAt) < hueClusterModelTTL {
+		if model.IsZero() {
+			return domain.HueClusterModel{}, pgx.ErrNoRows
+		}
+		return model, nil
+	}
+
+	model, err := s.clusterRepo.Latest(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return domain.HueClusterModel{}, err
+	}
+
+	// 無かったことも覚えておき、TTL の間は問い合わせない。
+	s.mu.Lock()
+	s.model = model
+	s.loadedAt = s.now()
+	s.mu.Unlock()
+
+	if err != nil {
+		return domain.HueClusterModel{}, err
+	}
+	return model, nil
+}
+
+func (s *HueClusterService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueClusterService] %s: %v", action, err)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"backend/internal/domain"
+	"backend/internal/infra/llm"
+)
+
+const (
+	HueGeneratorOpenAIResponses = "openai-responses"
+	HueGeneratorOpenAIChat      = "openai-chat"
+	HueGeneratorFake            = "fake"
+	HueGeneratorRuleBased       = "rule-based"
+
+	defaultHueModel = "gpt-4.1"
+)
+
+// HueGenerationRequest は結果生成に渡すプロンプトと回答をまとめる。
+type HueGenerationRequest struct {
+	SystemPrompt string
+	UserPrompt   string
+	Choices      domain.HueChoices
+	// OnUsage (nil 可) はベンダーが返したトークン数を受け取る。生成に失敗しても応答に含まれていれば呼ばれる。
+	OnUsage func(usage domain.LLMUsage)
+}
+
+// reportUsage はベンダーの usage を OnUsage へ渡す。モデル名が無いなど解釈できない値は捨てる。
+func (r HueGenerationRequest) reportUsage(model string, inputTokens, outputTokens int) {
+	if r.OnUsage == nil {
+		return
+	}
+	usage, err := domain.NewLLMUsage(model, inputTokens, outputTokens)
+	if err != nil {
+		return
+	}
+	r.OnUsage(usage)
+}
+
+// HueResultGenerator は回答から HueResult を生成する境界。LLM ベンダーごとに実装を差し替える。
+type HueResultGenerator interface {
+	Generate(ctx context.Context, req HueGenerationRequest) (domain.HueResult, error)
+	// Name は結果と一緒に保存する生成器/モデルの識別子を返す。
+	Name() string
+}
+
+// HueGeneratorConfig は Provider に応じて使う生成器の設定を保持する。
+type HueGeneratorConfig struct {
+	Provider string
+	Endpoint string
+	APIKey   string
+	Model    string
+	// Client はベンダー呼び出しの再試行やブレーカーの設定。
+	Client llm.Config
+}
+
+// NewHueResultGenerator は cfg.Provider に対応する HueResultGenerator を組み立てる。
+// Provider が空の場合は OpenAI Responses API を使う。
+func NewHueResultGenerator(cfg HueGeneratorConfig, client *http.Client) (HueResultGenerator, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if provider == "" {
+		provider = HueGeneratorOpenAIResponses
+	}
+
+	if provider == HueGeneratorFake {
+		return NewFakeHueResultGenerator(), nil
+	}
+
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		return nil, errors.New("HueResultGenerator: endpoint is required")
+	}
+	if strings.TrimSpace(cfg.APIKey) == "" {
+		return nil, errors.New("HueResultGenerator: api key is required")
+	}
+	llmClient := llm.NewClient(client, cfg.Client)
+	model := strings.TrimSpace(cfg.Model)
+	if model == "" {
+		model = defaultHueModel
+	}
+
+	switch provider {
+	case HueGeneratorOpenAIResponses:
+		return NewOpenAIResponsesGenerator(llmClient, cfg.Endpoint, cfg.APIKey, model), nil
+	case HueGeneratorOpenAIChat:
+		return NewOpenAIChatGenerator(llmClient, cfg.Endpoint, cfg.APIKey, model), nil
+	default:
+		return nil, fmt.Errorf("HueResultGenerator: unknown provider %q", cfg.Provider)
+	}
+}
+
+const hueResultSchemaName = "HueAreYouResultResponse"
+
+// hueResultSchema はモデルへ要求する JSON 出力の構造。
+func hueResultSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"hue": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"r": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255},
+					"g": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255},
+					"b": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255},
+				},
+				"required":             []string{"r", "g", "b"},
+				"additionalProperties": false,
+			},
+			"message": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"hue", "message"},
+		"additionalProperties": false,
+	}
+}
+
+// parseHueAnswer はモデルが返した JSON テキストを HueResult に変換する。
+func parseHueAnswer(text string) (domain.HueResult, error) {
+	var answer struct {
+		Hue struct {
+			R int `json:"r"`
+			G int `json:"g"`
+			B int `json:"b"`
+		} `json:"hue"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(text), &answer); err != nil {
+		return domain.HueResult{}, fmt.Errorf("decode hue answer: %w", err)
+	}
+
+	return domain.NewHueResultFromRaw(answer.Hue.R, answer.Hue.G, answer.Hue.B, answer.Message)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"backend/internal/domain"
+)
+
+// FakeHueResultGenerator は API キー無しで動かすためのローカル生成器。
+// 同じ回答には常に同じ色とメッセージを返す。
+type FakeHueResultGenerator struct{}
+
+func NewFakeHueResultGenerator() *FakeHueResultGenerator {
+	return &FakeHueResultGenerator{}
+}
+
+func (g *FakeHueResultGenerator) Name() string {
+	return HueGeneratorFake
+}
+
+func (g *FakeHueResultGenerator) Generate(_ context.Context, req HueGenerationRequest) (domain.HueResult, error) {
+	choices := req.Choices.ToMap()
+	words := make([]string, 0, len(choices))
+	for word := range choices {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	h := fnv.New32a()
+	for _, word := range words {
+		_, _ = h.Write([]byte(word))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(choices[word]))
+		_, _ = h.Write([]byte{0})
+	}
+	sum := h.Sum32()
+
+	message := fmt.Sprintf("ローカル生成器による結果です。%d 個の回答からあなたの色を選びました。", len(words))
+	return domain.NewHueResultFromRaw(int(sum>>16&0xff), int(sum>>8&0xff), int(sum&0xff), message)
+}
+
+// fakeStreamChunkRunes はストリーミング時に 1 回で送る文字数。
+const fakeStreamChunkRunes = 4
+
+// GenerateStream は Generate の結果のメッセージを数文字ずつ onMessage へ渡す。
+func (g *FakeHueResultGenerator) GenerateStream(ctx context.Context, req HueGenerationRequest, onMessage func(delta string) error) (domain.HueResult, error) {
+	result, err := g.Generate(ctx, req)
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+
+	runes := []rune(result.Message())
+	for start := 0; start < len(runes); start += fakeStreamChunkRunes {
+		if err := ctx.Err(); err != nil {
+			return domain.HueResult{}, err
+		}
+		end := min(start+fakeStreamChunkRunes, len(runes))
+		if err := onMessage(string(runes[start:end])); err != nil {
+			return domain.HueResult{}, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"backend/internal/domain"
+	"backend/internal/infra/llm"
+)
+
+// OpenAIResponsesGenerator は OpenAI Responses API (json_schema 形式) で結果を生成する。
+type OpenAIResponsesGenerator struct {
+	client   *llm.Client
+	endpoint string
+	apiKey   string
+	model    string
+}
+
+func NewOpenAIResponsesGenerator(client *llm.Client, endpoint, apiKey, model string) *OpenAIResponsesGenerator {
+	return &OpenAIResponsesGenerator{client: client, endpoint: endpoint, apiKey: apiKey, model: model}
+}
+
+func (g *OpenAIResponsesGenerator) Name() string {
+	return HueGeneratorOpenAIResponses + ":" + g.model
+}
+
+func (g *OpenAIResponsesGenerator) Generate(ctx context.Context, req HueGenerationRequest) (domain.HueResult, error) {
+	body, err := g.client.PostJSON(ctx, g.endpoint, g.apiKey, g.payload(req))
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+	reportResponsesUsage(req, body)
+
+	text, err := parseResponsesOutput(body)
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+
+	return parseHueAnswer(text)
+}
+
+// GenerateStream は stream=true で呼び出し、response.output_text.delta を順に読む。
+func (g *OpenAIResponsesGenerator) GenerateStream(ctx context.Context, req HueGenerationRequest, onMessage func(delta string) error) (domain.HueResult, error) {
+	payload := g.payload(req)
+	payload["stream"] = true
+
+	body, err := g.client.PostStream(ctx, g.endpoint, g.apiKey, payload)
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+	defer body.Close()
+
+	answer := newStreamHueAnswer(onMessage)
+	err = readSSE(body, func(_, data string) error {
+		var event struct {
+			Type    string `json:"type"`
+			Delta   string `json:"delta"`
+			Refusal string `json:"refusal"`
+			Error   *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+			Response json.RawMessage `json:"response"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("decode responses stream: %w", err)
+		}
+
+		switch event.Type {
+		case "response.output_text.delta":
+			return answer.write(event.Delta)
+		case "response.refusal.done":
+			return fmt.Errorf("model refused: %s", event.Refusal)
+		case "response.failed", "error":
+			if event.Error != nil {
+				return fmt.Errorf("responses stream failed: %s", event.Error.Message)
+			}
+			return errors.New("responses stream failed")
+		case "response.completed":
+			reportResponsesUsage(req, event.Response)
+			return errStopSSE
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+
+	return answer.result()
+}
+
+func (g *OpenAIResponsesGenerator) payload(req HueGenerationRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"model": g.model,
+		"input": []map[string]interface{}{
+			{
+				"role": "system",
+				"content": []map[string]string{
+					{"type": "input_text", "text": req.SystemPrompt},
+				},
+			},
+			{
+				"role": "user",
+				"content": []map[string]string{
+					{"type": "input_text", "text": req.UserPrompt},
+				},
+			},
+		},
+		"text": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":   "json_schema",
+				"name":   hueResultSchemaName,
+				"schema": hueResultSchema(),
+				"strict": true,
+			},
+		},
+	}
+}
+
+// parseResponsesOutput は Responses API の応答から最初の output_text を取り出す。
+func parseResponsesOutput(body []byte) (string, error) {
+	var raw struct {
+		Output []struct {
+			Content []struct {
+				Type    string `json:"type"`
+				Text    string `json:"text"`
+				Refusal string `json:"refusal"`
+			} `json:"content"`
+		} `json:"output"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return "", fmt.Errorf("decode responses output: %w", err)
+	}
+
+	for _, output := range raw.Output {
+		for _, content := range output.Content {
+			switch content.Type {
+			case "output_text":
+				return content.Text, nil
+			case "refusal":
+				return "", fmt.Errorf("model refused: %s", content.Refusal)
+			}
+		}
+	}
+
+	return "", errors.New("no content returned")
+}
+
+// reportResponsesUsage は Responses API の応答 (ストリームでは completed イベントの response) から usage を取り出す。
+func reportResponsesUsage(req HueGenerationRequest, body []byte) {
+	var raw struct {
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &raw) != nil || raw.Usage == nil {
+		return
+	}
+	req.reportUsage(raw.Model, raw.Usage.InputTokens, raw.Usage.OutputTokens)
+}
+
+// OpenAIChatGenerator は OpenAI Chat Completions API (response_format=json_schema) で結果を生成する。
+type OpenAIChatGenerator struct {
+	client   *llm.Client
+	endpoint string
+	apiKey   string
+	model    string
+}
+
+func NewOpenAIChatGenerator(client *llm.Client, endpoint, apiKey, model string) *OpenAIChatGenerator {
+	return &OpenAIChatGenerator{client: client, endpoint: endpoint, apiKey: apiKey, model: model}
+}
+
+func (g *OpenAIChatGenerator) Name() string {
+	return HueGeneratorOpenAIChat + ":" + g.model
+}
+
+func (g *OpenAIChatGenerator) Generate(ctx context.Context, req HueGenerationRequest) (domain.HueResult, error) {
+	body, err := g.client.PostJSON(ctx, g.endpoint, g.apiKey, g.payload(req))
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+	reportChatUsage(req, body)
+
+	text, err := parseChatOutput(body)
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+
+	return parseHueAnswer(text)
+}
+
+// GenerateStream は stream=true で呼び出し、choices[0].delta.content を順に読む。
+// usage は stream_options.include_usage を指定したときだけ、choices が空の最後のチャンクで届く。
+func (g *OpenAIChatGenerator) GenerateStream(ctx context.Context, req HueGenerationRequest, onMessage func(delta string) error) (domain.HueResult, error) {
+	payload := g.payload(req)
+	payload["stream"] = true
+	payload["stream_options"] = map[string]interface{}{"include_usage": true}
+
+	body, err := g.client.PostStream(ctx, g.endpoint, g.apiKey, payload)
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+	defer body.Close()
+
+	answer := newStreamHueAnswer(onMessage)
+	err = readSSE(body, func(_, data string) error {
+		if data == "[DONE]" {
+			return errStopSSE
+		}
+
+		var chunk struct {
+			Model   string     `json:"model"`
+			Usage   *chatUsage `json:"usage"`
+			Choices []struct {
+				Delta struct {
+					Content *string `json:"content"`
+					Refusal *string `json:"refusal"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("decode chat stream: %w", err)
+		}
+		if chunk.Usage != nil {
+			req.reportUsage(chunk.Model, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.Refusal != nil && *delta.Refusal != "" {
+			return fmt.Errorf("model refused: %s", *delta.Refusal)
+		}
+		if delta.Content == nil {
+			return nil
+		}
+		return answer.write(*delta.Content)
+	})
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+
+	return answer.result()
+}
+
+func (g *OpenAIChatGenerator) payload(req HueGenerationRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"model": g.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": req.SystemPrompt},
+			{"role": "user", "content": req.UserPrompt},
+		},
+		"response_format": map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   hueResultSchemaName,
+				"schema": hueResultSchema(),
+				"strict": true,
+			},
+		},
+	}
+}
+
+// chatUsage は Chat Completions の usage。
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// reportChatUsage は Chat Completions の応答から usage を取り出す。
+func reportChatUsage(req HueGenerationRequest, body []byte) {
+	var raw struct {
+		Model string     `json:"model"`
+		Usage *chatUsage `json:"usage"`
+	}
+	if json.Unmarshal(body, &raw) != nil || raw.Usage == nil {
+		return
+	}
+	req.reportUsage(raw.Model, raw.Usage.PromptTokens, raw.Usage.CompletionTokens)
+}
+
+// parseChatOutput は Chat Completions の応答から最初の message.content を取り出す。
+func parseChatOutput(body []byte) (string, error) {
+	var raw struct {
+		Choices []struct {
+			Message struct {
+				Content *string `json:"content"`
+				Refusal *string `json:"refusal"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return "", fmt.Errorf("decode chat output: %w", err)
+	}
+
+	if len(raw.Choices) == 0 {
+		return "", errors.New("no content returned")
+	}
+
+	message := raw.Choices[0].Message
+	if message.Refusal != nil && *message.Refusal != "" {
+		return "", fmt.Errorf("model refused: %s", *message.Refusal)
+	}
+	if message.Content == nil || *message.Content == "" {
+		return "", errors.New("no content returned")
+	}
+
+	return *message.Content, nil
+}
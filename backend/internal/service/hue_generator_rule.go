@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"backend/internal/domain"
+)
+
+// HueChoiceCounter は語彙ごとの色の選択回数(母集団)を返す。repository.HueRepository が満たす。
+type HueChoiceCounter interface {
+	CountChoices(ctx context.Context, words []string) (map[string]map[string]int, error)
+}
+
+// atypicalBias は「みんなと違う選択」をどれだけ強く色に反映させるかの係数。
+const atypicalBias = 2.0
+
+// hueColorPhrases は最も寄与した色ごとのメッセージ書き出し。
+var hueColorPhrases = map[domain.HueColor]string{
+	"黒":    "あなたには、静かに物事の奥を見つめる落ち着きがあるようです。",
+	"灰色":   "あなたには、どんな場面でもバランスを大切にするやわらかさがあるようです。",
+	"白":    "あなたには、まっさらな気持ちで物事を受けとめる素直さがあるようです。",
+	"ピンク":  "あなたには、まわりをふんわりと包みこむやさしさがあるようです。",
+	"赤":    "あなたには、心の中に小さな炎のような情熱が灯っているようです。",
+	"オレンジ": "あなたには、まわりを明るく照らすあたたかさがあるようです。",
+	"黄色":   "あなたには、ふとした瞬間に人を笑顔にする軽やかさがあるようです。",
+	"緑":    "あなたには、自分のペースでのびのびと育っていく穏やかさがあるようです。",
+	"青":    "あなたには、澄んだ水のように深く考える誠実さがあるようです。",
+	"紫":    "あなたには、ほかの人が気づかないものを感じとる感性があるようです。",
+	"茶":    "あなたには、大地のようにどっしりとした安心感があるようです。",
+}
+
+// RuleBasedHueResultGenerator は LLM を使わずに回答から結果を導く生成器。
+// パレット色を加重平均し、母集団と比べて珍しい選択ほど重みを大きくする。
+type RuleBasedHueResultGenerator struct {
+	counter HueChoiceCounter
+}
+
+func NewRuleBasedHueResultGenerator(counter HueChoiceCounter) *RuleBasedHueResultGenerator {
+	return &RuleBasedHueResultGenerator{counter: counter}
+}
+
+func (g *RuleBasedHueResultGenerator) Name() string {
+	return HueGeneratorRuleBased
+}
+
+func (g *RuleBasedHueResultGenerator) Generate(ctx context.Context, req HueGenerationRequest) (domain.HueResult, error) {
+	choices := req.Choices.ToMap()
+	words := make([]string, 0, len(choices))
+	for word := range choices {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	// 母集団が取れなくても結果は返したいので、その場合は全選択を同じ重みで扱う。
+	var population map[string]map[string]int
+	if g.counter != nil {
+		if counts, err := g.counter.CountChoices(ctx, words); err == nil {
+			population = counts
+		}
+	}
+
+	var (
+		sumR, sumG, sumB, sumWeight float64
+		colorWeights                = make(map[domain.HueColor]float64)
+		rarestWord                  string
+		rarestShare                 = math.Inf(1)
+	)
+	for _, word := range words {
+		color := domain.HueColor(choices[word])
+		rgb, ok := color.RGB()
+		if !ok {
+			continue
+		}
+
+		share := choiceShare(population[word], string(color))
+		weight := 1 + atypicalBias*(1-share)
+
+		sumR += float64(rgb.R()) * weight
+		sumG += float64(rgb.G()) * weight
+		sumB += float64(rgb.B()) * weight
+		sumWeight += weight
+		colorWeights[color] += weight
+
+		if share < rarestShare {
+			rarestShare = share
+			rarestWord = word
+		}
+	}
+
+	if sumWeight == 0 {
+		return domain.HueResult{}, domain.ErrInvalidChoice
+	}
+
+	hue, err := domain.NewHueRGB(
+		int(math.Round(sumR/sumWeight)),
+		int(math.Round(sumG/sumWeight)),
+		int(math.Round(sumB/sumWeight)),
+	)
+	if err != nil {
+		return domain.HueResult{}, err
+	}
+
+	return domain.NewHueResult(hue, buildRuleBasedMessage(colorWeights, rarestWord, choices[rarestWord], population != nil))
+}
+
+// choiceShare は語彙に対してその色が選ばれた割合を返す。母集団が無ければ 0。
+func choiceShare(counts map[string]int, color string) float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(counts[color]) / float64(total)
+}
+
+func buildRuleBasedMessage(colorWeights map[domain.HueColor]float64, rarestWord, rarestColor string, hasPopulation bool) string {
+	dominant := dominantHueColor(colorWeights)
+
+	var b strings.Builder
+	b.WriteString(hueColorPhrases[dominant])
+	if hasPopulation && rarestWord != "" {
+		fmt.Fprintf(&b, "「%s」に%sを選んだところに、あなたらしさがそっと表れています。", rarestWord, rarestColor)
+	}
+	b.WriteString("今日のあなたの色を、どうぞ大切にしてください。")
+	return b.String()
+}
+
+// dominantHueColor は重みが最大の色を返す。同点ならパレット名の辞書順で先のもの。
+func dominantHueColor(colorWeights map[domain.HueColor]float64) domain.HueColor {
+	var (
+		best       domain.HueColor
+		bestWeight = -1.0
+	)
+	for color, weight := range colorWeights {
+		if weight > bestWeight || (weight == bestWeight && color < best) {
+			best = color
+			bestWeight = weight
+		}
+	}
+	return best
+}
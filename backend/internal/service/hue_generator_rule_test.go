@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestRuleBasedHueResultGenerator_UniformWithoutPopulation(t *testing.T) {
+	gen := NewRuleBasedHueResultGenerator(&fakeChoiceCounter{err: errors.New("db down")})
+	choices, err := domain.NewHueChoices(map[string]string{"平和": "青", "未来": "青"})
+	if err != nil {
+		t.Fatalf("choices error: %v", err)
+	}
+
+	result, err := gen.Generate(context.Background(), HueGenerationRequest{Choices: choices})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	blue, _ := domain.HueColor("青").RGB()
+	if result.Hue() != blue {
+		t.Fatalf("expected palette blue, got %+v", result.Hue())
+	}
+	if !strings.Contains(result.Message(), "誠実さ") {
+		t.Fatalf("expected blue phrase, got %s", result.Message())
+	}
+}
+
+func TestRuleBasedHueResultGenerator_AtypicalChoiceWeighsMore(t *testing.T) {
+	counter := &fakeChoiceCounter{counts: map[string]map[string]int{
+		"平和": {"青": 99, "赤": 1},
+		"朝":  {"赤": 100},
+	}}
+	gen := NewRuleBasedHueResultGenerator(counter)
+	choices, err := domain.NewHueChoices(map[string]string{"平和": "赤", "朝": "白"})
+	if err != nil {
+		t.Fatalf("choices error: %v", err)
+	}
+
+	result, err := gen.Generate(context.Background(), HueGenerationRequest{Choices: choices})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 平和→赤 (1%) と 朝→白 (0%) はどちらも珍しいので重みはほぼ同じになり、赤と白の中間付近になる。
+	red, _ := domain.HueColor("赤").RGB()
+	white, _ := domain.HueColor("白").RGB()
+	if result.Hue().R() <= red.R() || result.Hue().R() >= white.R() {
+		t.Fatalf("expected blend between red and white, got %+v", result.Hue())
+	}
+	if !strings.Contains(result.Message(), "「朝」に白") {
+		t.Fatalf("expected rarest choice in message, got %s", result.Message())
+	}
+
+	again, err := gen.Generate(context.Background(), HueGenerationRequest{Choices: choices})
+	if err != nil || again != result {
+		t.Fatalf("expected deterministic result")
+	}
+}
+
+type fakeChoiceCounter struct {
+	counts map[string]map[string]int
+	err    error
+}
+
+func (f *fakeChoiceCounter) CountChoices(_ context.Context, _ []string) (map[string]map[string]int, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.counts, nil
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"backend/internal/domain"
+)
+
+// maxSSELineBytes はベンダーの SSE 1 行あたりの読み込み上限。
+const maxSSELineBytes = 1 << 20
+
+// HueStreamingGenerator はメッセージを書きながら少しずつ返せる生成器。
+// onMessage には message の未送信部分だけが渡され、戻り値は完成した結果。
+type HueStreamingGenerator interface {
+	HueResultGenerator
+	GenerateStream(ctx context.Context, req HueGenerationRequest, onMessage func(delta string) error) (domain.HueResult, error)
+}
+
+// readSSE は SSE を読み、イベントごとに (event 名, data) を fn へ渡す。fn が errStopSSE を返すと正常終了する。
+func readSSE(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELineBytes)
+
+	var (
+		event string
+		data  []string
+	)
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return stopOrErr(err)
+			}
+		case strings.HasPrefix(line, ":"):
+			// コメント (keep-alive)
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return stopOrErr(dispatch())
+}
+
+// errStopSSE は readSSE を途中で正常終了させる。
+var errStopSSE = fmt.Errorf("stop sse")
+
+func stopOrErr(err error) error {
+	if err == errStopSSE {
+		return nil
+	}
+	return err
+}
+
+// streamHueAnswer はベンダーから届く JSON テキストの断片を集め、message の部分を onMessage へ流す。
+// 最後に集めたテキスト全体を結果として解釈する。
+type streamHueAnswer struct {
+	text      strings.Builder
+	extractor hueMessageExtractor
+	onMessage func(delta string) error
+}
+
+func newStreamHueAnswer(onMessage func(delta string) error) *streamHueAnswer {
+	return &streamHueAnswer{onMessage: onMessage}
+}
+
+func (s *streamHueAnswer) write(chunk string) error {
+	s.text.WriteString(chunk)
+	if delta := s.extractor.feed(chunk); delta != "" {
+		return s.onMessage(delta)
+	}
+	return nil
+}
+
+func (s *streamHueAnswer) result() (domain.HueResult, error) {
+	if s.text.Len() == 0 {
+		return domain.HueResult{}, fmt.Errorf("no content returned")
+	}
+	return parseHueAnswer(s.text.String())
+}
+
+var hueMessageKey = regexp.MustCompile(`"message"\s*:\s*"`)
+
+// hueMessageExtractor は書きかけの JSON から "message" の文字列値を少しずつ取り出す。
+// スキーマ上 hue が先に来るので、message は出力の末尾側に現れる。
+type hueMessageExtractor struct {
+	buf      string
+	pos      int
+	started  bool
+	finished bool
+}
+
+// feed は chunk を追加し、新たに確定した message の文字列 (エスケープ解除済み) を返す。
+func (e *hueMessageExtractor) feed(chunk string) string {
+	if e.finished {
+		return ""
+	}
+	e.buf += chunk
+
+	if !e.started {
+		loc := hueMessageKey.FindStringIndex(e.buf)
+		if loc == nil {
+			return ""
+		}
+		e.started = true
+		e.pos = loc[1]
+	}
+
+	var out strings.Builder
+	for e.pos < len(e.buf) {
+		c := e.buf[e.pos]
+		switch {
+		case c == '"':
+			e.finished = true
+			return out.String()
+		case c == '\\':
+			r, size, ok := decodeJSONEscape(e.buf[e.pos:])
+			if !ok {
+				// エスケープの途中で切れているので続きを待つ。
+				return out.String()
+			}
+			out.WriteRune(r)
+			e.pos += size
+		default:
+			r, size := utf8.DecodeRuneInString(e.buf[e.pos:])
+			if r == utf8.RuneError && size <= 1 && !utf8.FullRuneInString(e.buf[e.pos:]) {
+				return out.String()
+			}
+			out.WriteRune(r)
+			e.pos += size
+		}
+	}
+	return out.String()
+}
+
+// decodeJSONEscape は s の先頭のエスケープ列を解釈する。足りなければ ok=false。
+func decodeJSONEscape(s string) (rune, int, bool) {
+	if len(s) < 2 {
+		return 0, 0, false
+	}
+	switch s[1] {
+	case '"', '\\', '/':
+		return rune(s[1]), 2, true
+	case 'b':
+		return '\b', 2, true
+	case 'f':
+		return '\f', 2, true
+	case 'n':
+		return '\n', 2, true
+	case 'r':
+		return '\r', 2, true
+	case 't':
+		return '\t', 2, true
+	case 'u':
+		if len(s) < 6 {
+			return 0, 0, false
+		}
+		v, err := strconv.ParseUint(s[2:6], 16, 32)
+		if err != nil {
+			return utf8.RuneError, 6, true
+		}
+		r := rune(v)
+		if !utf16.IsSurrogate(r) {
+			return r, 6, true
+		}
+		// サロゲートペアは後半の \uXXXX まで揃ってから解釈する。
+		if len(s) < 12 {
+			return 0, 0, false
+		}
+		low, err := strconv.ParseUint(s[8:12], 16, 32)
+		if err != nil || s[6] != '\\' || s[7] != 'u' {
+			return utf8.RuneError, 6, true
+		}
+		return utf16.DecodeRune(r, rune(low)), 12, true
+	default:
+		return utf8.RuneError, 2, true
+	}
+}
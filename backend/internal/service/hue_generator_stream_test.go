@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/domain"
+)
+
+func TestHueMessageExtractor_Chunks(t *testing.T) {
+	raw := `{"hue":{"r":1,"g":2,"b":3},"message":"こん\"に\\ちは\n🌈"}`
+	want := "こん\"に\\ちは\n🌈"
+
+	// 1 バイトずつ与えても、まとめて与えても同じメッセージになる。
+	for _, size := range []int{1, 3, 7, len(raw)} {
+		var (
+			e   hueMessageExtractor
+			got strings.Builder
+		)
+		for start := 0; start < len(raw); start += size {
+			end := min(start+size, len(raw))
+			got.WriteString(e.feed(raw[start:end]))
+		}
+		if got.String() != want {
+			t.Fatalf("chunk size %d: expected %q, got %q", size, want, got.String())
+		}
+	}
+}
+
+func TestHueMessageExtractor_NoMessage(t *testing.T) {
+	var e hueMessageExtractor
+	if got := e.feed(`{"hue":{"r":1,"g":2,"b":3}}`); got != "" {
+		t.Fatalf("expected no message, got %q", got)
+	}
+}
+
+func TestOpenAIResponsesGenerator_GenerateStream(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("unexpected accept header: %s", got)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: response.created\ndata: {\"type\":\"response.created\"}\n\n")
+		for _, chunk := range []string{`{"hue":{"r":1,"g":2,"b":3},`, `"mess`, `age":"こん`, `にちは"}`} {
+			b, _ := json.Marshal(map[string]string{"type": "response.output_text.delta", "delta": chunk})
+			fmt.Fprintf(w, "event: response.output_text.delta\ndata: %s\n\n", b)
+		}
+		fmt.Fprint(w, "event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"model\":\"test-model\",\"usage\":{\"input_tokens\":50,\"output_tokens\":9}}}\n\n")
+	}))
+	defer server.Close()
+
+	gen := NewOpenAIResponsesGenerator(newTestLLMClient(server), server.URL, "secret", "test-model")
+	req := buildGenerationRequest(t)
+	var usages []domain.LLMUsage
+	req.OnUsage = func(usage domain.LLMUsage) { usages = append(usages, usage) }
+	var streamed strings.Builder
+	result, err := gen.GenerateStream(context.Background(), req, func(delta string) error {
+		streamed.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Hue().R() != 1 || result.Message() != "こんにちは" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if streamed.String() != "こんにちは" {
+		t.Fatalf("expected streamed message, got %q", streamed.String())
+	}
+	if captured["stream"] != true {
+		t.Fatalf("expected stream to be requested, got %v", captured["stream"])
+	}
+	if len(usages) != 1 || usages[0].InputTokens() != 50 || usages[0].OutputTokens() != 9 {
+		t.Fatalf("unexpected usage: %+v", usages)
+	}
+}
+
+func TestOpenAIResponsesGenerator_GenerateStreamFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "data: {\"type\":\"error\",\"error\":{\"message\":\"overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	gen := NewOpenAIResponsesGenerator(newTestLLMClient(server), server.URL, "secret", "test-model")
+	_, err := gen.GenerateStream(context.Background(), buildGenerationRequest(t), func(string) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "overloaded") {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+}
+
+func TestOpenAIChatGenerator_GenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for _, chunk := range []string{`{"hue":{"r":10,"g":20,"b":30},`, `"message":"o`, `k"}`} {
+			b, _ := json.Marshal(map[string]interface{}{
+				"choices": []interface{}{map[string]interface{}{"delta": map[string]string{"content": chunk}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", b)
+		}
+		fmt.Fprint(w, "data: {\"model\":\"test-model\",\"choices\":[],\"usage\":{\"prompt_tokens\":70,\"completion_tokens\":5}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	gen := NewOpenAIChatGenerator(newTestLLMClient(server), server.URL, "secret", "test-model")
+	req := buildGenerationRequest(t)
+	var usages []domain.LLMUsage
+	req.OnUsage = func(usage domain.LLMUsage) { usages = append(usages, usage) }
+	var deltas []string
+	result, err := gen.GenerateStream(context.Background(), req, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Hue().R() != 10 || result.Message() != "ok" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if strings.Join(deltas, "|") != "o|k" {
+		t.Fatalf("unexpected deltas: %v", deltas)
+	}
+	if len(usages) != 1 || usages[0].InputTokens() != 70 || usages[0].OutputTokens() != 5 {
+		t.Fatalf("unexpected usage: %+v", usages)
+	}
+}
+
+func TestFakeHueResultGenerator_GenerateStream(t *testing.T) {
+	gen := NewFakeHueResultGenerator()
+	var streamed strings.Builder
+	result, err := gen.GenerateStream(context.Background(), buildGenerationRequest(t), func(delta string) error {
+		streamed.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if streamed.String() != result.Message() {
+		t.Fatalf("expected streamed message to match result, got %q", streamed.String())
+	}
+}
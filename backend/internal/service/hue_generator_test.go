@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/domain"
+	"backend/internal/infra/llm"
+)
+
+func TestNewHueResultGenerator_Providers(t *testing.T) {
+	fake, err := NewHueResultGenerator(HueGeneratorConfig{Provider: "fake"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := fake.(*FakeHueResultGenerator); !ok {
+		t.Fatalf("expected fake generator, got %T", fake)
+	}
+
+	if _, err := NewHueResultGenerator(HueGeneratorConfig{}, nil); err == nil {
+		t.Fatalf("expected error without endpoint")
+	}
+
+	chat, err := NewHueResultGenerator(HueGeneratorConfig{Provider: "openai-chat", Endpoint: "http://example.com", APIKey: "k"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := chat.(*OpenAIChatGenerator); !ok {
+		t.Fatalf("expected chat generator, got %T", chat)
+	}
+
+	if _, err := NewHueResultGenerator(HueGeneratorConfig{Provider: "unknown", Endpoint: "http://example.com", APIKey: "k"}, nil); err == nil {
+		t.Fatalf("expected error for unknown provider")
+	}
+}
+
+func TestOpenAIResponsesGenerator_Generate(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected authorization header: %s", got)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		_, _ = w.Write([]byte(`{"output":[{"type":"reasoning","content":[]},{"content":[{"type":"output_text","text":"{\"hue\":{\"r\":1,\"g\":2,\"b\":3},\"message\":\"こんにちは\"}"}]}],"model":"test-model-2025","usage":{"input_tokens":120,"output_tokens":30}}`))
+	}))
+	defer server.Close()
+
+	gen := NewOpenAIResponsesGenerator(newTestLLMClient(server), server.URL, "secret", "test-model")
+	req := buildGenerationRequest(t)
+	var usages []domain.LLMUsage
+	req.OnUsage = func(usage domain.LLMUsage) { usages = append(usages, usage) }
+	result, err := gen.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Hue().R() != 1 || result.Hue().G() != 2 || result.Hue().B() != 3 || result.Message() != "こんにちは" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if captured["model"] != "test-model" {
+		t.Fatalf("expected model to be sent, got %v", captured["model"])
+	}
+	if len(usages) != 1 || usages[0].Model() != "test-model-2025" || usages[0].InputTokens() != 120 || usages[0].OutputTokens() != 30 {
+		t.Fatalf("unexpected usage: %+v", usages)
+	}
+}
+
+func TestOpenAIResponsesGenerator_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	gen := NewOpenAIResponsesGenerator(newTestLLMClient(server), server.URL, "secret", "test-model")
+	if _, err := gen.Generate(context.Background(), buildGenerationRequest(t)); err == nil {
+		t.Fatalf("expected error for 500 response")
+	}
+}
+
+func TestOpenAIChatGenerator_Generate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"hue\":{\"r\":10,\"g\":20,\"b\":30},\"message\":\"ok\"}"}}],"model":"test-model","usage":{"prompt_tokens":80,"completion_tokens":12}}`))
+	}))
+	defer server.Close()
+
+	gen := NewOpenAIChatGenerator(newTestLLMClient(server), server.URL, "secret", "test-model")
+	req := buildGenerationRequest(t)
+	var usages []domain.LLMUsage
+	req.OnUsage = func(usage domain.LLMUsage) { usages = append(usages, usage) }
+	result, err := gen.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Hue().R() != 10 || result.Message() != "ok" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(usages) != 1 || usages[0].InputTokens() != 80 || usages[0].OutputTokens() != 12 {
+		t.Fatalf("unexpected usage: %+v", usages)
+	}
+}
+
+func TestParseResponsesOutput_Invalid(t *testing.T) {
+	cases := []string{
+		`not json`,
+		`{"output":[]}`,
+		`{"output":[{"content":[{"type":"refusal","refusal":"no"}]}]}`,
+	}
+	for _, body := range cases {
+		if _, err := parseResponsesOutput([]byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
+
+func TestParseChatOutput_Invalid(t *testing.T) {
+	cases := []string{
+		`not json`,
+		`{"choices":[]}`,
+		`{"choices":[{"message":{"content":null,"refusal":"no"}}]}`,
+	}
+	for _, body := range cases {
+		if _, err := parseChatOutput([]byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
+
+func TestParseHueAnswer_Invalid(t *testing.T) {
+	cases := []string{
+		`{"hue":{"r":300,"g":0,"b":0},"message":"x"}`,
+		`{"hue":{"r":0,"g":0,"b":0},"message":"  "}`,
+		`{"hue":`,
+	}
+	for _, text := range cases {
+		if _, err := parseHueAnswer(text); err == nil {
+			t.Fatalf("expected error for %s", text)
+		}
+	}
+}
+
+func TestFakeHueResultGenerator_Deterministic(t *testing.T) {
+	gen := NewFakeHueResultGenerator()
+	req := buildGenerationRequest(t)
+
+	first, err := gen.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := gen.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected deterministic result, got %+v and %+v", first, second)
+	}
+}
+
+func buildGenerationRequest(t *testing.T) HueGenerationRequest {
+	t.Helper()
+	choices, err := domain.NewHueChoices(map[string]string{"平和": "青", "朝": "赤"})
+	if err != nil {
+		t.Fatalf("choices error: %v", err)
+	}
+	return HueGenerationRequest{
+		SystemPrompt: "system",
+		UserPrompt:   "選択 : (語彙, 色) = (平和, 青), (朝, 赤)",
+		Choices:      choices,
+	}
+}
+
+// newTestLLMClient は再試行しないクライアント。再試行は llm パッケージのテストで確かめる。
+func newTestLLMClient(server *httptest.Server) *llm.Client {
+	return llm.NewClient(server.Client(), llm.Config{MaxAttempts: 1})
+}
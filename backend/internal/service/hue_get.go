@@ -2,76 +2,96 @@ package service
 
 import (
 	"context"
-	"errors"
 	"log"
-	"time"
 
 	"backend/internal/domain"
 	"backend/internal/repository"
-
-	"github.com/jackc/pgx/v5"
 )
 
 type HueGetService struct {
-	hueRepo     *repository.HueRepository
-	sessionRepo *repository.LoginSessionRepository
-	userRepo    *repository.UserRepository
-	logger      *log.Logger
+	hueRepo *repository.HueRepository
+	logger  *log.Logger
 }
 
-func NewHueGetService(hueRepo *repository.HueRepository, sessionRepo *repository.LoginSessionRepository, userRepo *repository.UserRepository, logger *log.Logger) *HueGetService {
+func NewHueGetService(hueRepo *repository.HueRepository, logger *log.Logger) *HueGetService {
 	if logger == nil {
 		logger = log.Default()
 	}
 	return &HueGetService{
-		hueRepo:     hueRepo,
-		sessionRepo: sessionRepo,
-		userRepo:    userRepo,
-		logger:      logger,
+		hueRepo: hueRepo,
+		logger:  logger,
 	}
 }
 
-func (s *HueGetService) GetData(ctx context.Context, session domain.SessionData, recordRange domain.RecordRange) ([]domain.HueRecord, error) {
-	loginSession, err := s.sessionRepo.Find(ctx, session.UserID(), session.Token())
+// GetData は query の条件でレコードを 1 ページ分返す。管理者のみ。
+func (s *HueGetService) GetData(ctx context.Context, principal domain.Principal, query domain.HueRecordQuery) (domain.HueRecordPage, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HueRecordPage{}, err
+	}
+
+	page, err := s.hueRepo.FindPage(ctx, query)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			s.logError("session not found", err)
-			return nil, domain.ErrInvalidLoginSession
-		}
-		s.logError("find session", err)
-		return nil, err
+		s.logError("fetch hue records", err)
+		return domain.HueRecordPage{}, err
 	}
 
-	if loginSession.IsExpired(time.Now()) {
-		s.logError("session expired", domain.ErrExpiredToken)
-		if delErr := s.sessionRepo.DeleteByID(ctx, loginSession.ID()); delErr != nil {
-			s.logError("cleanup expired session", delErr)
-		}
-		return nil, domain.ErrExpiredToken
+	return page, nil
+}
+
+// GetUserResults は principal のユーザー自身の回答を古い順に 1 ページ分返す。ロールは問わない。
+// query の絞り込み条件のユーザーは principal のユーザーで上書きする。
+func (s *HueGetService) GetUserResults(ctx context.Context, principal domain.Principal, query domain.HueRecordQuery) (domain.HueRecordPage, error) {
+	if principal.IsZero() {
+		return domain.HueRecordPage{}, domain.ErrInvalidLoginSession
 	}
 
-	user, err := s.userRepo.FindByID(ctx, session.UserID())
+	page, err := s.hueRepo.FindPage(ctx, query.WithFilter(query.Filter().WithUserID(principal.UserID())))
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			s.logError("user not found", err)
-			return nil, domain.ErrInvalidLoginSession
-		}
-		s.logError("find user by id", err)
-		return nil, err
+		s.logError("fetch user hue records", err)
+		return domain.HueRecordPage{}, err
 	}
 
-	if user.Role() != domain.UserRoleAdmin {
-		s.logError("non-admin access", domain.ErrInvalidLoginSession)
-		return nil, domain.ErrInvalidLoginSession
+	return page, nil
+}
+
+// GetStats は期間内の回答を語彙ごとに集計した統計を返す。管理者のみ。
+func (s *HueGetService) GetStats(ctx context.Context, principal domain.Principal, window domain.TimeWindow) (domain.HueStats, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HueStats{}, err
 	}
 
-	records, err := s.hueRepo.FindRange(ctx, recordRange)
+	stats, err := s.hueRepo.Stats(ctx, window)
 	if err != nil {
-		s.logError("fetch hue records", err)
-		return nil, err
+		s.logError("aggregate hue stats", err)
+		return domain.HueStats{}, err
 	}
 
-	return records, nil
+	return stats, nil
+}
+
+// Export は絞り込み条件を満たすレコードを sink へ順に流す。管理者のみ。
+// 認可に失敗した場合は sink に何も渡さないので、呼び出し側はエラー応答を返せる。
+func (s *HueGetService) Export(ctx context.Context, principal domain.Principal, filter domain.HueRecordFilter, sink domain.HueRecordSink) error {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return err
+	}
+
+	// 書き出し先の失敗 (クライアントの切断など) はリポジトリの障害としてログに残さない。
+	var sinkErr error
+	err := s.hueRepo.Export(ctx, filter,
+		func(words []string) error {
+			sinkErr = sink.Begin(words)
+			return sinkErr
+		},
+		func(record domain.HueRecord) error {
+			sinkErr = sink.Write(record)
+			return sinkErr
+		},
+	)
+	if err != nil && sinkErr == nil {
+		s.logError("export hue records", err)
+	}
+	return err
 }
 
 func (s *HueGetService) logError(action string, err error) {
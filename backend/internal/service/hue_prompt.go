@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// HuePromptService はプロンプトの版を一覧・作成・有効化する管理者向けユースケース。
+type HuePromptService struct {
+	promptRepo *repository.HuePromptRepository
+	logger     *log.Logger
+}
+
+func NewHuePromptService(promptRepo *repository.HuePromptRepository, logger *log.Logger) *HuePromptService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HuePromptService{
+		promptRepo: promptRepo,
+		logger:     logger,
+	}
+}
+
+// List はすべての版を作成順に返す。管理者のみ。
+func (s *HuePromptService) List(ctx context.Context, principal domain.Principal) ([]domain.HuePromptVersion, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	prompts, err := s.promptRepo.List(ctx)
+	if err != nil {
+		s.logError("list hue prompts", err)
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// Create は新しい版を無効な状態で保存する。割り当てるには Activate する。管理者のみ。
+func (s *HuePromptService) Create(ctx context.Context, principal domain.Principal, prompt domain.HuePromptVersion) error {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.promptRepo.Create(ctx, prompt); err != nil {
+		if !errors.Is(err, domain.ErrDuplicatePromptVersion) {
+			s.logError("create hue prompt", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Activate は version を weight の重みで割り当て対象にする。exclusive なら他の版を外す。管理者のみ。
+func (s *HuePromptService) Activate(ctx context.Context, principal domain.Principal, version string, weight int, exclusive bool) (domain.HuePromptVersion, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HuePromptVersion{}, err
+	}
+
+	prompt, err := s.promptRepo.FindByVersion(ctx, version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HuePromptVersion{}, domain.ErrPromptVersionNotFound
+		}
+		s.logError("find hue prompt", err)
+		return domain.HuePromptVersion{}, err
+	}
+
+	prompt, err = prompt.Activate(weight, time.Now())
+	if err != nil {
+		return domain.HuePromptVersion{}, err
+	}
+
+	if err := s.promptRepo.Activate(ctx, prompt, exclusive); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HuePromptVersion{}, domain.ErrPromptVersionNotFound
+		}
+		s.logError("activate hue prompt", err)
+		return domain.HuePromptVersion{}, err
+	}
+	return prompt, nil
+}
+
+func (s *HuePromptService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HuePromptService] %s: %v", action, err)
+}
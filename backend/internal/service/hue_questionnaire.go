@@ -0,0 +1,15 @@
+package service
+
+import "backend/internal/domain"
+
+// HueQuestionnaireService は出題する質問票を返す。質問票はコードで版管理している。
+type HueQuestionnaireService struct{}
+
+func NewHueQuestionnaireService() *HueQuestionnaireService {
+	return &HueQuestionnaireService{}
+}
+
+// Questionnaire は version の質問票を返す。空なら現行の版、未知の版なら ErrUnknownQuestionnaire。
+func (s *HueQuestionnaireService) Questionnaire(version string) (domain.Questionnaire, error) {
+	return domain.FindQuestionnaire(version)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// HueResultService は共有用の結果ページ (パーマリンク) を引くユースケース。
+type HueResultService struct {
+	hueRepo *repository.HueRepository
+	jobRepo *repository.HueJobRepository
+	logger  *log.Logger
+}
+
+func NewHueResultService(hueRepo *repository.HueRepository, jobRepo *repository.HueJobRepository, logger *log.Logger) *HueResultService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueResultService{hueRepo: hueRepo, jobRepo: jobRepo, logger: logger}
+}
+
+// GetResult は生成済みのレコードを返す。未生成または存在しない場合は ErrHueRecordNotFound。
+func (s *HueResultService) GetResult(ctx context.Context, id uuid.UUID) (domain.HueRecord, error) {
+	record, err := s.hueRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HueRecord{}, domain.ErrHueRecordNotFound
+		}
+		s.logError("find hue record", err)
+		return domain.HueRecord{}, err
+	}
+
+	if _, ok := record.Result(); !ok {
+		return domain.HueRecord{}, domain.ErrHueRecordNotFound
+	}
+
+	return record, nil
+}
+
+// GetStatus はレコードと結果生成の状態を返す。結果があれば succeeded、無ければジョブの状態と直近の失敗の原因。
+// レコードもジョブも無い場合は ErrHueRecordNotFound。
+func (s *HueResultService) GetStatus(ctx context.Context, id uuid.UUID) (domain.HueRecord, domain.HueResultStatus, error) {
+	record, err := s.hueRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HueRecord{}, domain.HueResultStatus{}, domain.ErrHueRecordNotFound
+		}
+		s.logError("find hue record", err)
+		return domain.HueRecord{}, domain.HueResultStatus{}, err
+	}
+
+	if _, ok := record.Result(); ok {
+		return record, domain.NewHueResultStatus(domain.HueJobStatusSucceeded, domain.HueJobFailureNone), nil
+	}
+
+	job, err := s.jobRepo.FindByRecordID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HueRecord{}, domain.HueResultStatus{}, domain.ErrHueRecordNotFound
+		}
+		s.logError("find hue job", err)
+		return domain.HueRecord{}, domain.HueResultStatus{}, err
+	}
+
+	// 結果の書き込みとジョブの確定の間に読んだ場合は、まだ生成中として扱う。
+	status := job.Status()
+	if status == domain.HueJobStatusSucceeded {
+		status = domain.HueJobStatusRunning
+	}
+	return record, domain.NewHueResultStatus(status, job.Failure()), nil
+}
+
+func (s *HueResultService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueResultService] %s: %v", action, err)
+}
@@ -3,169 +3,320 @@ package service
 import (
 	"backend/internal/domain"
 	"backend/internal/repository"
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"strings"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+// defaultHueJobMaxAttempts は結果生成ジョブを試す回数の既定値。
+const defaultHueJobMaxAttempts = 5
+
 type HueSaveConfig struct {
-	Endpoint     string
-	APIKey       string
-	SystemPrompt string
+	// MaxAttempts は結果生成ジョブを試す回数。0 なら defaultHueJobMaxAttempts。
+	MaxAttempts int
+	// Usage は 1 日の予算の判定に使う単価表と予算。
+	Usage HueUsageConfig
 }
 
 type HueSaveService struct {
-	hueRepo      *repository.HueRepository
-	logger       *log.Logger
-	endpoint     string
-	apiKey       string
-	systemPrompt string
+	hueRepo     *repository.HueRepository
+	promptRepo  *repository.HuePromptRepository
+	usageRepo   *repository.HueUsageRepository
+	cache       *HueResultCache
+	generator   HueResultGenerator
+	fallback    HueResultGenerator
+	broker      *HueMessageBroker
+	logger      *log.Logger
+	maxAttempts int
+	usage       HueUsageConfig
+	// roll はプロンプトの版を割り当てる [0, 1) の乱数。
+	roll func() float64
+	now  func() time.Time
 }
 
-func NewHueSaveService(hueRepo *repository.HueRepository, logger *log.Logger, cfg HueSaveConfig) (*HueSaveService, error) {
+// NewHueSaveService は generator が失敗したときに fallback (nil 可) で結果を補う。
+// broker (nil 可) を渡すと、generator がストリーミングに対応していれば生成中のメッセージを配る。
+// 今日の推定費用が cfg.Usage の予算に達すると、generator を呼ばずに fallback で結果を作る。
+// cache (nil 可) を渡すと、同じ回答には generator の結果を使い回す。
+func NewHueSaveService(hueRepo *repository.HueRepository, promptRepo *repository.HuePromptRepository, usageRepo *repository.HueUsageRepository, cache *HueResultCache, generator, fallback HueResultGenerator, broker *HueMessageBroker, logger *log.Logger, cfg HueSaveConfig) (*HueSaveService, error) {
 	if logger == nil {
 		logger = log.Default()
 	}
-	if strings.TrimSpace(cfg.Endpoint) == "" {
-		return nil, errors.New("HueSaveService: endpoint is required")
-	}
-	if strings.TrimSpace(cfg.APIKey) == "" {
-		return nil, errors.New("HueSaveService: api key is required")
+	if generator == nil {
+		return nil, errors.New("HueSaveService: generator is required")
 	}
-	if strings.TrimSpace(cfg.SystemPrompt) == "" {
-		return nil, errors.New("HueSaveService: system prompt is required")
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultHueJobMaxAttempts
 	}
 	return &HueSaveService{
-		hueRepo:      hueRepo,
-		logger:       logger,
-		endpoint:     cfg.Endpoint,
-		apiKey:       cfg.APIKey,
-		systemPrompt: cfg.SystemPrompt,
+		hueRepo:     hueRepo,
+		promptRepo:  promptRepo,
+		usageRepo:   usageRepo,
+		cache:       cache,
+		generator:   generator,
+		fallback:    fallback,
+		broker:      broker,
+		logger:      logger,
+		maxAttempts: maxAttempts,
+		usage:       cfg.Usage,
+		roll:        rand.Float64,
+		now:         time.Now,
 	}, nil
 }
 
-func (s *HueSaveService) SaveResult(ctx context.Context, record domain.HueRecord) (domain.HueResult, error) {
-	if err := s.hueRepo.Save(ctx, record); err != nil {
-		s.logger.Printf("[HueSaveService] save hue record: %v", err)
-		return domain.HueResult{}, err
+// SaveResult はプロンプトの版を割り当ててレコードを保存し、結果生成ジョブを積む。
+// 結果はワーカーが GenerateResult で作る。
+// key がゼロ値でなければ、同じキーの再送には最初に受け付けたジョブを返し、内容が違えば domain.ErrIdempotencyKeyConflict。
+func (s *HueSaveService) SaveResult(ctx context.Context, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error) {
+	return s.save(ctx, record, key, false)
+}
+
+// SaveUserResult はログイン中のユーザーの回答として SaveResult する。
+func (s *HueSaveService) SaveUserResult(ctx context.Context, principal domain.Principal, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error) {
+	if principal.IsZero() {
+		return domain.HueGenerationJob{}, domain.ErrInvalidLoginSession
 	}
+	return s.save(ctx, record.WithUserID(principal.UserID()), key, false)
+}
 
-	system := strings.ReplaceAll(s.systemPrompt, "\n", "")
+// SaveResultWithoutCache は結果キャッシュを使わずに生成させる SaveResult。プロンプトの確認などに使う。管理者のみ。
+// レコードは管理者自身の回答として紐付ける。
+func (s *HueSaveService) SaveResultWithoutCache(ctx context.Context, principal domain.Principal, record domain.HueRecord, key domain.IdempotencyKey) (domain.HueGenerationJob, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HueGenerationJob{}, err
+	}
+	return s.save(ctx, record.WithUserID(principal.UserID()), key, true)
+}
 
-	user := ""
-	for k, v := range record.Choices().ToMap() {
-		if user == "" {
-			user = fmt.Sprintf("選択 : (語彙, 色) = (%v, %v)", k, v)
-		} else {
-			user += fmt.Sprintf(", (%v, %v)", k, v)
+func (s *HueSaveService) save(ctx context.Context, record domain.HueRecord, key domain.IdempotencyKey, bypassCache bool) (domain.HueGenerationJob, error) {
+	if !key.IsZero() {
+		if job, ok, err := s.replay(ctx, key); err != nil || ok {
+			return job, err
 		}
 	}
 
-	payload := map[string]interface{}{
-		"model": "gpt-4.1",
-		"input": []map[string]interface{}{
-			{
-				"role": "system",
-				"content": []map[string]string{
-					{"type": "input_text", "text": system},
-				},
-			},
-			{
-				"role": "user",
-				"content": []map[string]string{
-					{"type": "input_text", "text": user},
-				},
-			},
-		},
-		"text": map[string]interface{}{
-			"format": map[string]interface{}{
-				"type": "json_schema",
-				"name": "HueAreYouResultResponse",
-				"schema": map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"hue": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"r": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255},
-								"g": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255},
-								"b": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255},
-							},
-							"required":             []string{"r", "g", "b"},
-							"additionalProperties": false,
-						},
-						"message": map[string]interface{}{"type": "string"},
-					},
-					"required":             []string{"hue", "message"},
-					"additionalProperties": false,
-				},
-				"strict": true,
-			},
+	prompt, err := s.assignPrompt(ctx)
+	if err != nil {
+		return domain.HueGenerationJob{}, err
+	}
+	record = record.WithPromptVersion(prompt.Version())
+
+	job, err := domain.NewHueGenerationJob(record.ID(), s.maxAttempts, s.now())
+	if err != nil {
+		return domain.HueGenerationJob{}, err
+	}
+	job = job.WithBypassCache(bypassCache)
+
+	if err := s.hueRepo.SaveWithJob(ctx, record, job, key); err != nil {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			// 同じキーの送信が先に確定した。その応答を返す。
+			replayed, ok, replayErr := s.replay(ctx, key)
+			if replayErr == nil && !ok {
+				replayErr = domain.ErrIdempotencyKeyConflict
+			}
+			return replayed, replayErr
+		}
+		s.logError("save hue record", err)
+		return domain.HueGenerationJob{}, err
+	}
+
+	return job, nil
+}
+
+// replay は key で受け付け済みの送信があれば、そのときのジョブを返す。無ければ false。
+// 同じキーで内容が違えば domain.ErrIdempotencyKeyConflict。
+func (s *HueSaveService) replay(ctx context.Context, key domain.IdempotencyKey) (domain.HueGenerationJob, bool, error) {
+	requestHash, job, err := s.hueRepo.FindIdempotentJob(ctx, key.Key(), time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HueGenerationJob{}, false, nil
+		}
+		s.logError("find idempotency key", err)
+		return domain.HueGenerationJob{}, false, err
+	}
+	if requestHash != key.RequestHash() {
+		return domain.HueGenerationJob{}, false, domain.ErrIdempotencyKeyConflict
+	}
+	return job, true, nil
+}
+
+// GenerateResult はジョブのレコードの結果を生成して書き戻す。生成済みなら何もしない。
+// ジョブは再実行されうるので、同じレコードに対して何度呼ばれてもよい。
+func (s *HueSaveService) GenerateResult(ctx context.Context, job domain.HueGenerationJob) error {
+	record, err := s.hueRepo.FindByID(ctx, job.RecordID())
+	if err != nil {
+		s.logError("find hue record", err)
+		return err
+	}
+	if _, ok := record.Result(); ok {
+		return nil
+	}
+
+	prompt, err := s.findPrompt(ctx, record)
+	if err != nil {
+		return err
+	}
+	systemPrompt, userPrompt, err := prompt.Render(domain.NewHuePromptData(record))
+	if err != nil {
+		s.logError("render hue prompt", err)
+		return err
+	}
+
+	started := time.Now()
+	cacheKey := domain.NewHueResultCacheKey(record, prompt, s.generator.Name())
+	if s.cache != nil {
+		if job.BypassesCache() {
+			s.cache.Bypass()
+		} else if cached, ok := s.cache.Get(ctx, cacheKey); ok {
+			return s.storeResult(ctx, record, cached, s.generator, prompt, started)
+		}
+	}
+
+	req := HueGenerationRequest{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+		Choices:      record.Choices(),
+		OnUsage: func(usage domain.LLMUsage) {
+			s.recordUsage(ctx, record.ID(), usage)
 		},
 	}
 
-	b, err := json.Marshal(payload)
+	var onMessage func(delta string) error
+	if s.broker != nil {
+		// 結果を書き戻してから終わらせるので、購読者は終了後の問い合わせで必ず結果を得る。
+		s.broker.Begin(record.ID())
+		defer s.broker.End(record.ID())
+		onMessage = func(delta string) error {
+			s.broker.Publish(record.ID(), delta)
+			return nil
+		}
+	}
+
+	result, generator, err := s.generate(ctx, req, onMessage)
 	if err != nil {
-		return domain.HueResult{}, err
+		return err
+	}
+	// fallback の結果は安く作れるうえ予算超過中の代替なので使い回さない。
+	if s.cache != nil && result.Source() == domain.HueResultSourceModel {
+		s.cache.Put(ctx, cacheKey, result)
 	}
 
-	req, _ := http.NewRequest("POST", s.endpoint, bytes.NewBuffer(b))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	return s.storeResult(ctx, record, result, generator, prompt, started)
+}
 
-	res, _ := http.DefaultClient.Do(req)
-	defer res.Body.Close()
+// storeResult は生成結果をレコードへ書き戻す。
+func (s *HueSaveService) storeResult(ctx context.Context, record domain.HueRecord, result domain.HueResult, generator HueResultGenerator, prompt domain.HuePromptVersion, started time.Time) error {
+	stored, err := domain.NewHueRecordResult(result, generator.Name(), prompt.Version(), time.Since(started), time.Now())
+	if err != nil {
+		s.logError("build hue record result", err)
+		return err
+	}
+	if err := s.hueRepo.UpdateResult(ctx, record.ID(), stored); err != nil {
+		s.logError("update hue record result", err)
+		return err
+	}
 
-	fmt.Println("status:", res.Status)
+	return nil
+}
 
-	body, _ := io.ReadAll(res.Body)
+// generate は generator で結果を作り、失敗したら fallback で補う。実際に使った生成器も返す。
+// onMessage が nil でなく generator がストリーミングに対応していれば、メッセージを書けた分から渡す。
+// 予算を超えていれば generator は呼ばない。
+func (s *HueSaveService) generate(ctx context.Context, req HueGenerationRequest, onMessage func(delta string) error) (domain.HueResult, HueResultGenerator, error) {
+	err := s.checkBudget(ctx)
+	if err == nil {
+		var result domain.HueResult
+		if streaming, ok := s.generator.(HueStreamingGenerator); ok && onMessage != nil {
+			result, err = streaming.GenerateStream(ctx, req, onMessage)
+		} else {
+			result, err = s.generator.Generate(ctx, req)
+		}
+		if err == nil {
+			return result.WithSource(domain.HueResultSourceModel), s.generator, nil
+		}
+	}
+	s.logError("generate hue result", err)
 
-	var raw struct {
-		Output []struct {
-			Content []struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			} `json:"content"`
-		} `json:"output"`
+	if s.fallback == nil {
+		return domain.HueResult{}, nil, err
 	}
 
-	if err := json.Unmarshal(body, &raw); err != nil {
-		return domain.HueResult{}, err
+	result, fallbackErr := s.fallback.Generate(ctx, req)
+	if fallbackErr != nil {
+		s.logError("generate fallback hue result", fallbackErr)
+		return domain.HueResult{}, nil, errors.Join(err, fallbackErr)
 	}
 
-	if len(raw.Output) == 0 || len(raw.Output[0].Content) == 0 {
-		return domain.HueResult{}, errors.New("no content returned")
+	return result.WithSource(domain.HueResultSourceFallback), s.fallback, nil
+}
+
+// checkBudget は今日 (UTC) の推定費用が予算に達していれば domain.ErrUsageBudgetExceeded を返す。
+// 集計に失敗したときは生成を止めない。
+func (s *HueSaveService) checkBudget(ctx context.Context) error {
+	if s.usage.DailyBudget <= 0 {
+		return nil
 	}
 
-	jsonText := raw.Output[0].Content[0].Text
+	totals, err := s.usageRepo.DailyTotals(ctx, domain.UsageDayWindow(s.now()))
+	if err != nil {
+		s.logError("aggregate llm usage", err)
+		return nil
+	}
+	if s.usage.Prices.TotalCost(totals) >= s.usage.DailyBudget {
+		return domain.ErrUsageBudgetExceeded
+	}
+	return nil
+}
 
-	var answer struct {
-		Hue struct {
-			R int `json:"r"`
-			G int `json:"g"`
-			B int `json:"b"`
-		} `json:"hue"`
-		Message string `json:"message"`
+// recordUsage は generator が返したトークン数を保存する。保存に失敗しても結果の生成は続ける。
+func (s *HueSaveService) recordUsage(ctx context.Context, recordID uuid.UUID, usage domain.LLMUsage) {
+	if err := s.usageRepo.Record(ctx, recordID, s.generator.Name(), usage, s.now()); err != nil {
+		s.logError("record llm usage", err)
 	}
-	if err := json.Unmarshal([]byte(jsonText), &answer); err != nil {
-		return domain.HueResult{}, err
+}
+
+// assignPrompt は有効な版から重みの比で 1 つ選ぶ。
+func (s *HueSaveService) assignPrompt(ctx context.Context) (domain.HuePromptVersion, error) {
+	active, err := s.promptRepo.FindActive(ctx)
+	if err != nil {
+		s.logError("find active hue prompts", err)
+		return domain.HuePromptVersion{}, err
 	}
 
-	hue, err := domain.NewHueRGB(answer.Hue.R, answer.Hue.G, answer.Hue.B)
+	prompt, err := domain.ChooseHuePromptVersion(active, s.roll())
 	if err != nil {
-		return domain.HueResult{}, err
+		s.logError("assign hue prompt", err)
+		return domain.HuePromptVersion{}, err
+	}
+	return prompt, nil
+}
+
+// findPrompt はレコードに割り当てた版を返す。版の導入前のレコードにはここで割り当てる。
+func (s *HueSaveService) findPrompt(ctx context.Context, record domain.HueRecord) (domain.HuePromptVersion, error) {
+	if record.PromptVersion() == "" {
+		return s.assignPrompt(ctx)
 	}
 
-	result, err := domain.NewHueResult(hue, answer.Message)
+	prompt, err := s.promptRepo.FindByVersion(ctx, record.PromptVersion())
 	if err != nil {
-		return domain.HueResult{}, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.HuePromptVersion{}, domain.ErrPromptVersionNotFound
+		}
+		s.logError("find hue prompt", err)
+		return domain.HuePromptVersion{}, err
 	}
+	return prompt, nil
+}
 
-	return result, nil
+func (s *HueSaveService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueSaveService] %s: %v", action, err)
 }
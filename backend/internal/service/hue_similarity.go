@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const defaultHueSimilarityInterval = 10 * time.Minute
+
+// HueSimilarityConfig は索引を作り直す間隔。0 なら既定値を使う。
+type HueSimilarityConfig struct {
+	Interval time.Duration
+}
+
+func (c HueSimilarityConfig) withDefaults() HueSimilarityConfig {
+	if c.Interval <= 0 {
+		c.Interval = defaultHueSimilarityInterval
+	}
+	return c
+}
+
+// HueSimilarityService は回答が似た参加者を探すユースケース。
+// リクエストごとに全回答を走査しないよう、回答の公開を許可した回答の転置索引をメモリに持ち、
+// Run をサーバープロセス内で動かして Interval ごとに作り直す。作り直すまでに保存された回答は候補に入らない。
+type HueSimilarityService struct {
+	hueRepo *repository.HueRepository
+	logger  *log.Logger
+	cfg     HueSimilarityConfig
+
+	mu    sync.RWMutex
+	index domain.HueSimilarityIndex
+}
+
+func NewHueSimilarityService(hueRepo *repository.HueRepository, logger *log.Logger, cfg HueSimilarityConfig) *HueSimilarityService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueSimilarityService{
+		hueRepo: hueRepo,
+		logger:  logger,
+		cfg:     cfg.withDefaults(),
+	}
+}
+
+// Run は起動直後と、その後 Interval ごとに ctx が終わるまで索引を作り直す。
+func (s *HueSimilarityService) Run(ctx context.Context) {
+	for {
+		if err := s.Reindex(ctx); err != nil && ctx.Err() == nil {
+			s.logError("reindex", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.Interval):
+		}
+	}
+}
+
+// Reindex は全回答を読み込んで索引を作り直す。失敗したときは前の索引を使い続ける。
+func (s *HueSimilarityService) Reindex(ctx context.Context) error {
+	var records []domain.HueRecord
+	if err := s.hueRepo.Each(ctx, domain.HueRecordFilter{}, func(record domain.HueRecord) error {
+		if _, ok := record.Result(); ok && record.SharesChoices() {
+			records = append(records, record)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	index := domain.NewHueSimilarityIndex(records)
+
+	s.mu.Lock()
+	s.index = index
+	s.mu.Unlock()
+
+	return nil
+}
+
+// GetSimilar は query のレコードに回答が似た、公開を許可した結果生成済みの他の参加者を似ている順に返す。
+// 基準のレコードが無ければ ErrHueRecordNotFound。索引を作る前は空で返す。
+func (s *HueSimilarityService) GetSimilar(ctx context.Context, query domain.HueSimilarityQuery) ([]domain.HueSimilarity, error) {
+	record, err := s.hueRepo.FindByID(ctx, query.RecordID())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrHueRecordNotFound
+		}
+		s.logError("find hue record", err)
+		return nil, err
+	}
+
+	s.mu.RLock()
+	index := s.index
+	s.mu.RUnlock()
+
+	return index.Find(record, query.Limit()), nil
+}
+
+func (s *HueSimilarityService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueSimilarityService] %s: %v", action, err)
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// defaultHueStreamPoll は生成が始まるのを待つ間に状態を問い合わせる間隔。
+const defaultHueStreamPoll = 500 * time.Millisecond
+
+// HueMessageBroker は生成中のメッセージを同じプロセス内の購読者へ配る。
+// ワーカーとサーバーが同じプロセスで動くことを前提にしており、届かない購読者は状態の問い合わせで補う。
+type HueMessageBroker struct {
+	mu      sync.Mutex
+	streams map[uuid.UUID]*hueMessageStream
+}
+
+// hueMessageStream は 1 回の生成ぶんのメッセージ。changed は更新のたびに close して作り直す。
+type hueMessageStream struct {
+	text    string
+	done    bool
+	changed chan struct{}
+}
+
+func NewHueMessageBroker() *HueMessageBroker {
+	return &HueMessageBroker{streams: make(map[uuid.UUID]*hueMessageStream)}
+}
+
+// Begin は id の生成を始める。前回の生成が残っていれば終わらせる。
+func (b *HueMessageBroker) Begin(id uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if prev, ok := b.streams[id]; ok {
+		prev.finish()
+	}
+	b.streams[id] = &hueMessageStream{changed: make(chan struct{})}
+}
+
+// Publish はメッセージの続きを追加する。Begin していなければ捨てる。
+func (b *HueMessageBroker) Publish(id uuid.UUID, delta string) {
+	if delta == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream, ok := b.streams[id]
+	if !ok || stream.done {
+		return
+	}
+	stream.text += delta
+	close(stream.changed)
+	stream.changed = make(chan struct{})
+}
+
+// End は id の生成を終わらせる。購読者は残りを読み切ってから終了を受け取る。
+func (b *HueMessageBroker) End(id uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if stream, ok := b.streams[id]; ok {
+		stream.finish()
+		delete(b.streams, id)
+	}
+}
+
+// Subscribe は生成中の id を購読する。生成中でなければ ok=false。
+// 購読者はそれまでに書かれたメッセージも最初から受け取る。
+func (b *HueMessageBroker) Subscribe(id uuid.UUID) (*HueMessageSubscription, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream, ok := b.streams[id]
+	if !ok {
+		return nil, false
+	}
+	return &HueMessageSubscription{broker: b, stream: stream}, true
+}
+
+func (s *hueMessageStream) finish() {
+	if s.done {
+		return
+	}
+	s.done = true
+	close(s.changed)
+}
+
+// HueMessageSubscription は 1 回の生成ぶんのメッセージを読み進める。
+type HueMessageSubscription struct {
+	broker *HueMessageBroker
+	stream *hueMessageStream
+	offset int
+}
+
+// Next はまだ読んでいないメッセージを返す。無ければ届くまで待つ。
+// 生成が終わって読み切ったら done=true を返す。
+func (s *HueMessageSubscription) Next(ctx context.Context) (string, bool, error) {
+	for {
+		s.broker.mu.Lock()
+		text, done, changed := s.stream.text, s.stream.done, s.stream.changed
+		s.broker.mu.Unlock()
+
+		if s.offset < len(text) {
+			delta := text[s.offset:]
+			s.offset = len(text)
+			return delta, false, nil
+		}
+		if done {
+			return "", true, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// HueResultStatusReader は結果生成の状態を返す。*HueResultService が満たす。
+type HueResultStatusReader interface {
+	GetStatus(ctx context.Context, id uuid.UUID) (domain.HueRecord, domain.HueResultStatus, error)
+}
+
+// HueStreamService は結果生成の途中経過 (状態とメッセージ) を順に届けるユースケース。
+type HueStreamService struct {
+	results HueResultStatusReader
+	broker  *HueMessageBroker
+	logger  *log.Logger
+	poll    time.Duration
+}
+
+// NewHueStreamService は broker が nil なら状態の問い合わせだけで結果を待つ。
+func NewHueStreamService(results HueResultStatusReader, broker *HueMessageBroker, logger *log.Logger) *HueStreamService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueStreamService{results: results, broker: broker, logger: logger, poll: defaultHueStreamPoll}
+}
+
+// Stream は id の生成が終わるまで sink へ途中経過を渡す。ctx が終わると ctx.Err() を返す。
+// 最初に現在の状態を 1 回渡すので、レコードが無い場合は sink を呼ばずに ErrHueRecordNotFound を返す。
+func (s *HueStreamService) Stream(ctx context.Context, id uuid.UUID, sink domain.HueResultStreamSink) error {
+	var (
+		last domain.HueResultStatus
+		sent bool
+	)
+
+	for {
+		record, status, err := s.results.GetStatus(ctx, id)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, domain.ErrHueRecordNotFound) {
+				s.logError("get hue result status", err)
+			}
+			return err
+		}
+
+		if status.Status() == domain.HueJobStatusSucceeded {
+			return sink.Result(record)
+		}
+		if status != last {
+			if err := sink.Status(record, status); err != nil {
+				return err
+			}
+			last = status
+		}
+		if status.Finished() {
+			return nil
+		}
+
+		var sub *HueMessageSubscription
+		if s.broker != nil {
+			sub, _ = s.broker.Subscribe(id)
+		}
+		if sub == nil {
+			if err := s.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 前回の生成でメッセージを送っていたら、やり直しを知らせてから新しい生成を流す。
+		if sent {
+			if err := sink.Reset(); err != nil {
+				return err
+			}
+			sent = false
+		}
+		for {
+			delta, done, err := sub.Next(ctx)
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+			if err := sink.Message(delta); err != nil {
+				return err
+			}
+			sent = true
+		}
+	}
+}
+
+func (s *HueStreamService) wait(ctx context.Context) error {
+	timer := time.NewTimer(s.poll)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *HueStreamService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueStreamService] %s: %v", action, err)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestHueMessageBroker_SubscribeLate(t *testing.T) {
+	broker := NewHueMessageBroker()
+	id := uuid.New()
+
+	if _, ok := broker.Subscribe(id); ok {
+		t.Fatalf("expected no stream before Begin")
+	}
+
+	broker.Begin(id)
+	broker.Publish(id, "こん")
+	sub, ok := broker.Subscribe(id)
+	if !ok {
+		t.Fatalf("expected stream after Begin")
+	}
+	broker.Publish(id, "にちは")
+	broker.End(id)
+
+	var got strings.Builder
+	for {
+		delta, done, err := sub.Next(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if done {
+			break
+		}
+		got.WriteString(delta)
+	}
+	if got.String() != "こんにちは" {
+		t.Fatalf("expected whole message, got %q", got.String())
+	}
+	if _, ok := broker.Subscribe(id); ok {
+		t.Fatalf("expected stream to be removed after End")
+	}
+}
+
+func TestHueMessageSubscription_NextCanceled(t *testing.T) {
+	broker := NewHueMessageBroker()
+	id := uuid.New()
+	broker.Begin(id)
+	sub, _ := broker.Subscribe(id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := sub.Next(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestHueStreamService_Stream(t *testing.T) {
+	record := buildStreamRecord(t)
+	broker := NewHueMessageBroker()
+	broker.Begin(record.ID())
+	status := &fakeHueStatusReader{record: record, status: domain.HueJobStatusRunning}
+	svc := NewHueStreamService(status, broker, nil)
+	sink := &recordingStreamSink{}
+
+	done := make(chan error, 1)
+	go func() { done <- svc.Stream(context.Background(), record.ID(), sink) }()
+	waitForEvents(t, sink, "status:running")
+
+	broker.Publish(record.ID(), "こん")
+	broker.Publish(record.ID(), "にちは")
+	status.set(domain.HueJobStatusSucceeded)
+	broker.End(record.ID())
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "status:running|message:こんにちは|result"
+	if got := sink.String(); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestHueStreamService_StreamFailed(t *testing.T) {
+	record := buildStreamRecord(t)
+	status := &fakeHueStatusReader{record: record, status: domain.HueJobStatusFailed, failure: domain.HueJobFailureTimeout}
+	sink := &recordingStreamSink{}
+
+	if err := NewHueStreamService(status, nil, nil).Stream(context.Background(), record.ID(), sink); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sink.String(); got != "status:failed(timeout)" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+}
+
+func TestHueStreamService_StreamNotFound(t *testing.T) {
+	status := &fakeHueStatusReader{err: domain.ErrHueRecordNotFound}
+	sink := &recordingStreamSink{}
+
+	err := NewHueStreamService(status, nil, nil).Stream(context.Background(), uuid.New(), sink)
+	if err != domain.ErrHueRecordNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if sink.String() != "" {
+		t.Fatalf("expected no events, got %s", sink.String())
+	}
+}
+
+func TestHueStreamService_StreamReset(t *testing.T) {
+	record := buildStreamRecord(t)
+	broker := NewHueMessageBroker()
+	broker.Begin(record.ID())
+	status := &fakeHueStatusReader{record: record, status: domain.HueJobStatusRunning}
+	svc := NewHueStreamService(status, broker, nil)
+	svc.poll = time.Millisecond
+	sink := &recordingStreamSink{}
+
+	done := make(chan error, 1)
+	go func() { done <- svc.Stream(context.Background(), record.ID(), sink) }()
+	waitForEvents(t, sink, "status:running")
+
+	// 1 回目の生成が途中で失敗し、再試行で最後まで生成される。
+	broker.Publish(record.ID(), "途中")
+	waitForEvents(t, sink, "status:running|message:途中")
+	broker.End(record.ID())
+	broker.Begin(record.ID())
+	waitForEvents(t, sink, "status:running|message:途中|reset")
+	broker.Publish(record.ID(), "完成")
+	status.set(domain.HueJobStatusSucceeded)
+	broker.End(record.ID())
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "status:running|message:途中|reset|message:完成|result"
+	if got := sink.String(); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func waitForEvents(t *testing.T, sink *recordingStreamSink, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for sink.String() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s, got %s", want, sink.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type fakeHueStatusReader struct {
+	mu      sync.Mutex
+	record  domain.HueRecord
+	status  domain.HueJobStatus
+	failure domain.HueJobFailure
+	err     error
+}
+
+func (f *fakeHueStatusReader) set(status domain.HueJobStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+func (f *fakeHueStatusReader) GetStatus(_ context.Context, _ uuid.UUID) (domain.HueRecord, domain.HueResultStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record, domain.NewHueResultStatus(f.status, f.failure), f.err
+}
+
+// recordingStreamSink は受け取ったイベントを並べ、続けて届いた message はまとめて記録する。
+type recordingStreamSink struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (s *recordingStreamSink) Status(_ domain.HueRecord, status domain.HueResultStatus) error {
+	event := "status:" + status.Status().String()
+	if failure := status.Failure(); failure != domain.HueJobFailureNone {
+		event += "(" + failure.String() + ")"
+	}
+	return s.add(event)
+}
+
+func (s *recordingStreamSink) Message(delta string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.events); n > 0 && strings.HasPrefix(s.events[n-1], "message:") {
+		s.events[n-1] += delta
+		return nil
+	}
+	s.events = append(s.events, "message:"+delta)
+	return nil
+}
+
+func (s *recordingStreamSink) Reset() error {
+	return s.add("reset")
+}
+
+func (s *recordingStreamSink) Result(_ domain.HueRecord) error {
+	return s.add("result")
+}
+
+func (s *recordingStreamSink) add(event string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingStreamSink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.events, "|")
+}
+
+func buildStreamRecord(t *testing.T) domain.HueRecord {
+	t.Helper()
+	name, err := domain.NewName("tester")
+	if err != nil {
+		t.Fatalf("name error: %v", err)
+	}
+	choices, err := domain.NewHueChoices(map[string]string{"夜": "青"})
+	if err != nil {
+		t.Fatalf("choices error: %v", err)
+	}
+	record, err := domain.NewHueRecord(name, choices)
+	if err != nil {
+		t.Fatalf("record error: %v", err)
+	}
+	return record
+}
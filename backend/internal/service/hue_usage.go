@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+)
+
+// HueUsageConfig は推定費用の単価表と 1 日の予算。
+type HueUsageConfig struct {
+	// Prices はモデルごとの単価。表に無いモデルの費用は 0 として数える。
+	Prices domain.LLMPriceTable
+	// DailyBudget は 1 日 (UTC) の推定費用の上限 (米ドル)。0 以下なら上限なし。
+	DailyBudget float64
+}
+
+// HueUsageService はベンダー呼び出しの使用量と推定費用、結果キャッシュの効き具合を集計する管理者向けユースケース。
+type HueUsageService struct {
+	usageRepo *repository.HueUsageRepository
+	cache     *HueResultCache
+	logger    *log.Logger
+	cfg       HueUsageConfig
+}
+
+// NewHueUsageService の cache は nil でもよい (キャッシュを使わない構成)。
+func NewHueUsageService(usageRepo *repository.HueUsageRepository, cache *HueResultCache, logger *log.Logger, cfg HueUsageConfig) *HueUsageService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueUsageService{
+		usageRepo: usageRepo,
+		cache:     cache,
+		logger:    logger,
+		cfg:       cfg,
+	}
+}
+
+// GetUsage は期間内の使用量を日ごとに集計し、推定費用を添えて返す。管理者のみ。
+func (s *HueUsageService) GetUsage(ctx context.Context, principal domain.Principal, window domain.TimeWindow) (domain.HueUsageReport, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HueUsageReport{}, err
+	}
+
+	totals, err := s.usageRepo.DailyTotals(ctx, window)
+	if err != nil {
+		s.logError("aggregate llm usage", err)
+		return domain.HueUsageReport{}, err
+	}
+
+	return domain.NewHueUsageReport(window, totals, s.cfg.Prices, s.cfg.DailyBudget), nil
+}
+
+// GetCacheStats は起動してからの結果キャッシュの当たり外れと件数を返す。管理者のみ。
+func (s *HueUsageService) GetCacheStats(ctx context.Context, principal domain.Principal) (domain.HueResultCacheStats, error) {
+	if err := principal.Require(domain.UserRoleAdmin); err != nil {
+		return domain.HueResultCacheStats{}, err
+	}
+	if s.cache == nil {
+		return domain.HueResultCacheStats{}, nil
+	}
+
+	return s.cache.Stats(ctx)
+}
+
+func (s *HueUsageService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[HueUsageService] %s: %v", action, err)
+}
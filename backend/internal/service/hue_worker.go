@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultHueWorkerConcurrency = 4
+	defaultHueWorkerPoll        = 500 * time.Millisecond
+	defaultHueJobTimeout        = 60 * time.Second
+	defaultHueJobBaseBackoff    = 2 * time.Second
+	defaultHueJobMaxBackoff     = 2 * time.Minute
+	// hueJobLeaseMargin だけ lease を長く取り、タイムアウト直後のジョブを他のワーカーが拾わないようにする。
+	hueJobLeaseMargin = 30 * time.Second
+)
+
+// HueJobQueue は結果生成ジョブの取り出しと確定を行う。*repository.HueJobRepository が満たす。
+type HueJobQueue interface {
+	Claim(ctx context.Context, lease time.Duration) (domain.HueGenerationJob, bool, error)
+	Complete(ctx context.Context, recordID uuid.UUID) error
+	Retry(ctx context.Context, recordID uuid.UUID, runAt time.Time, failure domain.HueJobFailure, cause string) error
+	Fail(ctx context.Context, recordID uuid.UUID, failure domain.HueJobFailure, cause string) error
+}
+
+// HueResultProcessor はジョブ 1 件ぶんの結果を生成する。*HueSaveService が満たす。
+type HueResultProcessor interface {
+	GenerateResult(ctx context.Context, job domain.HueGenerationJob) error
+}
+
+// HueWorkerConfig はワーカーの並列数や再試行の間隔。0 の項目は既定値を使う。
+type HueWorkerConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+	JobTimeout   time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+func (c HueWorkerConfig) withDefaults() HueWorkerConfig {
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultHueWorkerConcurrency
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultHueWorkerPoll
+	}
+	if c.JobTimeout <= 0 {
+		c.JobTimeout = defaultHueJobTimeout
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = defaultHueJobBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultHueJobMaxBackoff
+	}
+	return c
+}
+
+// HueGenerationWorker はサーバープロセス内で結果生成ジョブを処理するワーカープール。
+// 失敗したジョブは指数バックオフで再実行し、MaxAttempts を使い切ったら failed にする。
+type HueGenerationWorker struct {
+	queue     HueJobQueue
+	processor HueResultProcessor
+	logger    *log.Logger
+	cfg       HueWorkerConfig
+	now       func() time.Time
+}
+
+func NewHueGenerationWorker(queue HueJobQueue, processor HueResultProcessor, logger *log.Logger, cfg HueWorkerConfig) *HueGenerationWorker {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &HueGenerationWorker{
+		queue:     queue,
+		processor: processor,
+		logger:    logger,
+		cfg:       cfg.withDefaults(),
+		now:       time.Now,
+	}
+}
+
+// Run は ctx が終わるまでジョブを処理する。戻るのは処理中のジョブがすべて終わってから。
+func (w *HueGenerationWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *HueGenerationWorker) loop(ctx context.Context) {
+	for {
+		processed, err := w.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.logError("process hue job", err)
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+// runOnce はジョブを 1 件処理する。取り出せるジョブが無ければ false。
+func (w *HueGenerationWorker) runOnce(ctx context.Context) (bool, error) {
+	job, ok, err := w.queue.Claim(ctx, w.cfg.JobTimeout+hueJobLeaseMargin)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// 取り出したジョブは停止要求が来ても最後まで処理し、状態を確定させる。
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.cfg.JobTimeout)
+	defer cancel()
+
+	genErr := w.processor.GenerateResult(jobCtx, job)
+	if genErr == nil {
+		return true, w.queue.Complete(jobCtx, job.RecordID())
+	}
+
+	// 原因の分類は結果ページと SSE でクライアントに返す。
+	failure := domain.HueJobFailureOf(genErr)
+	if job.CanRetry() {
+		runAt := w.now().Add(w.retryDelay(job.Attempts(), genErr))
+		return true, w.queue.Retry(jobCtx, job.RecordID(), runAt, failure, genErr.Error())
+	}
+
+	w.logError("hue job failed permanently", genErr)
+	return true, w.queue.Fail(jobCtx, job.RecordID(), failure, genErr.Error())
+}
+
+// retryDelay は attempt 回目の失敗後に待つ時間。BaseBackoff から倍々に伸ばし MaxBackoff で止める。
+// ベンダーが Retry-After で待ち時間を指定していれば、それより早くは再実行しない。
+func (w *HueGenerationWorker) retryDelay(attempt int, cause error) time.Duration {
+	delay := w.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < w.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, w.cfg.MaxBackoff)
+
+	var limited interface{ RetryAfter() time.Duration }
+	if errors.As(cause, &limited) {
+		delay = max(delay, limited.RetryAfter())
+	}
+	return delay
+}
+
+func (w *HueGenerationWorker) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	w.logger.Printf("[HueGenerationWorker] %s: %v", action, err)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/infra/llm"
+
+	"github.com/google/uuid"
+)
+
+func TestHueGenerationWorker_RunOnce(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		attempts    int
+		genErr      error
+		wantState   string
+		wantRunAt   time.Time
+		wantFailure domain.HueJobFailure
+	}{
+		{name: "success", attempts: 1, wantState: "complete"},
+		{name: "retry first failure", attempts: 1, genErr: errors.New("timeout"), wantState: "retry", wantRunAt: now.Add(2 * time.Second), wantFailure: domain.HueJobFailureInternal},
+		{name: "retry backs off", attempts: 3, genErr: errors.New("timeout"), wantState: "retry", wantRunAt: now.Add(8 * time.Second), wantFailure: domain.HueJobFailureInternal},
+		{name: "retry honors retry-after", attempts: 1, genErr: fmt.Errorf("generate: %w", &llm.StatusError{StatusCode: 429, Retry: 20 * time.Second}), wantState: "retry", wantRunAt: now.Add(20 * time.Second), wantFailure: domain.HueJobFailureRateLimited},
+		{name: "give up", attempts: 5, genErr: llm.ErrCircuitOpen, wantState: "fail", wantFailure: domain.HueJobFailureUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			job, err := domain.NewHueGenerationJobFromPersistence(uuid.New(), domain.HueJobStatusRunning, tc.attempts, 5, now, "")
+			if err != nil {
+				t.Fatalf("job error: %v", err)
+			}
+			queue := &fakeHueJobQueue{jobs: []domain.HueGenerationJob{job}}
+			processor := &fakeHueResultProcessor{err: tc.genErr}
+			worker := NewHueGenerationWorker(queue, processor, nil, HueWorkerConfig{})
+			worker.now = func() time.Time { return now }
+
+			processed, err := worker.runOnce(context.Background())
+			if err != nil || !processed {
+				t.Fatalf("expected job to be processed, got %v %v", processed, err)
+			}
+			if processor.recordID != job.RecordID() {
+				t.Fatalf("expected processor to receive the claimed record")
+			}
+			if queue.state != tc.wantState {
+				t.Fatalf("expected %s, got %s", tc.wantState, queue.state)
+			}
+			if !tc.wantRunAt.IsZero() && !queue.runAt.Equal(tc.wantRunAt) {
+				t.Fatalf("expected retry at %v, got %v", tc.wantRunAt, queue.runAt)
+			}
+			if queue.failure != tc.wantFailure {
+				t.Fatalf("expected failure %q, got %q", tc.wantFailure, queue.failure)
+			}
+		})
+	}
+}
+
+func TestHueGenerationWorker_RunOnceEmptyQueue(t *testing.T) {
+	worker := NewHueGenerationWorker(&fakeHueJobQueue{}, &fakeHueResultProcessor{}, nil, HueWorkerConfig{})
+
+	processed, err := worker.runOnce(context.Background())
+	if err != nil || processed {
+		t.Fatalf("expected nothing to process, got %v %v", processed, err)
+	}
+}
+
+func TestHueGenerationWorker_RetryDelayCapped(t *testing.T) {
+	worker := NewHueGenerationWorker(&fakeHueJobQueue{}, &fakeHueResultProcessor{}, nil, HueWorkerConfig{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	if got := worker.retryDelay(1, nil); got != time.Second {
+		t.Fatalf("expected base backoff, got %v", got)
+	}
+	if got := worker.retryDelay(10, nil); got != 5*time.Second {
+		t.Fatalf("expected capped backoff, got %v", got)
+	}
+}
+
+type fakeHueJobQueue struct {
+	jobs    []domain.HueGenerationJob
+	state   string
+	runAt   time.Time
+	failure domain.HueJobFailure
+}
+
+func (f *fakeHueJobQueue) Claim(_ context.Context, _ time.Duration) (domain.HueGenerationJob, bool, error) {
+	if len(f.jobs) == 0 {
+		return domain.HueGenerationJob{}, false, nil
+	}
+	job := f.jobs[0]
+	f.jobs = f.jobs[1:]
+	return job, true, nil
+}
+
+func (f *fakeHueJobQueue) Complete(_ context.Context, _ uuid.UUID) error {
+	f.state = "complete"
+	return nil
+}
+
+func (f *fakeHueJobQueue) Retry(_ context.Context, _ uuid.UUID, runAt time.Time, failure domain.HueJobFailure, _ string) error {
+	f.state = "retry"
+	f.runAt = runAt
+	f.failure = failure
+	return nil
+}
+
+func (f *fakeHueJobQueue) Fail(_ context.Context, _ uuid.UUID, failure domain.HueJobFailure, _ string) error {
+	f.state = "fail"
+	f.failure = failure
+	return nil
+}
+
+type fakeHueResultProcessor struct {
+	recordID uuid.UUID
+	err      error
+}
+
+func (f *fakeHueResultProcessor) GenerateResult(_ context.Context, job domain.HueGenerationJob) error {
+	f.recordID = job.RecordID()
+	return f.err
+}
@@ -14,19 +14,21 @@ import (
 
 // LoginService はログイン処理の具象実装を提供する雛形。
 type LoginService struct {
-	userRepo    *repository.UserRepository
-	sessionRepo *repository.LoginSessionRepository
-	logger      *log.Logger
+	userRepo *repository.UserRepository
+	issuer   sessionIssuer
+	logger   *log.Logger
 }
 
-func NewLoginService(userRepo *repository.UserRepository, sessionRepo *repository.LoginSessionRepository, logger *log.Logger) *LoginService {
+func NewLoginService(userRepo *repository.UserRepository, sessionRepo *repository.LoginSessionRepository, refreshRepo *repository.RefreshTokenRepository, pepper domain.SessionTokenPepper, logger *log.Logger) *LoginService {
 	if logger == nil {
 		logger = log.Default()
 	}
-	return &LoginService{userRepo: userRepo, sessionRepo: sessionRepo, logger: logger}
+	s := &LoginService{userRepo: userRepo, logger: logger}
+	s.issuer = sessionIssuer{sessionRepo: sessionRepo, refreshRepo: refreshRepo, pepper: pepper, logError: s.logError}
+	return s
 }
 
-func (s *LoginService) Login(ctx context.Context, credential domain.AdminCredential) (domain.SessionData, domain.UserRole, error) {
+func (s *LoginService) Login(ctx context.Context, credential domain.AdminCredential, client domain.SessionClient) (domain.SessionData, domain.UserRole, error) {
 	user, err := s.userRepo.FindByName(ctx, credential.Name())
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -49,32 +51,8 @@ func (s *LoginService) Login(ctx context.Context, credential domain.AdminCredent
 		return domain.SessionData{}, "", domain.ErrInvalidCredential
 	}
 
-	token, err := domain.NewLoginSessionToken()
+	sessionData, err := s.issuer.issue(ctx, user.ID(), client, time.Now())
 	if err != nil {
-		s.logError("issue login token", err)
-		return domain.SessionData{}, "", err
-	}
-
-	sessionData, err := domain.NewSessionData(user.ID(), token)
-	if err != nil {
-		s.logError("build session data", err)
-		return domain.SessionData{}, "", err
-	}
-
-	hashedToken, err := token.Hash()
-	if err != nil {
-		s.logError("hash login token", err)
-		return domain.SessionData{}, "", err
-	}
-
-	session, err := domain.NewLoginSession(user.ID(), hashedToken, time.Now())
-	if err != nil {
-		s.logError("build login session", err)
-		return domain.SessionData{}, "", err
-	}
-
-	if err := s.sessionRepo.Create(ctx, session); err != nil {
-		s.logError("persist login session", err)
 		return domain.SessionData{}, "", err
 	}
 
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SessionService はログアウト、自分のセッションの一覧・失効、refresh token によるセッションの更新を扱う。ロールは問わない。
+type SessionService struct {
+	sessionRepo *repository.LoginSessionRepository
+	refreshRepo *repository.RefreshTokenRepository
+	userRepo    *repository.UserRepository
+	issuer      sessionIssuer
+	logger      *log.Logger
+	now         func() time.Time
+}
+
+func NewSessionService(sessionRepo *repository.LoginSessionRepository, refreshRepo *repository.RefreshTokenRepository, userRepo *repository.UserRepository, pepper domain.SessionTokenPepper, logger *log.Logger) *SessionService {
+	if logger == nil {
+		logger = log.Default()
+	}
+	s := &SessionService{sessionRepo: sessionRepo, refreshRepo: refreshRepo, userRepo: userRepo, logger: logger, now: time.Now}
+	s.issuer = sessionIssuer{sessionRepo: sessionRepo, refreshRepo: refreshRepo, pepper: pepper, logError: s.logError}
+	return s
+}
+
+// Logout は principal の認証に使ったセッションと、一緒に発行した refresh token を失効させる。
+func (s *SessionService) Logout(ctx context.Context, principal domain.Principal) error {
+	if principal.IsZero() {
+		return domain.ErrInvalidLoginSession
+	}
+
+	if err := s.revokeRefreshTokens(ctx, principal.UserID(), principal.SessionID()); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.DeleteByID(ctx, principal.SessionID()); err != nil {
+		s.logError("delete session", err)
+		return err
+	}
+	return nil
+}
+
+// ListSessions は呼び出したユーザーの期限内のセッションを返す。呼び出しに使ったものは principal.SessionID()。
+func (s *SessionService) ListSessions(ctx context.Context, principal domain.Principal) ([]domain.LoginSession, error) {
+	if principal.IsZero() {
+		return nil, domain.ErrInvalidLoginSession
+	}
+
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, principal.UserID(), s.now())
+	if err != nil {
+		s.logError("list sessions", err)
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession は呼び出したユーザーのセッション id と、一緒に発行した refresh token を失効させる。
+// 他人のセッションや存在しない id なら ErrLoginSessionNotFound。
+func (s *SessionService) RevokeSession(ctx context.Context, principal domain.Principal, id uuid.UUID) error {
+	if principal.IsZero() {
+		return domain.ErrInvalidLoginSession
+	}
+
+	if err := s.revokeRefreshTokens(ctx, principal.UserID(), id); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.DeleteByUserAndID(ctx, principal.UserID(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrLoginSessionNotFound
+		}
+		s.logError("delete session", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeAllSessions は呼び出しに使ったセッションも含め、ユーザーのセッションをすべて失効させる。
+func (s *SessionService) RevokeAllSessions(ctx context.Context, principal domain.Principal) (int64, error) {
+	if principal.IsZero() {
+		return 0, domain.ErrInvalidLoginSession
+	}
+
+	if err := s.refreshRepo.RevokeByUser(ctx, principal.UserID(), s.now()); err != nil {
+		s.logError("revoke user refresh tokens", err)
+		return 0, err
+	}
+
+	revoked, err := s.sessionRepo.DeleteByUser(ctx, principal.UserID())
+	if err != nil {
+		s.logError("delete user sessions", err)
+		return 0, err
+	}
+
+	s.logger.Printf("[SessionService] revoked %d sessions of user %s", revoked, principal.UserID())
+	return revoked, nil
+}
+
+// Refresh は refresh token を次のトークンへ回転させ、新しいセッションを発行する。前のセッションは消す。
+// 回転は 1 つのトランザクションで行うので、途中で失敗しても元のトークンで再試行できる。
+// 使用済みのトークンが再び使われたら、盗まれたとみなして系列ごと失効させ ErrRefreshTokenReused を返す。
+// 同じトークンで同時に更新した場合も再利用として扱う。
+func (s *SessionService) Refresh(ctx context.Context, token domain.LoginSessionToken, client domain.SessionClient) (domain.SessionData, domain.UserRole, error) {
+	selector, ok := token.Selector()
+	if !ok {
+		return domain.SessionData{}, "", domain.ErrInvalidRefreshToken
+	}
+
+	stored, err := s.refreshRepo.FindBySelector(ctx, selector)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.SessionData{}, "", domain.ErrInvalidRefreshToken
+		}
+		s.logError("find refresh token", err)
+		return domain.SessionData{}, "", err
+	}
+
+	now := s.now()
+	if err := stored.Check(token, s.issuer.pepper, now); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			s.revokeFamily(ctx, stored, now)
+		}
+		return domain.SessionData{}, "", err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, stored.UserID())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.SessionData{}, "", domain.ErrInvalidRefreshToken
+		}
+		s.logError("find user by id", err)
+		return domain.SessionData{}, "", err
+	}
+
+	data, err := s.issuer.rotate(ctx, stored, client, now)
+	if err != nil {
+		// Check のあとで別のリクエストが同じトークンを使った。
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.revokeFamily(ctx, stored, now)
+			return domain.SessionData{}, "", domain.ErrRefreshTokenReused
+		}
+		return domain.SessionData{}, "", err
+	}
+
+	return data, user.Role(), nil
+}
+
+func (s *SessionService) revokeFamily(ctx context.Context, token domain.RefreshToken, at time.Time) {
+	s.logger.Printf("[SessionService] refresh token reused, revoking family %s of user %s", token.FamilyID(), token.UserID())
+	if err := s.refreshRepo.RevokeFamily(ctx, token.FamilyID(), at); err != nil {
+		s.logError("revoke refresh token family", err)
+	}
+}
+
+// revokeRefreshTokens はセッションと一緒に発行した refresh token の系列を失効させる。セッションを消す前に呼ぶ。
+func (s *SessionService) revokeRefreshTokens(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.refreshRepo.RevokeBySession(ctx, userID, sessionID, s.now()); err != nil {
+		s.logError("revoke refresh tokens", err)
+		return err
+	}
+	return nil
+}
+
+func (s *SessionService) logError(action string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.Printf("[SessionService] %s: %v", action, err)
+}
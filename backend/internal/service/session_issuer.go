@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// sessionIssuer はセッションと refresh token の発行をまとめ、ログイン・サインイン・更新で同じ手順を共有する。
+type sessionIssuer struct {
+	sessionRepo *repository.LoginSessionRepository
+	refreshRepo *repository.RefreshTokenRepository
+	pepper      domain.SessionTokenPepper
+	logError    func(action string, err error)
+}
+
+// issue はセッションと、新しい系列の refresh token を発行する。
+func (i sessionIssuer) issue(ctx context.Context, userID uuid.UUID, client domain.SessionClient, now time.Time) (domain.SessionData, error) {
+	data, session, err := i.createSession(ctx, userID, client, now)
+	if err != nil {
+		return domain.SessionData{}, err
+	}
+
+	refreshToken, hashed, err := i.newRefreshSecret()
+	if err != nil {
+		return domain.SessionData{}, err
+	}
+	refresh, err := domain.NewRefreshToken(userID, session.ID(), hashed, now)
+	if err != nil {
+		i.logError("build refresh token", err)
+		return domain.SessionData{}, err
+	}
+	if err := i.refreshRepo.Create(ctx, refresh); err != nil {
+		i.logError("persist refresh token", err)
+		return domain.SessionData{}, err
+	}
+
+	return data.WithRefreshToken(refreshToken), nil
+}
+
+// rotate は used と同じ系列で、次の refresh token と新しいセッションを発行する。
+// used の使用済み化から前のセッションの削除までは repository.RefreshTokenRepository.Rotate が 1 つのトランザクションで行う。
+// used が同時に別のリクエストで使われていれば pgx.ErrNoRows。
+func (i sessionIssuer) rotate(ctx context.Context, used domain.RefreshToken, client domain.SessionClient, now time.Time) (domain.SessionData, error) {
+	data, session, err := i.newSession(used.UserID(), client, now)
+	if err != nil {
+		return domain.SessionData{}, err
+	}
+
+	refreshToken, hashed, err := i.newRefreshSecret()
+	if err != nil {
+		return domain.SessionData{}, err
+	}
+	next, err := used.Rotate(session.ID(), hashed, now)
+	if err != nil {
+		i.logError("rotate refresh token", err)
+		return domain.SessionData{}, err
+	}
+	if err := i.refreshRepo.Rotate(ctx, used, session, next, now); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			i.logError("persist rotated refresh token", err)
+		}
+		return domain.SessionData{}, err
+	}
+
+	return data.WithRefreshToken(refreshToken), nil
+}
+
+func (i sessionIssuer) createSession(ctx context.Context, userID uuid.UUID, client domain.SessionClient, now time.Time) (domain.SessionData, domain.LoginSession, error) {
+	data, session, err := i.newSession(userID, client, now)
+	if err != nil {
+		return domain.SessionData{}, domain.LoginSession{}, err
+	}
+
+	if err := i.sessionRepo.Create(ctx, session); err != nil {
+		i.logError("persist login session", err)
+		return domain.SessionData{}, domain.LoginSession{}, err
+	}
+
+	return data, session, nil
+}
+
+// newSession は保存前のセッションと、クライアントに渡すセッション情報を作る。
+func (i sessionIssuer) newSession(userID uuid.UUID, client domain.SessionClient, now time.Time) (domain.SessionData, domain.LoginSession, error) {
+	token, err := domain.NewLoginSessionToken()
+	if err != nil {
+		i.logError("issue login token", err)
+		return domain.SessionData{}, domain.LoginSession{}, err
+	}
+
+	data, err := domain.NewSessionData(userID, token)
+	if err != nil {
+		i.logError("build session data", err)
+		return domain.SessionData{}, domain.LoginSession{}, err
+	}
+
+	hashedToken, err := token.Hash(i.pepper)
+	if err != nil {
+		i.logError("hash login token", err)
+		return domain.SessionData{}, domain.LoginSession{}, err
+	}
+
+	session, err := domain.NewLoginSession(userID, hashedToken, now)
+	if err != nil {
+		i.logError("build login session", err)
+		return domain.SessionData{}, domain.LoginSession{}, err
+	}
+
+	return data, session.WithClient(client), nil
+}
+
+func (i sessionIssuer) newRefreshSecret() (domain.LoginSessionToken, domain.HashedLoginSessionToken, error) {
+	token, err := domain.NewLoginSessionToken()
+	if err != nil {
+		i.logError("issue refresh token", err)
+		return domain.LoginSessionToken{}, domain.HashedLoginSessionToken{}, err
+	}
+	hashed, err := token.Hash(i.pepper)
+	if err != nil {
+		i.logError("hash refresh token", err)
+		return domain.LoginSessionToken{}, domain.HashedLoginSessionToken{}, err
+	}
+	return token, hashed, nil
+}
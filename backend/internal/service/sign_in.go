@@ -13,19 +13,21 @@ import (
 
 // SignInService はサインイン処理を司る具体実装の雛形。
 type SignInService struct {
-	userRepo    *repository.UserRepository
-	sessionRepo *repository.LoginSessionRepository
-	logger      *log.Logger
+	userRepo *repository.UserRepository
+	issuer   sessionIssuer
+	logger   *log.Logger
 }
 
-func NewSignInService(userRepo *repository.UserRepository, sessionRepo *repository.LoginSessionRepository, logger *log.Logger) *SignInService {
+func NewSignInService(userRepo *repository.UserRepository, sessionRepo *repository.LoginSessionRepository, refreshRepo *repository.RefreshTokenRepository, pepper domain.SessionTokenPepper, logger *log.Logger) *SignInService {
 	if logger == nil {
 		logger = log.Default()
 	}
-	return &SignInService{userRepo: userRepo, sessionRepo: sessionRepo, logger: logger}
+	s := &SignInService{userRepo: userRepo, logger: logger}
+	s.issuer = sessionIssuer{sessionRepo: sessionRepo, refreshRepo: refreshRepo, pepper: pepper, logError: s.logError}
+	return s
 }
 
-func (s *SignInService) SignIn(ctx context.Context, credential domain.SignInCredential) (domain.SessionData, domain.UserRole, error) {
+func (s *SignInService) SignIn(ctx context.Context, credential domain.SignInCredential, client domain.SessionClient) (domain.SessionData, domain.UserRole, error) {
 	now := time.Now()
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(credential.Password()), bcrypt.DefaultCost)
@@ -51,32 +53,8 @@ func (s *SignInService) SignIn(ctx context.Context, credential domain.SignInCred
 		return domain.SessionData{}, "", err
 	}
 
-	token, err := domain.NewLoginSessionToken()
+	data, err := s.issuer.issue(ctx, user.ID(), client, now)
 	if err != nil {
-		s.logError("issue session token", err)
-		return domain.SessionData{}, "", err
-	}
-
-	data, err := domain.NewSessionData(user.ID(), token)
-	if err != nil {
-		s.logError("build session data", err)
-		return domain.SessionData{}, "", err
-	}
-
-	hashedToken, err := data.Token().Hash()
-	if err != nil {
-		s.logError("hash login token", err)
-		return domain.SessionData{}, "", err
-	}
-
-	session, err := domain.NewLoginSession(data.UserID(), hashedToken, now)
-	if err != nil {
-		s.logError("build login session", err)
-		return domain.SessionData{}, "", err
-	}
-
-	if err = s.sessionRepo.Create(ctx, session); err != nil {
-		s.logError("persist login session", err)
 		return domain.SessionData{}, "", err
 	}
 	return data, user.Role(), nil
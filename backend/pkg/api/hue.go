@@ -2,36 +2,98 @@ package api
 
 import (
 	"backend/internal/domain"
-	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// HueRecordPayload は hue-are-you の回答を JSON で表す。
-type HueRecordPayload struct {
-	Name   string            `json:"name"`
-	Choice map[string]string `json:"choice"`
+// HueAnswerPayload は save-result で受け取る回答。生成結果は受け付けない。
+// QuestionnaireVersion は回答した質問票の版で、省略すると現行の版として検証する。
+type HueAnswerPayload struct {
+	Name                 string            `json:"name"`
+	Choice               map[string]string `json:"choice"`
+	QuestionnaireVersion string            `json:"questionnaire_version,omitempty"`
 }
 
-func (p HueRecordPayload) ToDomain() (domain.HueRecord, error) {
-	return domain.NewHueRecordFromRaw(p.Name, p.Choice)
+func (p HueAnswerPayload) ToDomain() (domain.HueRecord, error) {
+	questionnaire, err := domain.FindQuestionnaire(p.QuestionnaireVersion)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+
+	name, err := domain.NewName(p.Name)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+
+	choices, err := domain.NewHueChoicesFor(questionnaire, p.Choice)
+	if err != nil {
+		return domain.HueRecord{}, err
+	}
+
+	return domain.NewHueRecord(name, choices)
+}
+
+// HueRecordPayload は保存済みの回答を返すときの JSON。Result は生成済みのときだけ埋まる。
+type HueRecordPayload struct {
+	HueAnswerPayload
+	Result *HueRecordResultPayload `json:"result,omitempty"`
 }
 
 func NewHueRecordPayload(record domain.HueRecord) HueRecordPayload {
-	return HueRecordPayload{
-		Name:   record.Name().String(),
-		Choice: record.ChoiceMap(),
+	payload := HueRecordPayload{
+		HueAnswerPayload: HueAnswerPayload{
+			Name:                 record.Name().String(),
+			Choice:               record.ChoiceMap(),
+			QuestionnaireVersion: record.QuestionnaireVersion(),
+		},
+	}
+	if result, ok := record.Result(); ok {
+		resultPayload := NewHueRecordResultPayload(result)
+		payload.Result = &resultPayload
+	}
+	return payload
+}
+
+// HueRecordResultPayload は保存済みの生成結果と生成条件を表す。
+type HueRecordResultPayload struct {
+	Hue           HuePayload `json:"hue"`
+	Message       string     `json:"message"`
+	Source        string     `json:"source"`
+	Generator     string     `json:"generator"`
+	PromptVersion string     `json:"prompt_version,omitempty"`
+	LatencyMS     int64      `json:"latency_ms"`
+	GeneratedAt   time.Time  `json:"generated_at"`
+}
+
+func NewHueRecordResultPayload(result domain.HueRecordResult) HueRecordResultPayload {
+	return HueRecordResultPayload{
+		Hue:           NewHuePayload(result.Result().Hue()),
+		Message:       result.Result().Message(),
+		Source:        result.Result().Source().String(),
+		Generator:     result.Generator(),
+		PromptVersion: result.PromptVersion(),
+		LatencyMS:     result.Latency().Milliseconds(),
+		GeneratedAt:   result.GeneratedAt(),
 	}
 }
 
+// SaveResultRequest は save-result の本文。result を送ると未知のフィールドとして 400 になる。
 type SaveResultRequest struct {
-	HueRecordPayload
+	HueAnswerPayload
+	// ShareChoices が true なら結果ページで回答も公開する。
+	ShareChoices bool `json:"share_choices,omitempty"`
+	// Session はログイン中なら付ける。回答をそのユーザーに紐付ける。
+	// 本文に無ければ Authorization ヘッダを見る。
+	Session *SessionPayload `json:"session,omitempty"`
 }
 
 func (r SaveResultRequest) ToDomain() (domain.HueRecord, error) {
-	record, err := r.HueRecordPayload.ToDomain()
+	record, err := r.HueAnswerPayload.ToDomain()
 	if err != nil {
 		return domain.HueRecord{}, err
 	}
-	return record, nil
+	return record.WithShareChoices(r.ShareChoices), nil
 }
 
 type HuePayload struct {
@@ -44,61 +106,256 @@ func NewHuePayload(h domain.HueRGB) HuePayload {
 	return HuePayload{R: h.R(), G: h.G(), B: h.B()}
 }
 
-// SaveResultResponse は色とメッセージを返す。
+// SaveResultResponse は受け付けた結果生成ジョブを返す。
+// ID はジョブ兼結果ページ /api/hue-are-you/results/{id} の識別子で、Status が確定するまでそこを問い合わせる。
 type SaveResultResponse struct {
-	Hue     HuePayload `json:"hue"`
-	Message string     `json:"message"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
 }
 
-func NewSaveResultResponse(result domain.HueResult) SaveResultResponse {
+func NewSaveResultResponse(job domain.HueGenerationJob) SaveResultResponse {
 	return SaveResultResponse{
-		Hue:     NewHuePayload(result.Hue()),
-		Message: result.Message(),
+		ID:     job.RecordID().String(),
+		Status: job.Status().String(),
 	}
 }
 
-type GetDataRequest struct {
-	Session   SessionPayload `json:"session"`
-	DataRange []int          `json:"data-range"`
+// SharedResultResponse は公開用の結果ページ。Status が succeeded のときだけ Hue などが埋まる。
+// Choice は本人が許可した場合のみ含める。Failure は再試行待ちか失敗のときの直近の失敗の原因。
+type SharedResultResponse struct {
+	ID      string            `json:"id"`
+	Status  string            `json:"status"`
+	Name    string            `json:"name"`
+	Hue     *HuePayload       `json:"hue,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Source  string            `json:"source,omitempty"`
+	Choice  map[string]string `json:"choice,omitempty"`
+	Failure string            `json:"failure,omitempty"`
+	// Cluster は回答に最も近い「色の性格」。クラスタリング前は含めない。
+	Cluster *ClusterSummaryPayload `json:"cluster,omitempty"`
+}
+
+func NewSharedResultResponse(record domain.HueRecord) SharedResultResponse {
+	resp := SharedResultResponse{
+		ID:     record.ID().String(),
+		Status: domain.HueJobStatusSucceeded.String(),
+		Name:   record.Name().String(),
+	}
+	if result, ok := record.Result(); ok {
+		hue := NewHuePayload(result.Result().Hue())
+		resp.Hue = &hue
+		resp.Message = result.Result().Message()
+		resp.Source = result.Result().Source().String()
+	}
+	if record.SharesChoices() {
+		resp.Choice = record.ChoiceMap()
+	}
+	return resp
+}
+
+// WithCluster は回答に最も近いクラスタを添えたコピーを返す。
+func (r SharedResultResponse) WithCluster(cluster domain.HueCluster) SharedResultResponse {
+	summary := NewClusterSummaryPayload(cluster)
+	r.Cluster = &summary
+	return r
+}
+
+// NewResultStatusResponse は生成が終わっていないレコードの状態と失敗の原因だけを返す。
+func NewResultStatusResponse(record domain.HueRecord, status domain.HueResultStatus) SharedResultResponse {
+	if status.Status() == domain.HueJobStatusSucceeded {
+		return NewSharedResultResponse(record)
+	}
+	return SharedResultResponse{
+		ID:      record.ID().String(),
+		Status:  status.Status().String(),
+		Name:    record.Name().String(),
+		Failure: status.Failure().String(),
+	}
+}
+
+// SimilarResultPayload は回答が似た参加者。認証無しで引けるので、名前やメッセージ、回答そのもの、
+// 結果ページの ID は含めない。
+type SimilarResultPayload struct {
+	Hue        HuePayload `json:"hue"`
+	Matches    int        `json:"matches"`
+	Similarity float64    `json:"similarity"`
+}
+
+type SimilarResultsResponse struct {
+	Results []SimilarResultPayload `json:"results"`
+}
+
+func NewSimilarResultsResponse(similar []domain.HueSimilarity) SimilarResultsResponse {
+	payloads := make([]SimilarResultPayload, 0, len(similar))
+	for _, s := range similar {
+		// HueSimilarity のレコードは必ず結果を持つ。
+		result, _ := s.Record().Result()
+		payloads = append(payloads, SimilarResultPayload{
+			Hue:        NewHuePayload(result.Result().Hue()),
+			Matches:    s.Matches(),
+			Similarity: s.Similarity(),
+		})
+	}
+	return SimilarResultsResponse{Results: payloads}
+}
+
+// RecordFilterPayload は get-data の絞り込み条件。省略した項目は条件にしない。
+// from/to は RFC3339 か YYYY-MM-DD、choices は「語彙 → 選んだ色」ですべてを満たすものに絞る。
+type RecordFilterPayload struct {
+	From         string            `json:"from,omitempty"`
+	To           string            `json:"to,omitempty"`
+	NameContains string            `json:"name_contains,omitempty"`
+	Choices      map[string]string `json:"choices,omitempty"`
+	MinWords     int               `json:"min_words,omitempty"`
 }
 
-func (r GetDataRequest) ToDomain() (domain.SessionData, domain.RecordRange, error) {
-	id, err := uuid.Parse(r.Session.UserID)
+func (p RecordFilterPayload) ToDomain() (domain.HueRecordFilter, error) {
+	window, err := StatsQuery{From: p.From, To: p.To}.ToDomain()
 	if err != nil {
-		return domain.SessionData{}, domain.RecordRange{}, err
+		return domain.HueRecordFilter{}, domain.ErrInvalidFilter
 	}
+	return domain.NewHueRecordFilter(window, p.NameContains, p.Choices, p.MinWords)
+}
 
-	token, err := domain.ParseLoginSessionToken(r.Session.Token)
+// GetDataRequest は data-range (旧来の位置指定) か cursor/limit のどちらかで取得範囲を指定する。
+// data-range があればそちらを優先し、どちらも無ければ先頭から DefaultRecordPageSize 件を返す。
+// data-range も一度に返すのは begin から MaxRecordPageSize (500) 件までで、続きは範囲をずらして取り直す。
+type GetDataRequest struct {
+	Session   SessionPayload       `json:"session"`
+	DataRange []int                `json:"data-range,omitempty"`
+	Cursor    string               `json:"cursor,omitempty"`
+	Limit     int                  `json:"limit,omitempty"`
+	Filter    *RecordFilterPayload `json:"filter,omitempty"`
+}
+
+// session を省略したときは空の SessionData を返すので、呼び出し側で別の経路から読む。
+func (r GetDataRequest) ToDomain() (domain.SessionData, domain.HueRecordQuery, error) {
+	query, err := r.query()
 	if err != nil {
-		return domain.SessionData{}, domain.RecordRange{}, err
+		return domain.SessionData{}, domain.HueRecordQuery{}, err
 	}
 
-	if len(r.DataRange) != 2 {
-		return domain.SessionData{}, domain.RecordRange{}, domain.ErrInvalidRange
+	if r.Filter != nil {
+		filter, err := r.Filter.ToDomain()
+		if err != nil {
+			return domain.SessionData{}, domain.HueRecordQuery{}, err
+		}
+		query = query.WithFilter(filter)
 	}
 
-	recordRange, err := domain.NewRecordRange(r.DataRange[0], r.DataRange[1])
-	if err != nil {
-		return domain.SessionData{}, domain.RecordRange{}, err
+	if r.Session.IsZero() {
+		return domain.SessionData{}, query, nil
 	}
 
-	session, err := domain.NewSessionData(id, token)
+	session, err := r.Session.ToDomain()
 	if err != nil {
-		return domain.SessionData{}, domain.RecordRange{}, err
+		return domain.SessionData{}, domain.HueRecordQuery{}, err
 	}
 
-	return session, recordRange, nil
+	return session, query, nil
+}
+
+func (r GetDataRequest) query() (domain.HueRecordQuery, error) {
+	if r.DataRange != nil {
+		if len(r.DataRange) != 2 {
+			return domain.HueRecordQuery{}, domain.ErrInvalidRange
+		}
+
+		recordRange, err := domain.NewRecordRange(r.DataRange[0], r.DataRange[1])
+		if err != nil {
+			return domain.HueRecordQuery{}, err
+		}
+		return domain.NewRangeRecordQuery(recordRange), nil
+	}
+
+	if r.Cursor == "" {
+		return domain.NewCursorRecordQuery(nil, r.Limit), nil
+	}
+
+	cursor, err := domain.ParseRecordCursor(r.Cursor)
+	if err != nil {
+		return domain.HueRecordQuery{}, err
+	}
+	return domain.NewCursorRecordQuery(&cursor, r.Limit), nil
 }
 
 type GetDataResponse struct {
-	Records []HueRecordPayload `json:"records"`
+	Records    []HueRecordPayload `json:"records"`
+	NextCursor string             `json:"next_cursor,omitempty"`
 }
 
-func NewGetDataResponse(records []domain.HueRecord) GetDataResponse {
+func NewGetDataResponse(page domain.HueRecordPage) GetDataResponse {
+	records := page.Records()
 	payloads := make([]HueRecordPayload, len(records))
 	for i, record := range records {
 		payloads[i] = NewHueRecordPayload(record)
 	}
 
-	return GetDataResponse{Records: payloads}
+	resp := GetDataResponse{Records: payloads}
+	if next, ok := page.Next(); ok {
+		resp.NextCursor = next.String()
+	}
+	return resp
+}
+
+// UserResultsQuery は /api/me/hue-results のクエリ文字列 (cursor, limit) を表す。
+type UserResultsQuery struct {
+	Cursor string
+	Limit  string
+}
+
+func (q UserResultsQuery) ToDomain() (domain.HueRecordQuery, error) {
+	var limit int
+	if v := strings.TrimSpace(q.Limit); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			return domain.HueRecordQuery{}, domain.ErrInvalidRange
+		}
+		limit = parsed
+	}
+
+	if strings.TrimSpace(q.Cursor) == "" {
+		return domain.NewCursorRecordQuery(nil, limit), nil
+	}
+
+	cursor, err := domain.ParseRecordCursor(q.Cursor)
+	if err != nil {
+		return domain.HueRecordQuery{}, err
+	}
+	return domain.NewCursorRecordQuery(&cursor, limit), nil
+}
+
+// UserResultPayload は本人に見せる過去の回答。ID で結果ページを開ける。
+type UserResultPayload struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	HueRecordPayload
+}
+
+type UserResultsResponse struct {
+	Results    []UserResultPayload `json:"results"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+func NewUserResultsResponse(page domain.HueRecordPage) UserResultsResponse {
+	records := page.Records()
+	payloads := make([]UserResultPayload, len(records))
+	for i, record := range records {
+		payloads[i] = UserResultPayload{
+			ID:               record.ID().String(),
+			CreatedAt:        record.CreatedAt(),
+			HueRecordPayload: NewHueRecordPayload(record),
+		}
+	}
+
+	resp := UserResultsResponse{Results: payloads}
+	if next, ok := page.Next(); ok {
+		resp.NextCursor = next.String()
+	}
+	return resp
+}
+
+// StreamMessagePayload は結果ストリームの message イベントで送るメッセージの続き。
+type StreamMessagePayload struct {
+	Delta string `json:"delta"`
 }
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"time"
+
+	"backend/internal/domain"
+)
+
+const (
+	// clusterAssociationLimit は管理画面でクラスタごとに出す (語, 色) の組の数。
+	clusterAssociationLimit = 5
+	// clusterSummaryAssociationLimit は結果ページでクラスタの特徴として出す組の数。
+	clusterSummaryAssociationLimit = 3
+)
+
+// ClusterAssociationPayload はクラスタ内で Weight の割合の人が Word に Color を選んだことを表す。
+type ClusterAssociationPayload struct {
+	Word   string  `json:"word"`
+	Color  string  `json:"color"`
+	Weight float64 `json:"weight"`
+}
+
+func newClusterAssociationPayloads(cluster domain.HueCluster, limit int) []ClusterAssociationPayload {
+	associations := cluster.Associations(limit)
+	payloads := make([]ClusterAssociationPayload, len(associations))
+	for i, a := range associations {
+		payloads[i] = ClusterAssociationPayload{Word: string(a.Word()), Color: string(a.Color()), Weight: a.Weight()}
+	}
+	return payloads
+}
+
+// ClusterExemplarPayload はクラスタの重心に近い回答。
+type ClusterExemplarPayload struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"created_at"`
+	Hue       *HuePayload `json:"hue,omitempty"`
+}
+
+type ClusterPayload struct {
+	Index        int                         `json:"index"`
+	Size         int                         `json:"size"`
+	Associations []ClusterAssociationPayload `json:"associations"`
+	Exemplars    []ClusterExemplarPayload    `json:"exemplars"`
+}
+
+// ClustersResponse は GET /api/hue-are-you/clusters の応答。クラスタは大きい順。
+type ClustersResponse struct {
+	CreatedAt   time.Time        `json:"created_at"`
+	RecordCount int              `json:"record_count"`
+	Clusters    []ClusterPayload `json:"clusters"`
+}
+
+func NewClustersResponse(report domain.HueClusterReport) ClustersResponse {
+	model := report.Model()
+	clusters := model.Clusters()
+	payloads := make([]ClusterPayload, len(clusters))
+	for i, cluster := range clusters {
+		records := report.Exemplars(cluster.Index())
+		exemplars := make([]ClusterExemplarPayload, len(records))
+		for j, record := range records {
+			exemplars[j] = ClusterExemplarPayload{
+				ID:        record.ID().String(),
+				Name:      record.Name().String(),
+				CreatedAt: record.CreatedAt(),
+			}
+			if result, ok := record.Result(); ok {
+				hue := NewHuePayload(result.Result().Hue())
+				exemplars[j].Hue = &hue
+			}
+		}
+		payloads[i] = ClusterPayload{
+			Index:        cluster.Index(),
+			Size:         cluster.Size(),
+			Associations: newClusterAssociationPayloads(cluster, clusterAssociationLimit),
+			Exemplars:    exemplars,
+		}
+	}
+
+	return ClustersResponse{
+		CreatedAt:   model.CreatedAt(),
+		RecordCount: model.RecordCount(),
+		Clusters:    payloads,
+	}
+}
+
+// ClusterSummaryPayload は結果ページに出す、回答に最も近いクラスタ。
+type ClusterSummaryPayload struct {
+	Index        int                         `json:"index"`
+	Associations []ClusterAssociationPayload `json:"associations"`
+}
+
+func NewClusterSummaryPayload(cluster domain.HueCluster) ClusterSummaryPayload {
+	return ClusterSummaryPayload{
+		Index:        cluster.Index(),
+		Associations: newClusterAssociationPayloads(cluster, clusterSummaryAssociationLimit),
+	}
+}
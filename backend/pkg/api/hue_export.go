@@ -0,0 +1,43 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"backend/internal/domain"
+)
+
+// ExportQuery は /api/hue-are-you/export のクエリ文字列のうち絞り込み条件を表す。
+// choice は "語彙:色" の形で複数指定でき、すべてを満たすレコードに絞る。
+type ExportQuery struct {
+	From         string
+	To           string
+	NameContains string
+	MinWords     string
+	Choices      []string
+}
+
+func (q ExportQuery) ToDomain() (domain.HueRecordFilter, error) {
+	filter := RecordFilterPayload{From: q.From, To: q.To, NameContains: q.NameContains}
+
+	if v := strings.TrimSpace(q.MinWords); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return domain.HueRecordFilter{}, domain.ErrInvalidFilter
+		}
+		filter.MinWords = n
+	}
+
+	if len(q.Choices) > 0 {
+		filter.Choices = make(map[string]string, len(q.Choices))
+		for _, choice := range q.Choices {
+			word, color, ok := strings.Cut(choice, ":")
+			if !ok {
+				return domain.HueRecordFilter{}, domain.ErrInvalidFilter
+			}
+			filter.Choices[word] = color
+		}
+	}
+
+	return filter.ToDomain()
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"time"
+
+	"backend/internal/domain"
+)
+
+// PromptVersionPayload はプロンプトの版。テンプレートは text/template の書式。
+type PromptVersionPayload struct {
+	Version        string     `json:"version"`
+	SystemTemplate string     `json:"system_template"`
+	UserTemplate   string     `json:"user_template"`
+	Weight         int        `json:"weight"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ActivatedAt    *time.Time `json:"activated_at,omitempty"`
+}
+
+func NewPromptVersionPayload(prompt domain.HuePromptVersion) PromptVersionPayload {
+	payload := PromptVersionPayload{
+		Version:        prompt.Version(),
+		SystemTemplate: prompt.SystemTemplate(),
+		UserTemplate:   prompt.UserTemplate(),
+		Weight:         prompt.Weight(),
+		Active:         prompt.Active(),
+		CreatedAt:      prompt.CreatedAt(),
+	}
+	if at, ok := prompt.ActivatedAt(); ok {
+		payload.ActivatedAt = &at
+	}
+	return payload
+}
+
+// PromptListResponse は GET /api/hue-are-you/prompts の応答。
+type PromptListResponse struct {
+	Prompts []PromptVersionPayload `json:"prompts"`
+}
+
+func NewPromptListResponse(prompts []domain.HuePromptVersion) PromptListResponse {
+	payloads := make([]PromptVersionPayload, len(prompts))
+	for i, prompt := range prompts {
+		payloads[i] = NewPromptVersionPayload(prompt)
+	}
+	return PromptListResponse{Prompts: payloads}
+}
+
+// CreatePromptRequest は新しい版の作成要求。作った版は有効化するまで割り当てない。
+// テンプレートには .Name と .Choices (.Word, .Color の並び) を渡す。
+type CreatePromptRequest struct {
+	Version        string `json:"version"`
+	SystemTemplate string `json:"system_template"`
+	UserTemplate   string `json:"user_template"`
+}
+
+func (r CreatePromptRequest) ToDomain() (domain.HuePromptVersion, error) {
+	return domain.NewHuePromptVersion(r.Version, r.SystemTemplate, r.UserTemplate)
+}
+
+// ActivatePromptRequest は版の有効化要求。Weight を省略すると DefaultPromptWeight。
+// Exclusive なら他の版を割り当て対象から外す。
+type ActivatePromptRequest struct {
+	Weight    *int `json:"weight,omitempty"`
+	Exclusive bool `json:"exclusive,omitempty"`
+}
+
+func (r ActivatePromptRequest) WeightOrDefault() int {
+	if r.Weight == nil {
+		return domain.DefaultPromptWeight
+	}
+	return *r.Weight
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+
+	"backend/internal/domain"
+)
+
+// PaletteColorPayload はパレットの色名と表示色。
+type PaletteColorPayload struct {
+	Name string `json:"name"`
+	Hex  string `json:"hex"`
+}
+
+// QuestionnaireResponse は質問票の語彙 (出題順) とパレット (表示順)。
+type QuestionnaireResponse struct {
+	Version string                `json:"version"`
+	Words   []string              `json:"words"`
+	Palette []PaletteColorPayload `json:"palette"`
+}
+
+func NewQuestionnaireResponse(q domain.Questionnaire) QuestionnaireResponse {
+	words := q.Words()
+	wordPayloads := make([]string, len(words))
+	for i, word := range words {
+		wordPayloads[i] = string(word)
+	}
+
+	palette := q.Palette()
+	palettePayloads := make([]PaletteColorPayload, 0, len(palette))
+	for _, color := range palette {
+		rgb, ok := color.RGB()
+		if !ok {
+			continue
+		}
+		palettePayloads = append(palettePayloads, PaletteColorPayload{
+			Name: string(color),
+			Hex:  fmt.Sprintf("#%02x%02x%02x", rgb.R(), rgb.G(), rgb.B()),
+		})
+	}
+
+	return QuestionnaireResponse{Version: q.Version(), Words: wordPayloads, Palette: palettePayloads}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"backend/internal/domain"
+)
+
+// StatsQuery は /api/hue-are-you/stats のクエリ文字列 (from, to) を表す。
+// いずれも RFC3339 か YYYY-MM-DD で、省略すると無制限になる。
+type StatsQuery struct {
+	From string
+	To   string
+}
+
+func (q StatsQuery) ToDomain() (domain.TimeWindow, error) {
+	from, err := parseTimeParam(q.From)
+	if err != nil {
+		return domain.TimeWindow{}, err
+	}
+	to, err := parseTimeParam(q.To)
+	if err != nil {
+		return domain.TimeWindow{}, err
+	}
+	return domain.NewTimeWindow(from, to)
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	v := strings.TrimSpace(value)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, domain.ErrInvalidRange
+}
+
+// ColorSharePayload は語彙に対する色の回数と割合。
+type ColorSharePayload struct {
+	Color string  `json:"color"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+func NewColorSharePayload(share domain.HueColorShare) ColorSharePayload {
+	return ColorSharePayload{
+		Color: string(share.Color()),
+		Count: share.Count(),
+		Share: share.Share(),
+	}
+}
+
+// WordStatsPayload は 1 語彙ぶんの色の分布。
+type WordStatsPayload struct {
+	Word         string            `json:"word"`
+	Total        int               `json:"total"`
+	Counts       map[string]int    `json:"counts"`
+	MostTypical  ColorSharePayload `json:"most_typical"`
+	LeastTypical ColorSharePayload `json:"least_typical"`
+}
+
+// StatsResponse は語彙ごとの色の分布と参加人数を返す。
+type StatsResponse struct {
+	From         *time.Time         `json:"from,omitempty"`
+	To           *time.Time         `json:"to,omitempty"`
+	Participants int                `json:"participants"`
+	Words        []WordStatsPayload `json:"words"`
+}
+
+func NewStatsResponse(stats domain.HueStats) StatsResponse {
+	words := stats.Words()
+	payloads := make([]WordStatsPayload, len(words))
+	for i, word := range words {
+		payloads[i] = WordStatsPayload{
+			Word:         string(word.Word()),
+			Total:        word.Total(),
+			Counts:       word.Counts(),
+			MostTypical:  NewColorSharePayload(word.MostTypical()),
+			LeastTypical: NewColorSharePayload(word.LeastTypical()),
+		}
+	}
+
+	resp := StatsResponse{Participants: stats.Participants(), Words: payloads}
+	if from, ok := stats.Window().From(); ok {
+		resp.From = &from
+	}
+	if to, ok := stats.Window().To(); ok {
+		resp.To = &to
+	}
+	return resp
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"time"
+
+	"backend/internal/domain"
+)
+
+// UsageModelPayload は 1 日・1 モデルぶんの使用量。単価表に無いモデルは estimated_cost_usd を省く。
+type UsageModelPayload struct {
+	Model            string   `json:"model"`
+	Requests         int64    `json:"requests"`
+	InputTokens      int64    `json:"input_tokens"`
+	OutputTokens     int64    `json:"output_tokens"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+}
+
+// UsageDayPayload は 1 日 (UTC) ぶんの使用量と推定費用。
+type UsageDayPayload struct {
+	Date             string              `json:"date"`
+	Requests         int64               `json:"requests"`
+	InputTokens      int64               `json:"input_tokens"`
+	OutputTokens     int64               `json:"output_tokens"`
+	EstimatedCostUSD float64             `json:"estimated_cost_usd"`
+	Models           []UsageModelPayload `json:"models"`
+}
+
+// UsageResponse は /api/hue-are-you/usage の応答。daily_budget_usd は予算が無ければ省く。
+type UsageResponse struct {
+	From           *time.Time        `json:"from,omitempty"`
+	To             *time.Time        `json:"to,omitempty"`
+	Days           []UsageDayPayload `json:"days"`
+	TotalCostUSD   float64           `json:"total_cost_usd"`
+	DailyBudgetUSD *float64          `json:"daily_budget_usd,omitempty"`
+}
+
+func NewUsageResponse(report domain.HueUsageReport) UsageResponse {
+	days := report.Days()
+	payloads := make([]UsageDayPayload, len(days))
+	for i, day := range days {
+		models := day.Models()
+		modelPayloads := make([]UsageModelPayload, len(models))
+		for j, model := range models {
+			total := model.Total()
+			modelPayloads[j] = UsageModelPayload{
+				Model:        total.Model(),
+				Requests:     total.Requests(),
+				InputTokens:  total.InputTokens(),
+				OutputTokens: total.OutputTokens(),
+			}
+			if cost, ok := model.Cost(); ok {
+				modelPayloads[j].EstimatedCostUSD = &cost
+			}
+		}
+
+		payloads[i] = UsageDayPayload{
+			Date:             day.Day().Format(time.DateOnly),
+			Requests:         day.Requests(),
+			InputTokens:      day.InputTokens(),
+			OutputTokens:     day.OutputTokens(),
+			EstimatedCostUSD: day.Cost(),
+			Models:           modelPayloads,
+		}
+	}
+
+	resp := UsageResponse{Days: payloads, TotalCostUSD: report.TotalCost()}
+	if from, ok := report.Window().From(); ok {
+		resp.From = &from
+	}
+	if to, ok := report.Window().To(); ok {
+		resp.To = &to
+	}
+	if budget, ok := report.DailyBudget(); ok {
+		resp.DailyBudgetUSD = &budget
+	}
+	return resp
+}
+
+// CacheStatsResponse は /api/hue-are-you/cache の応答。回数はプロセスの起動からの値。
+type CacheStatsResponse struct {
+	MemoryHits    int64   `json:"memory_hits"`
+	StoreHits     int64   `json:"store_hits"`
+	Misses        int64   `json:"misses"`
+	Bypasses      int64   `json:"bypasses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	MemoryEntries int     `json:"memory_entries"`
+	StoreEntries  int64   `json:"store_entries"`
+}
+
+func NewCacheStatsResponse(stats domain.HueResultCacheStats) CacheStatsResponse {
+	return CacheStatsResponse{
+		MemoryHits:    stats.MemoryHits,
+		StoreHits:     stats.StoreHits,
+		Misses:        stats.Misses,
+		Bypasses:      stats.Bypasses,
+		HitRatio:      stats.HitRatio(),
+		MemoryEntries: stats.MemoryEntries,
+		StoreEntries:  stats.StoreEntries,
+	}
+}
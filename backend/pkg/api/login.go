@@ -19,8 +19,19 @@ func (r LoginRequest) ToDomain() (domain.AdminCredential, error) {
 type LoginResponse struct {
 	SessionPayload
 	Role string `json:"role"`
+	// CSRFToken はクッキーモードのときだけ返す。GET 以外のリクエストで X-CSRF-Token ヘッダに載せる。
+	CSRFToken string `json:"csrf_token,omitempty"`
 }
 
 func NewLoginResponse(session domain.SessionData, role domain.UserRole) LoginResponse {
 	return LoginResponse{SessionPayload: NewSessionPayload(session), Role: role.String()}
 }
+
+// NewCookieLoginResponse はトークンをクッキーで渡すときのレスポンス。トークンは JSON に含めない。
+func NewCookieLoginResponse(session domain.SessionData, role domain.UserRole, csrfToken string) LoginResponse {
+	return LoginResponse{
+		SessionPayload: SessionPayload{UserID: session.UserID().String()},
+		Role:           role.String(),
+		CSRFToken:      csrfToken,
+	}
+}
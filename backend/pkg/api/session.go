@@ -1,18 +1,127 @@
 package api
 
 import (
+	"strings"
+	"time"
+
 	"backend/internal/domain"
+
+	"github.com/google/uuid"
 )
 
 // SessionPayload は session-data-struct を JSON で表現する。
+// refresh_token はログインと更新のレスポンスにだけ含まれ、リクエストでは読まない。
+// クッキーモードのレスポンスでは token, refresh_token とも空にする。
 type SessionPayload struct {
-	UserID string `json:"user_id"`
-	Token  string `json:"token"`
+	UserID       string `json:"user_id"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 func NewSessionPayload(session domain.SessionData) SessionPayload {
-	return SessionPayload{
+	payload := SessionPayload{
 		UserID: session.UserID().String(),
 		Token:  session.Token().String(),
 	}
+	if refreshToken, ok := session.RefreshToken(); ok {
+		payload.RefreshToken = refreshToken.String()
+	}
+	return payload
+}
+
+// IsZero は user_id も token も送られていないかを返す。本文の session を省略したリクエストの判定に使う。
+func (p SessionPayload) IsZero() bool {
+	return p.UserID == "" && p.Token == ""
+}
+
+// ToDomain は user_id と token を検証して SessionData に変換する。
+func (p SessionPayload) ToDomain() (domain.SessionData, error) {
+	id, err := uuid.Parse(p.UserID)
+	if err != nil {
+		return domain.SessionData{}, domain.ErrInvalidSessionData
+	}
+
+	token, err := domain.ParseLoginSessionToken(p.Token)
+	if err != nil {
+		return domain.SessionData{}, err
+	}
+
+	return domain.NewSessionData(id, token)
+}
+
+const bearerPrefix = "Bearer "
+
+// BearerCredential は Authorization ヘッダ用の "<user_id>:<token>" を返す。
+func (p SessionPayload) BearerCredential() string {
+	return p.UserID + ":" + p.Token
+}
+
+// ParseBearerSession は "Authorization: Bearer <user_id>:<token>" を SessionData に変換する。
+// ボディを持たない GET の保護 API で使う。
+func ParseBearerSession(header string) (domain.SessionData, error) {
+	if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return domain.SessionData{}, domain.ErrInvalidSessionData
+	}
+
+	userID, token, ok := strings.Cut(strings.TrimSpace(header[len(bearerPrefix):]), ":")
+	if !ok {
+		return domain.SessionData{}, domain.ErrInvalidSessionData
+	}
+
+	return SessionPayload{UserID: userID, Token: token}.ToDomain()
+}
+
+// RefreshSessionRequest は POST /api/session/refresh のボディ。
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r RefreshSessionRequest) ToDomain() (domain.LoginSessionToken, error) {
+	token, err := domain.ParseLoginSessionToken(r.RefreshToken)
+	if err != nil {
+		return domain.LoginSessionToken{}, domain.ErrInvalidRefreshToken
+	}
+	if _, ok := token.Selector(); !ok {
+		return domain.LoginSessionToken{}, domain.ErrInvalidRefreshToken
+	}
+	return token, nil
+}
+
+// SessionInfoPayload は /api/sessions で返すセッション 1 件。トークンは含めない。
+type SessionInfoPayload struct {
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	// Current は一覧の取得に使ったセッションかどうか。
+	Current bool `json:"current"`
+}
+
+type SessionsResponse struct {
+	Sessions []SessionInfoPayload `json:"sessions"`
+}
+
+func NewSessionsResponse(sessions []domain.LoginSession, current uuid.UUID) SessionsResponse {
+	payloads := make([]SessionInfoPayload, len(sessions))
+	for i, session := range sessions {
+		payloads[i] = SessionInfoPayload{
+			ID:        session.ID().String(),
+			CreatedAt: session.CreatedAt(),
+			ExpiresAt: session.ExpiresAt(),
+			UserAgent: session.Client().UserAgent(),
+			IPAddress: session.Client().IPAddress(),
+			Current:   session.ID() == current,
+		}
+		if lastUsedAt, ok := session.LastUsedAt(); ok {
+			payloads[i].LastUsedAt = &lastUsedAt
+		}
+	}
+	return SessionsResponse{Sessions: payloads}
+}
+
+// RevokeSessionsResponse は「すべての端末からログアウト」で失効させた件数。
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
 }
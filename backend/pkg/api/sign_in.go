@@ -12,11 +12,9 @@ func (r SignInRequest) ToDomain() (domain.SignInCredential, error) {
 	return domain.NewSignInCredential(r.Name, r.Email, r.Password)
 }
 
-type SignInResponse struct {
-	SessionPayload
-	Role string `json:"role"`
-}
+// SignInResponse はログインと同じ形で返す。
+type SignInResponse = LoginResponse
 
 func NewSignInResponse(session domain.SessionData, role domain.UserRole) SignInResponse {
-	return SignInResponse{SessionPayload: NewSessionPayload(session), Role: role.String()}
+	return NewLoginResponse(session, role)
 }
@@ -5,7 +5,28 @@ import type {
   HueAreYouDataResponse,
   SaveHueAreYouResultPayload,
   SaveHueAreYouResultResponse,
+  SessionData,
   SessionResponce,
+  SessionsResponse,
+  RevokeSessionsResponse,
+  SharedHueAreYouResult,
+  SimilarHueResultsResponse,
+  HueAreYouStatsResponse,
+  FetchHueAreYouStatsParams,
+  HueUsageResponse,
+  FetchHueUsageParams,
+  HueCacheStatsResponse,
+  HueClustersResponse,
+  FetchMyHueResultsParams,
+  MyHueResultsResponse,
+  ExportHueAreYouRecordsParams,
+  HueAreYouQuestionnaire,
+  HueAreYouJobResponse,
+  HueAreYouStreamMessage,
+  HuePromptVersion,
+  HuePromptListResponse,
+  CreateHuePromptPayload,
+  ActivateHuePromptPayload,
 } from './types'
 
 const DEFAULT_DEV_API_BASE_URL = 'http://localhost:8080/api/'
@@ -75,13 +96,31 @@ export class ApiError extends Error {
   }
 }
 
-type RequestMethod = 'GET' | 'POST'
+type RequestMethod = 'GET' | 'POST' | 'DELETE'
 
 interface RequestOptions {
   method?: RequestMethod
   body?: unknown
   searchParams?: Record<string, string | number | undefined>
   signal?: AbortSignal
+  session?: SessionData
+  headers?: Record<string, string>
+}
+
+const bearerHeader = (session: SessionData) => `Bearer ${session.user_id}:${session.token}`
+
+const CSRF_HEADER = 'X-CSRF-Token'
+
+/**
+ * サーバーがクッキーでセッションを渡すモードのとき、ログイン・更新のレスポンスで受け取った csrf_token。
+ * GET 以外のリクエストに X-CSRF-Token として付ける
+ */
+let csrfToken: string | null = null
+
+const rememberCsrfToken = (data: unknown) => {
+  if (data && typeof data === 'object' && typeof (data as { csrf_token?: unknown }).csrf_token === 'string') {
+    csrfToken = (data as { csrf_token: string }).csrf_token
+  }
 }
 
 const safeJsonParse = (raw: string) => {
@@ -93,16 +132,25 @@ const safeJsonParse = (raw: string) => {
 }
 
 async function request<T>(path: string, options: RequestOptions = {}): Promise<T> {
-  const { method = 'GET', body, searchParams, signal } = options
+  const { method = 'GET', body, searchParams, signal, session } = options
   const url = buildUrl(path, searchParams)
   const headers: Record<string, string> = {
+    ...options.headers,
     Accept: 'application/json',
   }
 
+  if (session?.token) {
+    headers.Authorization = bearerHeader(session)
+  }
+  if (method !== 'GET' && csrfToken) {
+    headers[CSRF_HEADER] = csrfToken
+  }
+
   const init: RequestInit = {
     method,
     headers,
     signal,
+    credentials: 'include',
   }
 
   if (body !== undefined) {
@@ -144,6 +192,7 @@ async function request<T>(path: string, options: RequestOptions = {}): Promise<T
     })
   }
 
+  rememberCsrfToken(data)
   return (data ?? undefined) as T
 }
 
@@ -167,14 +216,228 @@ export const signIn = async (
     signal: options?.signal,
   })
 
+/**
+ * refresh token で新しいセッションと refresh token を受け取る。古い refresh token は使えなくなり、
+ * 再び使うと盗まれたとみなされて同じログインのセッションがすべて失効する。
+ * クッキーモードでは refreshToken を省略し、クッキーの refresh token を使う
+ */
+export const refreshSession = async (
+  refreshToken?: string,
+  options?: { signal?: AbortSignal }
+): Promise<SessionResponce> =>
+  request<SessionResponce>('session/refresh', {
+    method: 'POST',
+    body: refreshToken ? { refresh_token: refreshToken } : undefined,
+    signal: options?.signal,
+  })
+
+/** 今使っているセッションを失効させる */
+export const logout = async (session: SessionData, options?: { signal?: AbortSignal }): Promise<void> => {
+  await request<void>('logout', {
+    method: 'POST',
+    session,
+    signal: options?.signal,
+  })
+  csrfToken = null
+}
+
+/** 自分の期限内のセッション一覧。current が今使っているセッション */
+export const fetchSessions = async (
+  session: SessionData,
+  options?: { signal?: AbortSignal }
+): Promise<SessionsResponse> =>
+  request<SessionsResponse>('sessions', {
+    session,
+    signal: options?.signal,
+  })
+
+export const revokeSession = async (
+  session: SessionData,
+  id: string,
+  options?: { signal?: AbortSignal }
+): Promise<void> =>
+  request<void>(`sessions/${encodeURIComponent(id)}`, {
+    method: 'DELETE',
+    session,
+    signal: options?.signal,
+  })
+
+/** すべての端末からログアウトする。今使っているセッションも失効する */
+export const revokeAllSessions = async (
+  session: SessionData,
+  options?: { signal?: AbortSignal }
+): Promise<RevokeSessionsResponse> =>
+  request<RevokeSessionsResponse>('sessions', {
+    method: 'DELETE',
+    session,
+    signal: options?.signal,
+  })
+
+export const fetchHueAreYouQuestionnaire = async (
+  version?: string,
+  options?: { signal?: AbortSignal }
+): Promise<HueAreYouQuestionnaire> =>
+  request<HueAreYouQuestionnaire>('hue-are-you/questionnaire', {
+    searchParams: { version },
+    signal: options?.signal,
+  })
+
+const RESULT_POLL_INTERVAL_MS = 1000
+const RESULT_POLL_TIMEOUT_MS = 3 * 60 * 1000
+
+const wait = (ms: number, signal?: AbortSignal) =>
+  new Promise<void>((resolve, reject) => {
+    const timer = setTimeout(resolve, ms)
+    signal?.addEventListener(
+      'abort',
+      () => {
+        clearTimeout(timer)
+        reject(signal.reason)
+      },
+      { once: true }
+    )
+  })
+
+const toSaveResultResponse = (result: SharedHueAreYouResult): SaveHueAreYouResultResponse | null =>
+  result.status === 'succeeded' && result.hue && result.message && result.source
+    ? { id: result.id, hue: result.hue, message: result.message, source: result.source, cluster: result.cluster }
+    : null
+
+const generationFailed = (payload: SharedHueAreYouResult) =>
+  new ApiError({ status: 500, message: '結果の生成に失敗しました', payload, code: payload.failure })
+
+/**
+ * results/{id}/stream を購読し、生成中のメッセージを onMessage へ渡しながら結果を待つ。
+ * onMessage には届いたところまでのメッセージ全体を渡す。
+ * EventSource が使えない、または接続が切れた場合は null を返すので、呼び出し側で問い合わせに切り替える。
+ */
+export const streamHueAreYouResult = (
+  id: string,
+  onMessage: (message: string) => void,
+  options?: { signal?: AbortSignal }
+): Promise<SaveHueAreYouResultResponse | null> =>
+  new Promise((resolve, reject) => {
+    if (typeof EventSource === 'undefined') {
+      resolve(null)
+      return
+    }
+
+    const source = new EventSource(buildUrl(`hue-are-you/results/${encodeURIComponent(id)}/stream`))
+    let message = ''
+    const finish = (fn: () => void) => {
+      source.close()
+      options?.signal?.removeEventListener('abort', onAbort)
+      fn()
+    }
+    const onAbort = () => finish(() => reject(options?.signal?.reason))
+    options?.signal?.addEventListener('abort', onAbort, { once: true })
+
+    source.addEventListener('message', (event) => {
+      const data = safeJsonParse((event as MessageEvent<string>).data) as HueAreYouStreamMessage | null
+      if (!data) return
+      message += data.delta
+      onMessage(message)
+    })
+    source.addEventListener('reset', () => {
+      message = ''
+      onMessage(message)
+    })
+    source.addEventListener('status', (event) => {
+      const data = safeJsonParse((event as MessageEvent<string>).data) as SharedHueAreYouResult | null
+      if (data?.status === 'failed') {
+        finish(() => reject(generationFailed(data)))
+      }
+    })
+    source.addEventListener('result', (event) => {
+      const data = safeJsonParse((event as MessageEvent<string>).data) as SharedHueAreYouResult | null
+      const result = data ? toSaveResultResponse(data) : null
+      if (result) onMessage(result.message)
+      finish(() => resolve(result))
+    })
+    // 404 や切断、サーバー側の時間切れ。自動再接続はせず問い合わせに任せる。
+    source.addEventListener('error', () => finish(() => resolve(null)))
+  })
+
+/**
+ * save-result の Idempotency-Key を作る。crypto.randomUUID は安全なコンテキスト (https) にしか無いので、
+ * http で開かれたときは getRandomValues で同じ形式の UUID v4 を組み立てる。
+ */
+export const createIdempotencyKey = (): string => {
+  if (typeof crypto.randomUUID === 'function') return crypto.randomUUID()
+
+  const bytes = crypto.getRandomValues(new Uint8Array(16))
+  bytes[6] = (bytes[6] & 0x0f) | 0x40
+  bytes[8] = (bytes[8] & 0x3f) | 0x80
+  const hex = Array.from(bytes, (byte) => byte.toString(16).padStart(2, '0')).join('')
+  return `${hex.slice(0, 8)}-${hex.slice(8, 12)}-${hex.slice(12, 16)}-${hex.slice(16, 20)}-${hex.slice(20)}`
+}
+
+/**
+ * 回答を保存し、サーバー側の結果生成が終わるまで待って結果を返す。
+ * onMessage を渡すと SSE で生成中のメッセージを受け取り、使えなければ results/{id} の問い合わせで待つ。
+ * idempotencyKey は回答ごとに createIdempotencyKey で一度だけ作って回答と一緒に持ち、再送時に同じ値を渡す。
+ * サーバーは最初に受け付けた結果を返す。省略すると呼び出しごとに新しいキーになる。
+ * bypassCache に管理者のセッションを渡すと、結果キャッシュを使わずにモデルで生成し直させる。管理者以外では無視される。
+ */
 export const saveHueAreYouResult = async (
   payload: SaveHueAreYouResultPayload,
-  options?: { signal?: AbortSignal }
-): Promise<SaveHueAreYouResultResponse> =>
-  request<SaveHueAreYouResultResponse>('hue-are-you/save-result', {
+  options?: {
+    signal?: AbortSignal
+    onMessage?: (message: string) => void
+    bypassCache?: { session: SessionData }
+    idempotencyKey?: string
+  }
+): Promise<SaveHueAreYouResultResponse> => {
+  const headers: Record<string, string> = {
+    'Idempotency-Key': options?.idempotencyKey ?? createIdempotencyKey(),
+  }
+  if (options?.bypassCache) headers['X-Hue-Cache'] = 'bypass'
+
+  const job = await request<HueAreYouJobResponse>('hue-are-you/save-result', {
     method: 'POST',
     body: payload,
     signal: options?.signal,
+    session: options?.bypassCache?.session,
+    headers,
+  })
+
+  if (options?.onMessage) {
+    const streamed = await streamHueAreYouResult(job.id, options.onMessage, options)
+    if (streamed) return streamed
+  }
+
+  const deadline = Date.now() + RESULT_POLL_TIMEOUT_MS
+  while (Date.now() < deadline) {
+    const result = await fetchSharedHueAreYouResult(job.id, options)
+    const done = toSaveResultResponse(result)
+    if (done) return done
+    if (result.status === 'failed') {
+      throw generationFailed(result)
+    }
+    await wait(RESULT_POLL_INTERVAL_MS, options?.signal)
+  }
+
+  throw new ApiError({ status: 504, message: '結果の生成がタイムアウトしました', payload: job })
+}
+
+export const fetchSharedHueAreYouResult = async (
+  id: string,
+  options?: { signal?: AbortSignal }
+): Promise<SharedHueAreYouResult> =>
+  request<SharedHueAreYouResult>(`hue-are-you/results/${encodeURIComponent(id)}`, {
+    signal: options?.signal,
+  })
+
+/**
+ * 回答が似ている順に、結果の出た他の参加者を返す。limit を省略すると 5 人
+ */
+export const fetchSimilarHueAreYouResults = async (
+  id: string,
+  options?: { limit?: number; signal?: AbortSignal }
+): Promise<SimilarHueResultsResponse> =>
+  request<SimilarHueResultsResponse>(`hue-are-you/results/${encodeURIComponent(id)}/similar`, {
+    searchParams: { limit: options?.limit },
+    signal: options?.signal,
   })
 
 export const fetchHueAreYouRecords = async (
@@ -186,8 +449,121 @@ export const fetchHueAreYouRecords = async (
     body: {
       session: params.session,
       'data-range': params.dataRange,
+      cursor: params.cursor,
+      limit: params.limit,
+      filter: params.filter,
     },
     signal: options?.signal,
   })
 
+/**
+ * ログイン中のユーザー自身の回答を古い順に返す。next_cursor があれば続きを取れる
+ */
+export const fetchMyHueResults = async (
+  params: FetchMyHueResultsParams,
+  options?: { signal?: AbortSignal }
+): Promise<MyHueResultsResponse> =>
+  request<MyHueResultsResponse>('me/hue-results', {
+    session: params.session,
+    searchParams: { cursor: params.cursor, limit: params.limit },
+    signal: options?.signal,
+  })
+
+export const fetchHueAreYouStats = async (
+  params: FetchHueAreYouStatsParams,
+  options?: { signal?: AbortSignal }
+): Promise<HueAreYouStatsResponse> =>
+  request<HueAreYouStatsResponse>('hue-are-you/stats', {
+    session: params.session,
+    searchParams: { from: params.from, to: params.to },
+    signal: options?.signal,
+  })
+
+export const fetchHueUsage = async (
+  params: FetchHueUsageParams,
+  options?: { signal?: AbortSignal }
+): Promise<HueUsageResponse> =>
+  request<HueUsageResponse>('hue-are-you/usage', {
+    session: params.session,
+    searchParams: { from: params.from, to: params.to },
+    signal: options?.signal,
+  })
+
+export const fetchHueCacheStats = async (
+  session: SessionData,
+  options?: { signal?: AbortSignal }
+): Promise<HueCacheStatsResponse> =>
+  request<HueCacheStatsResponse>('hue-are-you/cache', { session, signal: options?.signal })
+
+export const fetchHueClusters = async (
+  session: SessionData,
+  options?: { signal?: AbortSignal }
+): Promise<HueClustersResponse> =>
+  request<HueClustersResponse>('hue-are-you/clusters', { session, signal: options?.signal })
+
+export const fetchHuePrompts = async (
+  session: SessionData,
+  options?: { signal?: AbortSignal }
+): Promise<HuePromptListResponse> =>
+  request<HuePromptListResponse>('hue-are-you/prompts', { session, signal: options?.signal })
+
+export const createHuePrompt = async (
+  session: SessionData,
+  payload: CreateHuePromptPayload,
+  options?: { signal?: AbortSignal }
+): Promise<HuePromptVersion> =>
+  request<HuePromptVersion>('hue-are-you/prompts', {
+    method: 'POST',
+    session,
+    body: payload,
+    signal: options?.signal,
+  })
+
+export const activateHuePrompt = async (
+  session: SessionData,
+  version: string,
+  payload: ActivateHuePromptPayload = {},
+  options?: { signal?: AbortSignal }
+): Promise<HuePromptVersion> =>
+  request<HuePromptVersion>(`hue-are-you/prompts/${encodeURIComponent(version)}/activate`, {
+    method: 'POST',
+    session,
+    body: payload,
+    signal: options?.signal,
+  })
+
+export const exportHueAreYouRecords = async (
+  params: ExportHueAreYouRecordsParams,
+  options?: { signal?: AbortSignal }
+): Promise<Blob> => {
+  const { filter } = params
+  const url = new URL(
+    buildUrl('hue-are-you/export', {
+      format: params.format,
+      from: filter?.from,
+      to: filter?.to,
+      name_contains: filter?.name_contains,
+      min_words: filter?.min_words,
+      bom: params.bom === undefined ? undefined : String(params.bom),
+    })
+  )
+  Object.entries(filter?.choices ?? {}).forEach(([word, color]) => {
+    url.searchParams.append('choice', `${word}:${color}`)
+  })
+
+  const response = await fetch(url.toString(), {
+    headers: { Authorization: bearerHeader(params.session) },
+    signal: options?.signal,
+  })
+
+  if (!response.ok) {
+    throw new ApiError({
+      status: response.status,
+      message: `API request failed with status ${response.status}`,
+    })
+  }
+
+  return response.blob()
+}
+
 export * from './types'
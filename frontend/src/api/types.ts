@@ -1,6 +1,9 @@
 export interface HueAreYouRecord {
   name: string
   choice: Record<string, string>
+  /** 回答した質問票の版。保存時に省略すると現行の版として扱われる */
+  questionnaire_version?: string
+  result?: HueAreYouRecordResult
 }
 
 export interface HueValue {
@@ -9,9 +12,37 @@ export interface HueValue {
   b: number
 }
 
+export type HueResultSource = 'model' | 'fallback'
+
 export interface HueAreYouResultResponse {
+  id: string
   hue: HueValue
   message: string
+  source: HueResultSource
+  /** 回答に最も近い「色の性格」。クラスタリング前やストリームで受け取った場合は無い */
+  cluster?: HueClusterSummary
+}
+
+/** クラスタ内で weight の割合の人が word に color を選んだ */
+export interface HueClusterAssociation {
+  word: string
+  color: string
+  weight: number
+}
+
+export interface HueClusterSummary {
+  index: number
+  associations: HueClusterAssociation[]
+}
+
+export interface HueAreYouRecordResult {
+  hue: HueValue
+  message: string
+  source: HueResultSource
+  generator: string
+  prompt_version?: string
+  latency_ms: number
+  generated_at: string
 }
 
 export type UserRole = 'admin' | 'user'
@@ -21,10 +52,37 @@ export interface SessionData {
   token: string
 }
 
+/**
+ * ログイン・サインイン・更新のレスポンス。
+ * サーバーがクッキーモードなら token と refresh_token は省略され、代わりに csrf_token が入る
+ */
 export interface SessionResponce {
   user_id: string
   token: string
   role: UserRole
+  /** セッションが切れたら refreshSession で作り直す。使うたびに新しい値に変わる */
+  refresh_token?: string
+  /** クッキーモードのときだけ入る。api が GET 以外のリクエストに X-CSRF-Token として付ける */
+  csrf_token?: string
+}
+
+/** /api/sessions のセッション 1 件。トークンは含まない */
+export interface SessionInfo {
+  id: string
+  created_at: string
+  expires_at: string
+  last_used_at?: string
+  user_agent: string
+  ip_address: string
+  current: boolean
+}
+
+export interface SessionsResponse {
+  sessions: SessionInfo[]
+}
+
+export interface RevokeSessionsResponse {
+  revoked: number
 }
 
 export interface LoginPayload {
@@ -38,14 +96,234 @@ export interface SignInPayload {
   password: string
 }
 
-export type SaveHueAreYouResultPayload = HueAreYouRecord
+export type SaveHueAreYouResultPayload = HueAreYouRecord & {
+  share_choices?: boolean
+  /** ログイン中なら付ける。回答がそのユーザーに紐付き、/me/hue-results で見られる */
+  session?: SessionData
+}
 export type SaveHueAreYouResultResponse = HueAreYouResultResponse
 
+export type HueGenerationStatus = 'pending' | 'running' | 'succeeded' | 'failed'
+
+/** 再試行待ちや失敗した生成の直近の原因 */
+export type HueGenerationFailure = 'rate_limited' | 'timeout' | 'unavailable' | 'rejected' | 'internal'
+
+/** save-result が 202 で返す受付結果。id で results/{id} を問い合わせる */
+export interface HueAreYouJobResponse {
+  id: string
+  status: HueGenerationStatus
+}
+
+/** results/{id}/stream の message イベント。届いた順に連結するとメッセージになる */
+export interface HueAreYouStreamMessage {
+  delta: string
+}
+
+/** status が succeeded のときだけ hue / message / source が入る */
+export interface SharedHueAreYouResult {
+  id: string
+  status: HueGenerationStatus
+  name: string
+  hue?: HueValue
+  message?: string
+  source?: HueResultSource
+  choice?: Record<string, string>
+  cluster?: HueClusterSummary
+  failure?: HueGenerationFailure
+}
+
+export interface HueClusterExemplar {
+  id: string
+  name: string
+  created_at: string
+  hue?: HueValue
+}
+
+export interface HueCluster {
+  index: number
+  size: number
+  associations: HueClusterAssociation[]
+  exemplars: HueClusterExemplar[]
+}
+
+/** クラスタは大きい順 */
+export interface HueClustersResponse {
+  created_at: string
+  record_count: number
+  clusters: HueCluster[]
+}
+
+/** 回答が似た、回答の公開を許可した参加者。名前やメッセージは含まない。similarity は 0〜1 で、1 なら回答がすべて同じ */
+export interface SimilarHueResult {
+  hue: HueValue
+  matches: number
+  similarity: number
+}
+
+export interface SimilarHueResultsResponse {
+  results: SimilarHueResult[]
+}
+
+export interface HueAreYouRecordFilter {
+  /** RFC3339 か YYYY-MM-DD */
+  from?: string
+  to?: string
+  name_contains?: string
+  /** 語彙 → 選んだ色。すべてを満たすレコードに絞る */
+  choices?: Record<string, string>
+  min_words?: number
+}
+
 export interface FetchHueAreYouDataParams {
   session: SessionData
-  dataRange: [number, number]
+  filter?: HueAreYouRecordFilter
+  /** 旧来の位置指定。指定すると cursor/limit より優先される */
+  dataRange?: [number, number]
+  cursor?: string
+  limit?: number
 }
 
 export interface HueAreYouDataResponse {
   records: HueAreYouRecord[]
+  /** 続きがある場合のみ返る。次の呼び出しの cursor に渡す */
+  next_cursor?: string
+}
+
+export interface FetchMyHueResultsParams {
+  session: SessionData
+  cursor?: string
+  limit?: number
+}
+
+/** ログイン中のユーザー自身の過去の回答。id で結果ページを開ける */
+export type MyHueResult = HueAreYouRecord & {
+  id: string
+  created_at: string
+}
+
+export interface MyHueResultsResponse {
+  results: MyHueResult[]
+  next_cursor?: string
+}
+
+export interface FetchHueAreYouStatsParams {
+  session: SessionData
+  from?: string
+  to?: string
+}
+
+export interface HueColorShare {
+  color: string
+  count: number
+  share: number
+}
+
+export interface HueWordStats {
+  word: string
+  total: number
+  counts: Record<string, number>
+  most_typical: HueColorShare
+  least_typical: HueColorShare
+}
+
+export interface HueAreYouStatsResponse {
+  from?: string
+  to?: string
+  participants: number
+  words: HueWordStats[]
+}
+
+export interface FetchHueUsageParams {
+  session: SessionData
+  from?: string
+  to?: string
+}
+
+export interface HueUsageModel {
+  model: string
+  requests: number
+  input_tokens: number
+  output_tokens: number
+  /** 単価表に無いモデルでは省略される */
+  estimated_cost_usd?: number
+}
+
+/** 1 日 (UTC) ぶんの LLM の使用量 */
+export interface HueUsageDay {
+  /** YYYY-MM-DD */
+  date: string
+  requests: number
+  input_tokens: number
+  output_tokens: number
+  estimated_cost_usd: number
+  models: HueUsageModel[]
+}
+
+export interface HueUsageResponse {
+  from?: string
+  to?: string
+  days: HueUsageDay[]
+  total_cost_usd: number
+  /** 1 日の予算。超えると LLM を呼ばずにルールベースで結果を作る。予算が無ければ省略される */
+  daily_budget_usd?: number
+}
+
+/** 結果キャッシュの当たり外れ。回数はサーバーの起動からの値 */
+export interface HueCacheStatsResponse {
+  memory_hits: number
+  store_hits: number
+  misses: number
+  bypasses: number
+  hit_ratio: number
+  memory_entries: number
+  store_entries: number
+}
+
+export type HueAreYouExportFormat = 'csv' | 'csv-long' | 'ndjson'
+
+export interface ExportHueAreYouRecordsParams {
+  session: SessionData
+  format?: HueAreYouExportFormat
+  filter?: HueAreYouRecordFilter
+  /** false で CSV の BOM を外す (既定は Excel 向けに付ける) */
+  bom?: boolean
+}
+
+export interface HueAreYouPaletteColor {
+  name: string
+  hex: string
+}
+
+export interface HueAreYouQuestionnaire {
+  version: string
+  words: string[]
+  palette: HueAreYouPaletteColor[]
+}
+
+/** 結果生成のプロンプトの版。テンプレートは Go の text/template で、.Name と .Choices (.Word, .Color) を参照できる */
+export interface HuePromptVersion {
+  version: string
+  system_template: string
+  user_template: string
+  weight: number
+  active: boolean
+  created_at: string
+  activated_at?: string
+}
+
+export interface HuePromptListResponse {
+  prompts: HuePromptVersion[]
+}
+
+export interface CreateHuePromptPayload {
+  version: string
+  system_template: string
+  user_template: string
+}
+
+export interface ActivateHuePromptPayload {
+  /** 省略すると 100 */
+  weight?: number
+  /** true なら他の版を割り当て対象から外す */
+  exclusive?: boolean
 }
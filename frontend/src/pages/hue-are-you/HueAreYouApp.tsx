@@ -1,5 +1,5 @@
-import React, { useState } from 'react'
-import { saveHueAreYouResult, type SaveHueAreYouResultResponse } from '../../api'
+import React, { useRef, useState } from 'react'
+import { createIdempotencyKey, saveHueAreYouResult, type SaveHueAreYouResultResponse } from '../../api'
 import StartScreen from './user/StartScreen'
 import SelectionScreen from './user/SelectionScreen'
 import ResultScreen from './user/ResultScreen'
@@ -7,16 +7,25 @@ import './HueAreYouApp.css'
 
 type Screen = 'start' | 'selection' | 'result'
 
+// 保存を送った回答。同じ名前での再送 (二度押しやリトライ) には同じ Idempotency-Key を使う。
+type Submission = {
+  name: string
+  idempotencyKey: string
+}
+
 const HueAreYouApp: React.FC = () => {
   const [currentScreen, setCurrentScreen] = useState<Screen>('start')
   const [assignments, setAssignments] = useState<Record<string, string>>({})
   const [userName, setUserName] = useState('')
+  // 二度押しでも同じキーになるよう、描画を待たずに読めるところに持つ。
+  const submissionRef = useRef<Submission | null>(null)
 
   const handleStart = () => {
     setCurrentScreen('selection')
   }
 
   const handleComplete = (results: Record<string, string>) => {
+    submissionRef.current = null
     setAssignments(results)
     setCurrentScreen('result')
   }
@@ -31,15 +40,26 @@ const HueAreYouApp: React.FC = () => {
 
     setUserName(normalizedName)
 
-    const response = await saveHueAreYouResult({
-      name: normalizedName,
-      choice: assignments,
-    })
+    // 名前を変えると本文が変わるので、別の回答として新しいキーを作る。
+    let submission = submissionRef.current
+    if (!submission || submission.name !== normalizedName) {
+      submission = { name: normalizedName, idempotencyKey: createIdempotencyKey() }
+      submissionRef.current = submission
+    }
+
+    const response = await saveHueAreYouResult(
+      {
+        name: normalizedName,
+        choice: assignments,
+      },
+      { idempotencyKey: submission.idempotencyKey }
+    )
 
     return response
   }
 
   const handleRestart = () => {
+    submissionRef.current = null
     setAssignments({})
     setUserName('')
     setCurrentScreen('start')